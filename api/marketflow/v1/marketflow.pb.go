@@ -0,0 +1,1041 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: marketflow/v1/marketflow.proto
+
+package marketflowv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Mode int32
+
+const (
+	Mode_MODE_UNSPECIFIED Mode = 0
+	Mode_MODE_LIVE        Mode = 1
+	Mode_MODE_TEST        Mode = 2
+)
+
+// Enum value maps for Mode.
+var (
+	Mode_name = map[int32]string{
+		0: "MODE_UNSPECIFIED",
+		1: "MODE_LIVE",
+		2: "MODE_TEST",
+	}
+	Mode_value = map[string]int32{
+		"MODE_UNSPECIFIED": 0,
+		"MODE_LIVE":        1,
+		"MODE_TEST":        2,
+	}
+)
+
+func (x Mode) Enum() *Mode {
+	p := new(Mode)
+	*p = x
+	return p
+}
+
+func (x Mode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Mode) Descriptor() protoreflect.EnumDescriptor {
+	return file_marketflow_v1_marketflow_proto_enumTypes[0].Descriptor()
+}
+
+func (Mode) Type() protoreflect.EnumType {
+	return &file_marketflow_v1_marketflow_proto_enumTypes[0]
+}
+
+func (x Mode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Mode.Descriptor instead.
+func (Mode) EnumDescriptor() ([]byte, []int) {
+	return file_marketflow_v1_marketflow_proto_rawDescGZIP(), []int{0}
+}
+
+// PriceRequest selects a pair and, except for the latest price, a time range.
+// The range follows the REST period, from and to parameters.
+type PriceRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Symbol   string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Exchange string                 `protobuf:"bytes,2,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	Period   string                 `protobuf:"bytes,3,opt,name=period,proto3" json:"period,omitempty"`
+	From     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=from,proto3" json:"from,omitempty"`
+	To       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=to,proto3" json:"to,omitempty"`
+	// max_age makes GetLatestPrice fail with UNAVAILABLE when the latest tick
+	// is older.
+	MaxAge        *durationpb.Duration `protobuf:"bytes,6,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PriceRequest) Reset() {
+	*x = PriceRequest{}
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PriceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceRequest) ProtoMessage() {}
+
+func (x *PriceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceRequest.ProtoReflect.Descriptor instead.
+func (*PriceRequest) Descriptor() ([]byte, []int) {
+	return file_marketflow_v1_marketflow_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PriceRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *PriceRequest) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *PriceRequest) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *PriceRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *PriceRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *PriceRequest) GetMaxAge() *durationpb.Duration {
+	if x != nil {
+		return x.MaxAge
+	}
+	return nil
+}
+
+type PriceResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Symbol    string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Exchange  string                 `protobuf:"bytes,2,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	Price     float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+	From      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=from,proto3" json:"from,omitempty"`
+	To        *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=to,proto3" json:"to,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// data_timestamp, age_ms and stale are only set for the latest price.
+	// data_timestamp is the time of the tick.
+	DataTimestamp *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=data_timestamp,json=dataTimestamp,proto3" json:"data_timestamp,omitempty"`
+	AgeMs         int64                  `protobuf:"varint,8,opt,name=age_ms,json=ageMs,proto3" json:"age_ms,omitempty"`
+	Stale         bool                   `protobuf:"varint,9,opt,name=stale,proto3" json:"stale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PriceResponse) Reset() {
+	*x = PriceResponse{}
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PriceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceResponse) ProtoMessage() {}
+
+func (x *PriceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceResponse.ProtoReflect.Descriptor instead.
+func (*PriceResponse) Descriptor() ([]byte, []int) {
+	return file_marketflow_v1_marketflow_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PriceResponse) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *PriceResponse) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *PriceResponse) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *PriceResponse) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *PriceResponse) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *PriceResponse) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *PriceResponse) GetDataTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DataTimestamp
+	}
+	return nil
+}
+
+func (x *PriceResponse) GetAgeMs() int64 {
+	if x != nil {
+		return x.AgeMs
+	}
+	return 0
+}
+
+func (x *PriceResponse) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+type StatsResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Symbol   string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Exchange string                 `protobuf:"bytes,2,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	From     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	To       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	// start and end bound the data that was actually found.
+	Start         *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=start,proto3" json:"start,omitempty"`
+	End           *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=end,proto3" json:"end,omitempty"`
+	Min           float64                `protobuf:"fixed64,7,opt,name=min,proto3" json:"min,omitempty"`
+	Max           float64                `protobuf:"fixed64,8,opt,name=max,proto3" json:"max,omitempty"`
+	Average       float64                `protobuf:"fixed64,9,opt,name=average,proto3" json:"average,omitempty"`
+	First         float64                `protobuf:"fixed64,10,opt,name=first,proto3" json:"first,omitempty"`
+	Last          float64                `protobuf:"fixed64,11,opt,name=last,proto3" json:"last,omitempty"`
+	Count         int64                  `protobuf:"varint,12,opt,name=count,proto3" json:"count,omitempty"`
+	ChangePct     float64                `protobuf:"fixed64,13,opt,name=change_pct,json=changePct,proto3" json:"change_pct,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_marketflow_v1_marketflow_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StatsResponse) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *StatsResponse) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *StatsResponse) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetAverage() float64 {
+	if x != nil {
+		return x.Average
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetFirst() float64 {
+	if x != nil {
+		return x.First
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLast() float64 {
+	if x != nil {
+		return x.Last
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetChangePct() float64 {
+	if x != nil {
+		return x.ChangePct
+	}
+	return 0
+}
+
+type CandlesRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Symbol   string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Exchange string                 `protobuf:"bytes,2,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	// interval is 1m, 5m, 1h or 1d; 1m when empty.
+	Interval      string `protobuf:"bytes,3,opt,name=interval,proto3" json:"interval,omitempty"`
+	Limit         int32  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CandlesRequest) Reset() {
+	*x = CandlesRequest{}
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CandlesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CandlesRequest) ProtoMessage() {}
+
+func (x *CandlesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CandlesRequest.ProtoReflect.Descriptor instead.
+func (*CandlesRequest) Descriptor() ([]byte, []int) {
+	return file_marketflow_v1_marketflow_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CandlesRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *CandlesRequest) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *CandlesRequest) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *CandlesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type Candle struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	Open          float64                `protobuf:"fixed64,2,opt,name=open,proto3" json:"open,omitempty"`
+	High          float64                `protobuf:"fixed64,3,opt,name=high,proto3" json:"high,omitempty"`
+	Low           float64                `protobuf:"fixed64,4,opt,name=low,proto3" json:"low,omitempty"`
+	Close         float64                `protobuf:"fixed64,5,opt,name=close,proto3" json:"close,omitempty"`
+	Average       float64                `protobuf:"fixed64,6,opt,name=average,proto3" json:"average,omitempty"`
+	TickCount     int64                  `protobuf:"varint,7,opt,name=tick_count,json=tickCount,proto3" json:"tick_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Candle) Reset() {
+	*x = Candle{}
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Candle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Candle) ProtoMessage() {}
+
+func (x *Candle) ProtoReflect() protoreflect.Message {
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Candle.ProtoReflect.Descriptor instead.
+func (*Candle) Descriptor() ([]byte, []int) {
+	return file_marketflow_v1_marketflow_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Candle) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *Candle) GetOpen() float64 {
+	if x != nil {
+		return x.Open
+	}
+	return 0
+}
+
+func (x *Candle) GetHigh() float64 {
+	if x != nil {
+		return x.High
+	}
+	return 0
+}
+
+func (x *Candle) GetLow() float64 {
+	if x != nil {
+		return x.Low
+	}
+	return 0
+}
+
+func (x *Candle) GetClose() float64 {
+	if x != nil {
+		return x.Close
+	}
+	return 0
+}
+
+func (x *Candle) GetAverage() float64 {
+	if x != nil {
+		return x.Average
+	}
+	return 0
+}
+
+func (x *Candle) GetTickCount() int64 {
+	if x != nil {
+		return x.TickCount
+	}
+	return 0
+}
+
+type CandlesResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Symbol   string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Exchange string                 `protobuf:"bytes,2,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	Interval string                 `protobuf:"bytes,3,opt,name=interval,proto3" json:"interval,omitempty"`
+	// candles are ordered oldest first.
+	Candles       []*Candle `protobuf:"bytes,4,rep,name=candles,proto3" json:"candles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CandlesResponse) Reset() {
+	*x = CandlesResponse{}
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CandlesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CandlesResponse) ProtoMessage() {}
+
+func (x *CandlesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CandlesResponse.ProtoReflect.Descriptor instead.
+func (*CandlesResponse) Descriptor() ([]byte, []int) {
+	return file_marketflow_v1_marketflow_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CandlesResponse) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *CandlesResponse) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *CandlesResponse) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *CandlesResponse) GetCandles() []*Candle {
+	if x != nil {
+		return x.Candles
+	}
+	return nil
+}
+
+type SetModeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mode          Mode                   `protobuf:"varint,1,opt,name=mode,proto3,enum=marketflow.v1.Mode" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetModeRequest) Reset() {
+	*x = SetModeRequest{}
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetModeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetModeRequest) ProtoMessage() {}
+
+func (x *SetModeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetModeRequest.ProtoReflect.Descriptor instead.
+func (*SetModeRequest) Descriptor() ([]byte, []int) {
+	return file_marketflow_v1_marketflow_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SetModeRequest) GetMode() Mode {
+	if x != nil {
+		return x.Mode
+	}
+	return Mode_MODE_UNSPECIFIED
+}
+
+type SetModeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mode          Mode                   `protobuf:"varint,1,opt,name=mode,proto3,enum=marketflow.v1.Mode" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetModeResponse) Reset() {
+	*x = SetModeResponse{}
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetModeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetModeResponse) ProtoMessage() {}
+
+func (x *SetModeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetModeResponse.ProtoReflect.Descriptor instead.
+func (*SetModeResponse) Descriptor() ([]byte, []int) {
+	return file_marketflow_v1_marketflow_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SetModeResponse) GetMode() Mode {
+	if x != nil {
+		return x.Mode
+	}
+	return Mode_MODE_UNSPECIFIED
+}
+
+type SubscribePricesRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Symbols []string               `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	// exchanges defaults to every real exchange; "global" has to be named.
+	Exchanges []string `protobuf:"bytes,2,rep,name=exchanges,proto3" json:"exchanges,omitempty"`
+	// rate conflates each pair to at most rate ticks per second; 0 sends every
+	// tick.
+	Rate          uint32 `protobuf:"varint,3,opt,name=rate,proto3" json:"rate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribePricesRequest) Reset() {
+	*x = SubscribePricesRequest{}
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribePricesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribePricesRequest) ProtoMessage() {}
+
+func (x *SubscribePricesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribePricesRequest.ProtoReflect.Descriptor instead.
+func (*SubscribePricesRequest) Descriptor() ([]byte, []int) {
+	return file_marketflow_v1_marketflow_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SubscribePricesRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+func (x *SubscribePricesRequest) GetExchanges() []string {
+	if x != nil {
+		return x.Exchanges
+	}
+	return nil
+}
+
+func (x *SubscribePricesRequest) GetRate() uint32 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+type Tick struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Exchange      string                 `protobuf:"bytes,2,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	Price         float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tick) Reset() {
+	*x = Tick{}
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tick) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tick) ProtoMessage() {}
+
+func (x *Tick) ProtoReflect() protoreflect.Message {
+	mi := &file_marketflow_v1_marketflow_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tick.ProtoReflect.Descriptor instead.
+func (*Tick) Descriptor() ([]byte, []int) {
+	return file_marketflow_v1_marketflow_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Tick) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Tick) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *Tick) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Tick) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+var File_marketflow_v1_marketflow_proto protoreflect.FileDescriptor
+
+const file_marketflow_v1_marketflow_proto_rawDesc = "" +
+	"\n" +
+	"\x1emarketflow/v1/marketflow.proto\x12\rmarketflow.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xea\x01\n" +
+	"\fPriceRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\bexchange\x18\x02 \x01(\tR\bexchange\x12\x16\n" +
+	"\x06period\x18\x03 \x01(\tR\x06period\x12.\n" +
+	"\x04from\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x122\n" +
+	"\amax_age\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\x06maxAge\"\xdf\x02\n" +
+	"\rPriceResponse\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\bexchange\x18\x02 \x01(\tR\bexchange\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x01R\x05price\x12.\n" +
+	"\x04from\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x128\n" +
+	"\ttimestamp\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12A\n" +
+	"\x0edata_timestamp\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\rdataTimestamp\x12\x15\n" +
+	"\x06age_ms\x18\b \x01(\x03R\x05ageMs\x12\x14\n" +
+	"\x05stale\x18\t \x01(\bR\x05stale\"\x9c\x03\n" +
+	"\rStatsResponse\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\bexchange\x18\x02 \x01(\tR\bexchange\x12.\n" +
+	"\x04from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x120\n" +
+	"\x05start\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12,\n" +
+	"\x03end\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\x12\x10\n" +
+	"\x03min\x18\a \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\b \x01(\x01R\x03max\x12\x18\n" +
+	"\aaverage\x18\t \x01(\x01R\aaverage\x12\x14\n" +
+	"\x05first\x18\n" +
+	" \x01(\x01R\x05first\x12\x12\n" +
+	"\x04last\x18\v \x01(\x01R\x04last\x12\x14\n" +
+	"\x05count\x18\f \x01(\x03R\x05count\x12\x1d\n" +
+	"\n" +
+	"change_pct\x18\r \x01(\x01R\tchangePct\"v\n" +
+	"\x0eCandlesRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\bexchange\x18\x02 \x01(\tR\bexchange\x12\x1a\n" +
+	"\binterval\x18\x03 \x01(\tR\binterval\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"\xc3\x01\n" +
+	"\x06Candle\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12\x12\n" +
+	"\x04open\x18\x02 \x01(\x01R\x04open\x12\x12\n" +
+	"\x04high\x18\x03 \x01(\x01R\x04high\x12\x10\n" +
+	"\x03low\x18\x04 \x01(\x01R\x03low\x12\x14\n" +
+	"\x05close\x18\x05 \x01(\x01R\x05close\x12\x18\n" +
+	"\aaverage\x18\x06 \x01(\x01R\aaverage\x12\x1d\n" +
+	"\n" +
+	"tick_count\x18\a \x01(\x03R\ttickCount\"\x92\x01\n" +
+	"\x0fCandlesResponse\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\bexchange\x18\x02 \x01(\tR\bexchange\x12\x1a\n" +
+	"\binterval\x18\x03 \x01(\tR\binterval\x12/\n" +
+	"\acandles\x18\x04 \x03(\v2\x15.marketflow.v1.CandleR\acandles\"9\n" +
+	"\x0eSetModeRequest\x12'\n" +
+	"\x04mode\x18\x01 \x01(\x0e2\x13.marketflow.v1.ModeR\x04mode\":\n" +
+	"\x0fSetModeResponse\x12'\n" +
+	"\x04mode\x18\x01 \x01(\x0e2\x13.marketflow.v1.ModeR\x04mode\"d\n" +
+	"\x16SubscribePricesRequest\x12\x18\n" +
+	"\asymbols\x18\x01 \x03(\tR\asymbols\x12\x1c\n" +
+	"\texchanges\x18\x02 \x03(\tR\texchanges\x12\x12\n" +
+	"\x04rate\x18\x03 \x01(\rR\x04rate\"\x8a\x01\n" +
+	"\x04Tick\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\bexchange\x18\x02 \x01(\tR\bexchange\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x01R\x05price\x128\n" +
+	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp*:\n" +
+	"\x04Mode\x12\x14\n" +
+	"\x10MODE_UNSPECIFIED\x10\x00\x12\r\n" +
+	"\tMODE_LIVE\x10\x01\x12\r\n" +
+	"\tMODE_TEST\x10\x022\xf1\x04\n" +
+	"\n" +
+	"MarketFlow\x12K\n" +
+	"\x0eGetLatestPrice\x12\x1b.marketflow.v1.PriceRequest\x1a\x1c.marketflow.v1.PriceResponse\x12L\n" +
+	"\x0fGetHighestPrice\x12\x1b.marketflow.v1.PriceRequest\x1a\x1c.marketflow.v1.PriceResponse\x12K\n" +
+	"\x0eGetLowestPrice\x12\x1b.marketflow.v1.PriceRequest\x1a\x1c.marketflow.v1.PriceResponse\x12L\n" +
+	"\x0fGetAveragePrice\x12\x1b.marketflow.v1.PriceRequest\x1a\x1c.marketflow.v1.PriceResponse\x12E\n" +
+	"\bGetStats\x12\x1b.marketflow.v1.PriceRequest\x1a\x1c.marketflow.v1.StatsResponse\x12K\n" +
+	"\n" +
+	"GetCandles\x12\x1d.marketflow.v1.CandlesRequest\x1a\x1e.marketflow.v1.CandlesResponse\x12H\n" +
+	"\aSetMode\x12\x1d.marketflow.v1.SetModeRequest\x1a\x1e.marketflow.v1.SetModeResponse\x12O\n" +
+	"\x0fSubscribePrices\x12%.marketflow.v1.SubscribePricesRequest\x1a\x13.marketflow.v1.Tick0\x01B+Z)marketflow/api/marketflow/v1;marketflowv1b\x06proto3"
+
+var (
+	file_marketflow_v1_marketflow_proto_rawDescOnce sync.Once
+	file_marketflow_v1_marketflow_proto_rawDescData []byte
+)
+
+func file_marketflow_v1_marketflow_proto_rawDescGZIP() []byte {
+	file_marketflow_v1_marketflow_proto_rawDescOnce.Do(func() {
+		file_marketflow_v1_marketflow_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_marketflow_v1_marketflow_proto_rawDesc), len(file_marketflow_v1_marketflow_proto_rawDesc)))
+	})
+	return file_marketflow_v1_marketflow_proto_rawDescData
+}
+
+var file_marketflow_v1_marketflow_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_marketflow_v1_marketflow_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_marketflow_v1_marketflow_proto_goTypes = []any{
+	(Mode)(0),                      // 0: marketflow.v1.Mode
+	(*PriceRequest)(nil),           // 1: marketflow.v1.PriceRequest
+	(*PriceResponse)(nil),          // 2: marketflow.v1.PriceResponse
+	(*StatsResponse)(nil),          // 3: marketflow.v1.StatsResponse
+	(*CandlesRequest)(nil),         // 4: marketflow.v1.CandlesRequest
+	(*Candle)(nil),                 // 5: marketflow.v1.Candle
+	(*CandlesResponse)(nil),        // 6: marketflow.v1.CandlesResponse
+	(*SetModeRequest)(nil),         // 7: marketflow.v1.SetModeRequest
+	(*SetModeResponse)(nil),        // 8: marketflow.v1.SetModeResponse
+	(*SubscribePricesRequest)(nil), // 9: marketflow.v1.SubscribePricesRequest
+	(*Tick)(nil),                   // 10: marketflow.v1.Tick
+	(*timestamppb.Timestamp)(nil),  // 11: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),    // 12: google.protobuf.Duration
+}
+var file_marketflow_v1_marketflow_proto_depIdxs = []int32{
+	11, // 0: marketflow.v1.PriceRequest.from:type_name -> google.protobuf.Timestamp
+	11, // 1: marketflow.v1.PriceRequest.to:type_name -> google.protobuf.Timestamp
+	12, // 2: marketflow.v1.PriceRequest.max_age:type_name -> google.protobuf.Duration
+	11, // 3: marketflow.v1.PriceResponse.from:type_name -> google.protobuf.Timestamp
+	11, // 4: marketflow.v1.PriceResponse.to:type_name -> google.protobuf.Timestamp
+	11, // 5: marketflow.v1.PriceResponse.timestamp:type_name -> google.protobuf.Timestamp
+	11, // 6: marketflow.v1.PriceResponse.data_timestamp:type_name -> google.protobuf.Timestamp
+	11, // 7: marketflow.v1.StatsResponse.from:type_name -> google.protobuf.Timestamp
+	11, // 8: marketflow.v1.StatsResponse.to:type_name -> google.protobuf.Timestamp
+	11, // 9: marketflow.v1.StatsResponse.start:type_name -> google.protobuf.Timestamp
+	11, // 10: marketflow.v1.StatsResponse.end:type_name -> google.protobuf.Timestamp
+	11, // 11: marketflow.v1.Candle.start:type_name -> google.protobuf.Timestamp
+	5,  // 12: marketflow.v1.CandlesResponse.candles:type_name -> marketflow.v1.Candle
+	0,  // 13: marketflow.v1.SetModeRequest.mode:type_name -> marketflow.v1.Mode
+	0,  // 14: marketflow.v1.SetModeResponse.mode:type_name -> marketflow.v1.Mode
+	11, // 15: marketflow.v1.Tick.timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 16: marketflow.v1.MarketFlow.GetLatestPrice:input_type -> marketflow.v1.PriceRequest
+	1,  // 17: marketflow.v1.MarketFlow.GetHighestPrice:input_type -> marketflow.v1.PriceRequest
+	1,  // 18: marketflow.v1.MarketFlow.GetLowestPrice:input_type -> marketflow.v1.PriceRequest
+	1,  // 19: marketflow.v1.MarketFlow.GetAveragePrice:input_type -> marketflow.v1.PriceRequest
+	1,  // 20: marketflow.v1.MarketFlow.GetStats:input_type -> marketflow.v1.PriceRequest
+	4,  // 21: marketflow.v1.MarketFlow.GetCandles:input_type -> marketflow.v1.CandlesRequest
+	7,  // 22: marketflow.v1.MarketFlow.SetMode:input_type -> marketflow.v1.SetModeRequest
+	9,  // 23: marketflow.v1.MarketFlow.SubscribePrices:input_type -> marketflow.v1.SubscribePricesRequest
+	2,  // 24: marketflow.v1.MarketFlow.GetLatestPrice:output_type -> marketflow.v1.PriceResponse
+	2,  // 25: marketflow.v1.MarketFlow.GetHighestPrice:output_type -> marketflow.v1.PriceResponse
+	2,  // 26: marketflow.v1.MarketFlow.GetLowestPrice:output_type -> marketflow.v1.PriceResponse
+	2,  // 27: marketflow.v1.MarketFlow.GetAveragePrice:output_type -> marketflow.v1.PriceResponse
+	3,  // 28: marketflow.v1.MarketFlow.GetStats:output_type -> marketflow.v1.StatsResponse
+	6,  // 29: marketflow.v1.MarketFlow.GetCandles:output_type -> marketflow.v1.CandlesResponse
+	8,  // 30: marketflow.v1.MarketFlow.SetMode:output_type -> marketflow.v1.SetModeResponse
+	10, // 31: marketflow.v1.MarketFlow.SubscribePrices:output_type -> marketflow.v1.Tick
+	24, // [24:32] is the sub-list for method output_type
+	16, // [16:24] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_marketflow_v1_marketflow_proto_init() }
+func file_marketflow_v1_marketflow_proto_init() {
+	if File_marketflow_v1_marketflow_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_marketflow_v1_marketflow_proto_rawDesc), len(file_marketflow_v1_marketflow_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_marketflow_v1_marketflow_proto_goTypes,
+		DependencyIndexes: file_marketflow_v1_marketflow_proto_depIdxs,
+		EnumInfos:         file_marketflow_v1_marketflow_proto_enumTypes,
+		MessageInfos:      file_marketflow_v1_marketflow_proto_msgTypes,
+	}.Build()
+	File_marketflow_v1_marketflow_proto = out.File
+	file_marketflow_v1_marketflow_proto_goTypes = nil
+	file_marketflow_v1_marketflow_proto_depIdxs = nil
+}
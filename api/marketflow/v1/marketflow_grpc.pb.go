@@ -0,0 +1,399 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             (unknown)
+// source: marketflow/v1/marketflow.proto
+
+package marketflowv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MarketFlow_GetLatestPrice_FullMethodName  = "/marketflow.v1.MarketFlow/GetLatestPrice"
+	MarketFlow_GetHighestPrice_FullMethodName = "/marketflow.v1.MarketFlow/GetHighestPrice"
+	MarketFlow_GetLowestPrice_FullMethodName  = "/marketflow.v1.MarketFlow/GetLowestPrice"
+	MarketFlow_GetAveragePrice_FullMethodName = "/marketflow.v1.MarketFlow/GetAveragePrice"
+	MarketFlow_GetStats_FullMethodName        = "/marketflow.v1.MarketFlow/GetStats"
+	MarketFlow_GetCandles_FullMethodName      = "/marketflow.v1.MarketFlow/GetCandles"
+	MarketFlow_SetMode_FullMethodName         = "/marketflow.v1.MarketFlow/SetMode"
+	MarketFlow_SubscribePrices_FullMethodName = "/marketflow.v1.MarketFlow/SubscribePrices"
+)
+
+// MarketFlowClient is the client API for MarketFlow service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MarketFlow mirrors the REST API. An empty exchange means the aggregate of
+// all exchanges ("global").
+type MarketFlowClient interface {
+	GetLatestPrice(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*PriceResponse, error)
+	GetHighestPrice(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*PriceResponse, error)
+	GetLowestPrice(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*PriceResponse, error)
+	GetAveragePrice(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*PriceResponse, error)
+	GetStats(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	GetCandles(ctx context.Context, in *CandlesRequest, opts ...grpc.CallOption) (*CandlesResponse, error)
+	SetMode(ctx context.Context, in *SetModeRequest, opts ...grpc.CallOption) (*SetModeResponse, error)
+	// SubscribePrices streams live ticks until the client cancels.
+	SubscribePrices(ctx context.Context, in *SubscribePricesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Tick], error)
+}
+
+type marketFlowClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMarketFlowClient(cc grpc.ClientConnInterface) MarketFlowClient {
+	return &marketFlowClient{cc}
+}
+
+func (c *marketFlowClient) GetLatestPrice(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*PriceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceResponse)
+	err := c.cc.Invoke(ctx, MarketFlow_GetLatestPrice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) GetHighestPrice(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*PriceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceResponse)
+	err := c.cc.Invoke(ctx, MarketFlow_GetHighestPrice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) GetLowestPrice(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*PriceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceResponse)
+	err := c.cc.Invoke(ctx, MarketFlow_GetLowestPrice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) GetAveragePrice(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*PriceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceResponse)
+	err := c.cc.Invoke(ctx, MarketFlow_GetAveragePrice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) GetStats(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, MarketFlow_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) GetCandles(ctx context.Context, in *CandlesRequest, opts ...grpc.CallOption) (*CandlesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CandlesResponse)
+	err := c.cc.Invoke(ctx, MarketFlow_GetCandles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) SetMode(ctx context.Context, in *SetModeRequest, opts ...grpc.CallOption) (*SetModeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetModeResponse)
+	err := c.cc.Invoke(ctx, MarketFlow_SetMode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) SubscribePrices(ctx context.Context, in *SubscribePricesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Tick], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MarketFlow_ServiceDesc.Streams[0], MarketFlow_SubscribePrices_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribePricesRequest, Tick]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MarketFlow_SubscribePricesClient = grpc.ServerStreamingClient[Tick]
+
+// MarketFlowServer is the server API for MarketFlow service.
+// All implementations must embed UnimplementedMarketFlowServer
+// for forward compatibility.
+//
+// MarketFlow mirrors the REST API. An empty exchange means the aggregate of
+// all exchanges ("global").
+type MarketFlowServer interface {
+	GetLatestPrice(context.Context, *PriceRequest) (*PriceResponse, error)
+	GetHighestPrice(context.Context, *PriceRequest) (*PriceResponse, error)
+	GetLowestPrice(context.Context, *PriceRequest) (*PriceResponse, error)
+	GetAveragePrice(context.Context, *PriceRequest) (*PriceResponse, error)
+	GetStats(context.Context, *PriceRequest) (*StatsResponse, error)
+	GetCandles(context.Context, *CandlesRequest) (*CandlesResponse, error)
+	SetMode(context.Context, *SetModeRequest) (*SetModeResponse, error)
+	// SubscribePrices streams live ticks until the client cancels.
+	SubscribePrices(*SubscribePricesRequest, grpc.ServerStreamingServer[Tick]) error
+	mustEmbedUnimplementedMarketFlowServer()
+}
+
+// UnimplementedMarketFlowServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMarketFlowServer struct{}
+
+func (UnimplementedMarketFlowServer) GetLatestPrice(context.Context, *PriceRequest) (*PriceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetLatestPrice not implemented")
+}
+func (UnimplementedMarketFlowServer) GetHighestPrice(context.Context, *PriceRequest) (*PriceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetHighestPrice not implemented")
+}
+func (UnimplementedMarketFlowServer) GetLowestPrice(context.Context, *PriceRequest) (*PriceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetLowestPrice not implemented")
+}
+func (UnimplementedMarketFlowServer) GetAveragePrice(context.Context, *PriceRequest) (*PriceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetAveragePrice not implemented")
+}
+func (UnimplementedMarketFlowServer) GetStats(context.Context, *PriceRequest) (*StatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedMarketFlowServer) GetCandles(context.Context, *CandlesRequest) (*CandlesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCandles not implemented")
+}
+func (UnimplementedMarketFlowServer) SetMode(context.Context, *SetModeRequest) (*SetModeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetMode not implemented")
+}
+func (UnimplementedMarketFlowServer) SubscribePrices(*SubscribePricesRequest, grpc.ServerStreamingServer[Tick]) error {
+	return status.Error(codes.Unimplemented, "method SubscribePrices not implemented")
+}
+func (UnimplementedMarketFlowServer) mustEmbedUnimplementedMarketFlowServer() {}
+func (UnimplementedMarketFlowServer) testEmbeddedByValue()                    {}
+
+// UnsafeMarketFlowServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MarketFlowServer will
+// result in compilation errors.
+type UnsafeMarketFlowServer interface {
+	mustEmbedUnimplementedMarketFlowServer()
+}
+
+func RegisterMarketFlowServer(s grpc.ServiceRegistrar, srv MarketFlowServer) {
+	// If the following call panics, it indicates UnimplementedMarketFlowServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MarketFlow_ServiceDesc, srv)
+}
+
+func _MarketFlow_GetLatestPrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PriceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).GetLatestPrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_GetLatestPrice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).GetLatestPrice(ctx, req.(*PriceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_GetHighestPrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PriceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).GetHighestPrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_GetHighestPrice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).GetHighestPrice(ctx, req.(*PriceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_GetLowestPrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PriceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).GetLowestPrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_GetLowestPrice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).GetLowestPrice(ctx, req.(*PriceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_GetAveragePrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PriceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).GetAveragePrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_GetAveragePrice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).GetAveragePrice(ctx, req.(*PriceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PriceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).GetStats(ctx, req.(*PriceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_GetCandles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CandlesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).GetCandles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_GetCandles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).GetCandles(ctx, req.(*CandlesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_SetMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetModeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).SetMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_SetMode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).SetMode(ctx, req.(*SetModeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_SubscribePrices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribePricesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MarketFlowServer).SubscribePrices(m, &grpc.GenericServerStream[SubscribePricesRequest, Tick]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MarketFlow_SubscribePricesServer = grpc.ServerStreamingServer[Tick]
+
+// MarketFlow_ServiceDesc is the grpc.ServiceDesc for MarketFlow service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MarketFlow_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "marketflow.v1.MarketFlow",
+	HandlerType: (*MarketFlowServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLatestPrice",
+			Handler:    _MarketFlow_GetLatestPrice_Handler,
+		},
+		{
+			MethodName: "GetHighestPrice",
+			Handler:    _MarketFlow_GetHighestPrice_Handler,
+		},
+		{
+			MethodName: "GetLowestPrice",
+			Handler:    _MarketFlow_GetLowestPrice_Handler,
+		},
+		{
+			MethodName: "GetAveragePrice",
+			Handler:    _MarketFlow_GetAveragePrice_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _MarketFlow_GetStats_Handler,
+		},
+		{
+			MethodName: "GetCandles",
+			Handler:    _MarketFlow_GetCandles_Handler,
+		},
+		{
+			MethodName: "SetMode",
+			Handler:    _MarketFlow_SetMode_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribePrices",
+			Handler:       _MarketFlow_SubscribePrices_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "marketflow/v1/marketflow.proto",
+}
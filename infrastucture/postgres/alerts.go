@@ -0,0 +1,234 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+const createAlertRule = `
+INSERT INTO
+    alert_rules (
+        name,
+        kind,
+        pair_name,
+        exchange,
+        other_exchange,
+        operator,
+        threshold,
+        hysteresis,
+        window_seconds,
+        cooldown_seconds
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, created_at
+`
+
+// listAlertRules restores the state of every rule from its latest event and
+// its latest trigger.
+const listAlertRules = `
+SELECT
+    r.id,
+    r.name,
+    r.kind,
+    r.pair_name,
+    r.exchange,
+    r.other_exchange,
+    r.operator,
+    r.threshold,
+    r.hysteresis,
+    r.window_seconds,
+    r.cooldown_seconds,
+    r.created_at,
+    COALESCE(last.type = 'triggered', false),
+    triggered.at
+FROM alert_rules r
+LEFT JOIN LATERAL (
+    SELECT type
+    FROM alert_events
+    WHERE rule_id = r.id
+    ORDER BY created_at DESC, id DESC
+    LIMIT 1
+) last ON true
+LEFT JOIN LATERAL (
+    SELECT MAX(created_at) AS at
+    FROM alert_events
+    WHERE rule_id = r.id
+      AND type = 'triggered'
+) triggered ON true
+WHERE r.deleted_at IS NULL
+ORDER BY r.id
+`
+
+const deleteAlertRule = `
+UPDATE alert_rules
+SET deleted_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NULL
+`
+
+// insertAlertEvents keeps the first of the events that every instance raises
+// for a rule in a bucket.
+const insertAlertEvents = `
+INSERT INTO
+    alert_events (rule_id, type, value, threshold, created_at, bucket)
+SELECT *
+FROM unnest($1::bigint[], $2::text[], $3::float8[], $4::float8[], $5::timestamp[], $6::timestamp[])
+ON CONFLICT (rule_id, type, bucket) DO NOTHING
+`
+
+const listAlertEvents = `
+SELECT
+    e.id,
+    e.rule_id,
+    r.name,
+    e.type,
+    r.kind,
+    r.pair_name,
+    r.exchange,
+    r.other_exchange,
+    e.value,
+    e.threshold,
+    e.created_at
+FROM alert_events e
+JOIN alert_rules r ON r.id = e.rule_id
+WHERE
+    e.created_at >= $1
+    AND e.created_at < $2
+    AND ($3::bigint = 0 OR e.rule_id = $3)
+ORDER BY e.created_at DESC, e.id DESC
+LIMIT $4
+`
+
+func (q *Queries) CreateAlertRule(ctx context.Context, rule model.AlertRule) (model.AlertRule, error) {
+	err := q.db.QueryRow(ctx, createAlertRule,
+		rule.Name,
+		rule.Kind,
+		rule.Symbol,
+		rule.Exchange,
+		rule.OtherExchange,
+		rule.Operator,
+		rule.Threshold,
+		rule.Hysteresis,
+		int64(rule.Window.Seconds()),
+		int64(rule.Cooldown.Seconds()),
+	).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return model.AlertRule{}, fmt.Errorf("create alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (q *Queries) ListAlertRules(ctx context.Context) ([]model.AlertRule, error) {
+	rows, err := q.db.Query(ctx, listAlertRules)
+	if err != nil {
+		return nil, fmt.Errorf("list alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []model.AlertRule
+	for rows.Next() {
+		var rule model.AlertRule
+		var window, cooldown int64
+		var triggered *time.Time
+		err := rows.Scan(
+			&rule.ID,
+			&rule.Name,
+			&rule.Kind,
+			&rule.Symbol,
+			&rule.Exchange,
+			&rule.OtherExchange,
+			&rule.Operator,
+			&rule.Threshold,
+			&rule.Hysteresis,
+			&window,
+			&cooldown,
+			&rule.CreatedAt,
+			&rule.Firing,
+			&triggered,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rule.Window = time.Duration(window) * time.Second
+		rule.Cooldown = time.Duration(cooldown) * time.Second
+		if triggered != nil {
+			rule.LastTriggered = *triggered
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// DeleteAlertRule returns ErrNoRows when there is no rule with id left.
+func (q *Queries) DeleteAlertRule(ctx context.Context, id int64) error {
+	tag, err := q.db.Exec(ctx, deleteAlertRule, id)
+	if err != nil {
+		return fmt.Errorf("delete alert rule %d: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNoRows
+	}
+	return nil
+}
+
+func (q *Queries) InsertAlertEvents(ctx context.Context, events []model.AlertEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ruleIDs := make([]int64, len(events))
+	types := make([]string, len(events))
+	values := make([]float64, len(events))
+	thresholds := make([]float64, len(events))
+	times := make([]time.Time, len(events))
+	buckets := make([]time.Time, len(events))
+	for i, e := range events {
+		ruleIDs[i] = e.RuleID
+		types[i] = e.Type
+		values[i] = e.Value
+		thresholds[i] = e.Threshold
+		times[i] = e.Time.UTC()
+		buckets[i] = e.Bucket().UTC()
+	}
+
+	if _, err := q.db.Exec(ctx, insertAlertEvents, ruleIDs, types, values, thresholds, times, buckets); err != nil {
+		return fmt.Errorf("insert alert events: %w", err)
+	}
+	return nil
+}
+
+func (q *Queries) ListAlertEvents(ctx context.Context, ruleID int64, rng model.TimeRange, limit int) ([]model.AlertEvent, error) {
+	rows, err := q.db.Query(ctx, listAlertEvents, rng.From.UTC(), rng.To.UTC(), ruleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list alert events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]model.AlertEvent, 0, limit)
+	for rows.Next() {
+		var e model.AlertEvent
+		err := rows.Scan(
+			&e.ID,
+			&e.RuleID,
+			&e.RuleName,
+			&e.Type,
+			&e.Kind,
+			&e.Symbol,
+			&e.Exchange,
+			&e.OtherExchange,
+			&e.Value,
+			&e.Threshold,
+			&e.Time,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
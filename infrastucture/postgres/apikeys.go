@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"marketflow/internal/core/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation is the SQLSTATE of a duplicate key.
+const uniqueViolation = "23505"
+
+var ErrDuplicateKey = model.NewError(model.KindConflict, "duplicate_api_key", "an api key with this hash already exists")
+
+const createAPIKey = `
+INSERT INTO
+    api_keys (name, prefix, key_hash, role)
+VALUES ($1, $2, $3, $4)
+RETURNING id, created_at
+`
+
+const getAPIKeyByHash = `
+SELECT id, name, prefix, role, created_at
+FROM api_keys
+WHERE key_hash = $1
+  AND revoked_at IS NULL
+`
+
+const listAPIKeys = `
+SELECT id, name, prefix, role, created_at, revoked_at
+FROM api_keys
+ORDER BY id
+`
+
+const revokeAPIKey = `
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE id = $1
+  AND revoked_at IS NULL
+`
+
+func (q *Queries) CreateAPIKey(ctx context.Context, key model.APIKey, hash string) (model.APIKey, error) {
+	err := q.db.QueryRow(ctx, createAPIKey, key.Name, key.Prefix, hash, string(key.Role)).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return model.APIKey{}, ErrDuplicateKey
+		}
+		return model.APIKey{}, fmt.Errorf("create api key: %w", err)
+	}
+	return key, nil
+}
+
+func (q *Queries) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	var key model.APIKey
+	var role string
+	err := q.db.QueryRow(ctx, getAPIKeyByHash, hash).Scan(&key.ID, &key.Name, &key.Prefix, &role, &key.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.APIKey{}, ErrNoRows
+		}
+		return model.APIKey{}, fmt.Errorf("get api key: %w", err)
+	}
+	key.Role = model.Role(role)
+	return key, nil
+}
+
+func (q *Queries) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	rows, err := q.db.Query(ctx, listAPIKeys)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		var key model.APIKey
+		var role string
+		if err := rows.Scan(&key.ID, &key.Name, &key.Prefix, &role, &key.CreatedAt, &key.RevokedAt); err != nil {
+			return nil, err
+		}
+		key.Role = model.Role(role)
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey returns ErrNoRows when there is no active key with id.
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int64) error {
+	tag, err := q.db.Exec(ctx, revokeAPIKey, id)
+	if err != nil {
+		return fmt.Errorf("revoke api key %d: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Market rows written before first and last prices were tracked fall back to
+// their average.
+const getMarketCandles = `
+SELECT
+    timestamp,
+    COALESCE(first_price, average_price),
+    max_price,
+    min_price,
+    COALESCE(last_price, average_price),
+    average_price,
+    tick_count
+FROM market
+WHERE
+    pair_name = $1
+    AND exchange = $2
+ORDER BY timestamp DESC
+LIMIT $3
+`
+
+const getRollupCandles = `
+SELECT
+    bucket,
+    COALESCE(first_price, average_price),
+    max_price,
+    min_price,
+    COALESCE(last_price, average_price),
+    average_price,
+    tick_count
+FROM market_rollup
+WHERE
+    pair_name = $1
+    AND exchange = $2
+    AND resolution = $4
+ORDER BY bucket DESC
+LIMIT $3
+`
+
+// GetCandles returns the most recent candles oldest first.
+func (q *Queries) GetCandles(ctx context.Context, arg storage.CandleParams) ([]model.Candle, error) {
+	if arg.Resolution == "" {
+		arg.Resolution = model.MarketResolution.Name
+	}
+
+	var rows pgx.Rows
+	var err error
+	if arg.Resolution == model.MarketResolution.Name {
+		rows, err = q.db.Query(ctx, getMarketCandles, arg.PairName, arg.Exchange, arg.Limit)
+	} else {
+		rows, err = q.db.Query(ctx, getRollupCandles, arg.PairName, arg.Exchange, arg.Limit, arg.Resolution)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get candles %s:%s: %w", arg.Exchange, arg.PairName, err)
+	}
+	defer rows.Close()
+
+	candles := make([]model.Candle, 0, arg.Limit)
+	for rows.Next() {
+		c := model.Candle{
+			Exchange:   arg.Exchange,
+			Symbol:     arg.PairName,
+			Resolution: arg.Resolution,
+		}
+		err := rows.Scan(
+			&c.Start,
+			&c.Open,
+			&c.High,
+			&c.Low,
+			&c.Close,
+			&c.Average,
+			&c.TickCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(candles)
+	return candles, nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core/model"
+)
+
+// The distribution is taken over the average prices of the samples, each
+// weighed by the market rows it stands for, so that a day bucket counts as
+// much as the 1440 minutes it replaces. The percentiles are the lowest
+// prices at or above the share of weight; the volatility is the realized
+// volatility of the log returns between consecutive closes.
+const getDistribution = withSamples + `,
+returns AS (
+    SELECT ln(last_price / LAG(last_price) OVER (ORDER BY ts)) AS r
+    FROM samples
+),
+mean AS (
+    SELECT SUM(average_price * sample_count) / SUM(sample_count) AS price
+    FROM samples
+),
+weighted AS (
+    SELECT
+        average_price::float8 AS price,
+        SUM(sample_count) OVER (ORDER BY average_price ROWS UNBOUNDED PRECEDING) AS cumulative,
+        SUM(sample_count) OVER () AS total
+    FROM samples
+)
+SELECT
+    (SELECT COUNT(*) FROM samples),
+    (SELECT COALESCE(SUM(tick_count), 0)::bigint FROM samples),
+    (SELECT sqrt(SUM(s.sample_count * power(s.average_price - mean.price, 2)) / SUM(s.sample_count))::float8 FROM samples s, mean),
+    (SELECT sqrt(SUM(r * r))::float8 FROM returns),
+    (SELECT MIN(price) FROM weighted WHERE cumulative >= 0.05 * total),
+    (SELECT MIN(price) FROM weighted WHERE cumulative >= 0.50 * total),
+    (SELECT MIN(price) FROM weighted WHERE cumulative >= 0.95 * total)
+`
+
+// GetDistribution reports the coarsest resolution it read from as its
+// source.
+func (q *Queries) GetDistribution(ctx context.Context, arg storage.Params) (model.Distribution, error) {
+	row := q.db.QueryRow(ctx, getDistribution, sampleArgs(arg)...)
+	dist := model.Distribution{Source: coarsest(arg).Name, Approximate: true}
+
+	var stddev, volatility, p5, p50, p95 sql.NullFloat64
+	err := row.Scan(
+		&dist.Samples,
+		&dist.TickCount,
+		&stddev,
+		&volatility,
+		&p5,
+		&p50,
+		&p95,
+	)
+	if err != nil {
+		return model.Distribution{}, fmt.Errorf("get distribution %s:%s: %w", arg.Exchange, arg.PairName, err)
+	}
+
+	if dist.Samples == 0 {
+		return model.Distribution{}, ErrNoRows
+	}
+
+	dist.StdDev = stddev.Float64
+	dist.Volatility = volatility.Float64
+	dist.P5 = p5.Float64
+	dist.P50 = p50.Float64
+	dist.P95 = p95.Float64
+
+	return dist, nil
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -14,22 +15,26 @@ INSERT INTO
 	raw_data (
 		exchange,
 		pair_name,
-		price
+		price,
+		created_at
 	)
-VALUES ($1, $2, $3);
+VALUES ($1, $2, $3, $4);
 `
 
+// SaveRawData stores a tick at the time of its trade, or at the time it is
+// written if the trade carries none.
 func (q *Queries) SaveRawData(ctx context.Context, exchanger string, data model.Trade) error {
+	at := time.Now()
+	if data.Timestamp != 0 {
+		at = time.UnixMilli(data.Timestamp)
+	}
+
 	_, err := q.db.Exec(ctx, saveRawData,
 		exchanger,
 		data.Symbol,
 		data.Price,
+		at.UTC(),
 	)
-	if err != nil {
-		return err
-	}
-	_, _ = q.db.Exec(ctx, "DELETE FROM raw_data WHERE created_at < now() - $1::interval", 1*time.Minute)
-
 	return err
 }
 
@@ -42,11 +47,6 @@ WHERE pair_name = $1
 ORDER BY created_at ASC;
 `
 
-const deleteOldRawData = `
-DELETE FROM raw_data
-WHERE created_at < now() - $1::interval;
-`
-
 func (q *Queries) GetRawData(ctx context.Context, exchange string, symbol string, interval time.Duration) ([]model.Trade, error) {
 	rows, err := q.db.Query(ctx, getRawDataByRange, symbol, exchange, interval)
 	if err != nil {
@@ -69,11 +69,10 @@ func (q *Queries) GetRawData(ctx context.Context, exchange string, symbol string
 			return nil, err
 		}
 
-		trade.Timestamp = t.Unix()
+		trade.Timestamp = t.UnixMilli()
 
 		trades = append(trades, trade)
 	}
-	_, _ = q.db.Exec(ctx, deleteOldRawData, 90*time.Second)
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
@@ -124,7 +123,7 @@ func (q *Queries) GetCollection(ctx context.Context) ([]string, []string, error)
 }
 
 const getLatest = `
-SELECT  price
+SELECT price::float8, created_at
 FROM raw_data
 WHERE pair_name = $1
   AND exchange = $2
@@ -132,15 +131,54 @@ ORDER BY created_at DESC
 LIMIT 1;
 `
 
-func (q *Queries) GetLatest(ctx context.Context, exchange string, symbol string) (float64, error) {
+func (q *Queries) GetLatest(ctx context.Context, exchange string, symbol string) (model.Quote, error) {
 	row := q.db.QueryRow(ctx, getLatest, symbol, exchange)
-	var price float64
-	err := row.Scan(&price)
+	quote := model.Quote{Exchange: exchange, Symbol: symbol}
+	err := row.Scan(&quote.Price, &quote.Timestamp)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return 0, ErrNoRows
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Quote{}, ErrNoRows
 		}
-		return 0, fmt.Errorf("get latest %s:%s: %w", exchange, symbol, err)
+		return model.Quote{}, fmt.Errorf("get latest %s:%s: %w", exchange, symbol, err)
 	}
-	return price, nil
+	return quote, nil
+}
+
+// getLatestBatch picks the newest tick of every requested pair in one query.
+const getLatestBatch = `
+SELECT DISTINCT ON (r.exchange, r.pair_name)
+    r.exchange, r.pair_name, r.price::float8, r.created_at
+FROM raw_data r
+JOIN unnest($1::text[], $2::text[]) AS p (exchange, pair_name)
+    ON r.exchange = p.exchange AND r.pair_name = p.pair_name
+ORDER BY r.exchange, r.pair_name, r.created_at DESC;
+`
+
+func (q *Queries) GetLatestBatch(ctx context.Context, pairs []model.Pair) ([]model.Quote, error) {
+	exchanges := make([]string, len(pairs))
+	symbols := make([]string, len(pairs))
+	for i, p := range pairs {
+		exchanges[i] = p.Exchange
+		symbols[i] = p.Symbol
+	}
+
+	rows, err := q.db.Query(ctx, getLatestBatch, exchanges, symbols)
+	if err != nil {
+		return nil, fmt.Errorf("get latest batch: %w", err)
+	}
+	defer rows.Close()
+
+	var quotes []model.Quote
+	for rows.Next() {
+		var quote model.Quote
+		if err := rows.Scan(&quote.Exchange, &quote.Symbol, &quote.Price, &quote.Timestamp); err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, quote)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return quotes, nil
 }
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// The history queries page through a pair with a keyset on the time column,
+// so every page is an index range scan no matter how deep into the history it
+// starts. A NULL cursor starts at the range.
+
+const getMarketHistory = `
+SELECT
+    timestamp,
+    COALESCE(first_price, average_price),
+    max_price,
+    min_price,
+    COALESCE(last_price, average_price),
+    average_price,
+    tick_count
+FROM market
+WHERE
+    pair_name = $1
+    AND exchange = $2
+    AND timestamp >= $3
+    AND timestamp < $4
+    AND ($5::timestamp IS NULL OR timestamp > $5)
+ORDER BY timestamp
+LIMIT $6
+`
+
+const getRollupHistory = `
+SELECT
+    bucket,
+    COALESCE(first_price, average_price),
+    max_price,
+    min_price,
+    COALESCE(last_price, average_price),
+    average_price,
+    tick_count
+FROM market_rollup
+WHERE
+    resolution = $7
+    AND pair_name = $1
+    AND exchange = $2
+    AND bucket >= $3
+    AND bucket < $4
+    AND ($5::timestamp IS NULL OR bucket > $5)
+ORDER BY bucket
+LIMIT $6
+`
+
+// GetHistory returns a page of market rows or rollup buckets, oldest first.
+func (q *Queries) GetHistory(ctx context.Context, arg storage.HistoryParams) ([]model.HistoryPoint, error) {
+	from, to := arg.From.UTC(), arg.To.UTC()
+	var after any
+	if !arg.After.IsZero() {
+		after = arg.After.UTC()
+	}
+
+	var rows pgx.Rows
+	var err error
+	if arg.Resolution == model.MarketResolution.Name {
+		rows, err = q.db.Query(ctx, getMarketHistory, arg.PairName, arg.Exchange, from, to, after, arg.Limit)
+	} else {
+		rows, err = q.db.Query(ctx, getRollupHistory, arg.PairName, arg.Exchange, from, to, after, arg.Limit, arg.Resolution)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get history %s:%s: %w", arg.Exchange, arg.PairName, err)
+	}
+	defer rows.Close()
+
+	points := make([]model.HistoryPoint, 0, arg.Limit)
+	for rows.Next() {
+		var p model.HistoryPoint
+		err = rows.Scan(
+			&p.Time,
+			&p.Open,
+			&p.High,
+			&p.Low,
+			&p.Close,
+			&p.Average,
+			&p.TickCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
@@ -3,30 +3,27 @@ package postgres
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 
 	"marketflow/internal/adapters/secondary/storage"
 	"marketflow/internal/core/model"
 )
 
-var ErrNoRows = fmt.Errorf("no data found")
+var ErrNoRows = model.NewError(model.KindNotFound, "no_data", "no data found")
 
-const getAverage = `
-SELECT AVG(average_price) AS avg_price
-FROM market
-WHERE
-    pair_name = $1
-    AND exchange = $2
-    AND timestamp > now() - ($3 * interval '1 second')
+// The average of a range is the average of its one-minute windows, so every
+// bucket weighs as much as the market rows it stands for.
+const getAverage = withSamples + `
+SELECT (SUM(average_price * sample_count) / SUM(sample_count))::float8
+FROM samples
 `
 
 func (q *Queries) GetAverage(ctx context.Context, arg storage.Params) (float64, error) {
-	seconds := int64(arg.Interval.Seconds())
-	row := q.db.QueryRow(ctx, getAverage, arg.PairName, arg.Exchange, seconds)
+	row := q.db.QueryRow(ctx, getAverage, sampleArgs(arg)...)
 	var avg_price sql.NullFloat64
 	err := row.Scan(&avg_price)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			return 0, ErrNoRows
 		}
 		return 0, err
@@ -38,22 +35,17 @@ func (q *Queries) GetAverage(ctx context.Context, arg storage.Params) (float64,
 	return avg_price.Float64, nil
 }
 
-const getMax = `
-SELECT MAX(max_price) AS max_price
-FROM market
-WHERE
-    pair_name = $1
-    AND exchange = $2
-    AND timestamp > now() - ($3 * interval '1 second')
+const getMax = withSamples + `
+SELECT MAX(max_price)::float8
+FROM samples
 `
 
 func (q *Queries) GetMax(ctx context.Context, arg storage.Params) (float64, error) {
-	seconds := int64(arg.Interval.Seconds())
-	row := q.db.QueryRow(ctx, getMax, arg.PairName, arg.Exchange, seconds)
+	row := q.db.QueryRow(ctx, getMax, sampleArgs(arg)...)
 	var max_price sql.NullFloat64
 	err := row.Scan(&max_price)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			return 0, ErrNoRows
 		}
 		return 0, err
@@ -65,22 +57,17 @@ func (q *Queries) GetMax(ctx context.Context, arg storage.Params) (float64, erro
 	return max_price.Float64, nil
 }
 
-const getMin = `
-SELECT MIN(min_price) AS min_price
-FROM market
-WHERE
-    pair_name = $1
-    AND exchange = $2
-    AND timestamp > now() - ($3 * interval '1 second')
+const getMin = withSamples + `
+SELECT MIN(min_price)::float8
+FROM samples
 `
 
 func (q *Queries) GetMin(ctx context.Context, arg storage.Params) (float64, error) {
-	seconds := int64(arg.Interval.Seconds())
-	row := q.db.QueryRow(ctx, getMin, arg.PairName, arg.Exchange, seconds)
+	row := q.db.QueryRow(ctx, getMin, sampleArgs(arg)...)
 	var min_price sql.NullFloat64
 	err := row.Scan(&min_price)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			return 0, ErrNoRows
 		}
 		return 0, err
@@ -92,27 +79,46 @@ func (q *Queries) GetMin(ctx context.Context, arg storage.Params) (float64, erro
 	return min_price.Float64, nil
 }
 
+// insertMarket writes one window per pair, exchange and start time. A window
+// that is written again, e.g. after it was rebuilt from Redis on restart,
+// replaces the old row and bumps updated_at so the rollups pick it up.
 const insertMarket = `
 INSERT INTO
     market (
         pair_name,
         exchange,
+        timestamp,
         average_price,
         min_price,
-        max_price
+        max_price,
+        first_price,
+        last_price,
+        tick_count
     )
-VALUES ($1, $2, $3, $4, $5)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (pair_name, exchange, timestamp) DO UPDATE SET
+    average_price = EXCLUDED.average_price,
+    min_price = EXCLUDED.min_price,
+    max_price = EXCLUDED.max_price,
+    first_price = EXCLUDED.first_price,
+    last_price = EXCLUDED.last_price,
+    tick_count = EXCLUDED.tick_count,
+    updated_at = now()
 RETURNING
-    id, pair_name, exchange, timestamp, average_price, min_price, max_price
+    id, pair_name, exchange, timestamp, average_price, min_price, max_price, first_price, last_price, tick_count
 `
 
 func (q *Queries) InsertMarket(ctx context.Context, arg storage.InsertMarketParams) (model.AgregetedData, error) {
 	row := q.db.QueryRow(ctx, insertMarket,
 		arg.PairName,
 		arg.Exchange,
+		arg.Timestamp.UTC(),
 		arg.AveragePrice,
 		arg.MinPrice,
 		arg.MaxPrice,
+		arg.FirstPrice,
+		arg.LastPrice,
+		arg.TickCount,
 	)
 	var i model.AgregetedData
 
@@ -124,6 +130,9 @@ func (q *Queries) InsertMarket(ctx context.Context, arg storage.InsertMarketPara
 		&i.AveragePrice,
 		&i.MinPrice,
 		&i.MaxPrice,
+		&i.FirstPrice,
+		&i.LastPrice,
+		&i.TickCount,
 	)
 	return i, err
 }
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"marketflow/internal/core/model"
+)
+
+// retentionColumns lists the tables a retention policy may target together
+// with the column their age is measured by. Table names are never taken from
+// anywhere else, so they are safe to format into the queries below.
+var retentionColumns = map[string]string{
+	"raw_data":           "created_at",
+	"market":             "timestamp",
+	"market_rollup":      "bucket",
+	"alert_events":       "created_at",
+	"webhook_deliveries": "created_at",
+}
+
+var ErrUnknownTable = fmt.Errorf("table is not subject to retention")
+
+const deleteExpired = `
+DELETE FROM %[1]s
+WHERE ctid IN (
+    SELECT ctid
+    FROM %[1]s
+    WHERE %[2]s < now() - ($1 * interval '1 second')%[3]s
+    LIMIT $2
+)
+`
+
+const previewExpired = `
+SELECT now() - ($1 * interval '1 second'), COUNT(*), MIN(%[2]s)
+FROM %[1]s
+WHERE %[2]s < now() - ($1 * interval '1 second')%[3]s
+`
+
+// retentionQuery fills in the table, its age column and, for rollups, a
+// resolution filter bound to the placeholder after the last one in args.
+func retentionQuery(query string, p model.RetentionPolicy, args []any) (string, []any, error) {
+	column, ok := retentionColumns[p.Table]
+	if !ok {
+		return "", nil, fmt.Errorf("%s: %w", p.Table, ErrUnknownTable)
+	}
+
+	var filter string
+	if p.Resolution != "" {
+		args = append(args, p.Resolution)
+		filter = fmt.Sprintf("\n      AND resolution = $%d", len(args))
+	}
+
+	return fmt.Sprintf(query, p.Table, column, filter), args, nil
+}
+
+// DeleteExpired removes at most batch rows that are older than the policy
+// allows and returns how many were removed.
+func (q *Queries) DeleteExpired(ctx context.Context, p model.RetentionPolicy, batch int64) (int64, error) {
+	seconds := int64(p.MaxAge.Seconds())
+	query, args, err := retentionQuery(deleteExpired, p, []any{seconds, batch})
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := q.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired %s: %w", p.Name(), err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (q *Queries) PreviewExpired(ctx context.Context, p model.RetentionPolicy) (model.RetentionPreview, error) {
+	seconds := int64(p.MaxAge.Seconds())
+	query, args, err := retentionQuery(previewExpired, p, []any{seconds})
+	if err != nil {
+		return model.RetentionPreview{}, err
+	}
+
+	var preview model.RetentionPreview
+	var oldest sql.NullTime
+	err = q.db.QueryRow(ctx, query, args...).Scan(&preview.Cutoff, &preview.Rows, &oldest)
+	if err != nil {
+		return model.RetentionPreview{}, fmt.Errorf("preview expired %s: %w", p.Name(), err)
+	}
+
+	if oldest.Valid {
+		t := oldest.Time
+		preview.Oldest = &t
+	}
+
+	return preview, nil
+}
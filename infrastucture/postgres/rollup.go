@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+// rollupLag keeps the rollups behind the market rows by more than any insert
+// takes to commit. updated_at is set when an insert starts, so a row that is
+// still being written can carry a time the watermark has already passed.
+const rollupLag = 30 * time.Second
+
+const getRollupWatermark = `
+SELECT COALESCE(
+    (SELECT rolled_up_to FROM rollup_watermark WHERE resolution = $1),
+    TIMESTAMP 'epoch'
+)
+`
+
+// getRollupUpperBound picks the next batch of written market rows, by the
+// time they were last written, that is older than the lag.
+const getRollupUpperBound = `
+SELECT COALESCE(MAX(updated_at), $1), COUNT(*)
+FROM (
+    SELECT updated_at
+    FROM market
+    WHERE updated_at > $1
+      AND updated_at <= now() - $3 * interval '1 second'
+    ORDER BY updated_at
+    LIMIT $2
+) batch
+`
+
+// rollupMarket recomputes every bucket touched by the market rows written in
+// ($3, $4] from scratch and moves the watermark in the same statement, so a
+// crash either keeps both or neither. A late or rewritten row gets a new
+// updated_at and therefore causes its bucket to be rebuilt.
+const rollupMarket = `
+WITH touched AS (
+    SELECT DISTINCT
+        pair_name,
+        exchange,
+        date_bin($2 * interval '1 second', timestamp, TIMESTAMP '2000-01-01') AS bucket
+    FROM market
+    WHERE updated_at > $3 AND updated_at <= $4
+),
+upserted AS (
+    INSERT INTO
+        market_rollup (
+            resolution,
+            pair_name,
+            exchange,
+            bucket,
+            average_price,
+            min_price,
+            max_price,
+            sample_count,
+            first_price,
+            last_price,
+            tick_count
+        )
+    SELECT
+        $1,
+        t.pair_name,
+        t.exchange,
+        t.bucket,
+        AVG(m.average_price),
+        MIN(m.min_price),
+        MAX(m.max_price),
+        COUNT(*),
+        (array_agg(m.first_price ORDER BY m.timestamp ASC))[1],
+        (array_agg(m.last_price ORDER BY m.timestamp DESC))[1],
+        SUM(m.tick_count)
+    FROM touched t
+    JOIN market m
+      ON m.pair_name = t.pair_name
+     AND m.exchange = t.exchange
+     AND m.timestamp >= t.bucket
+     AND m.timestamp < t.bucket + $2 * interval '1 second'
+    GROUP BY t.pair_name, t.exchange, t.bucket
+    ON CONFLICT (resolution, pair_name, exchange, bucket) DO UPDATE SET
+        average_price = EXCLUDED.average_price,
+        min_price = EXCLUDED.min_price,
+        max_price = EXCLUDED.max_price,
+        sample_count = EXCLUDED.sample_count,
+        first_price = EXCLUDED.first_price,
+        last_price = EXCLUDED.last_price,
+        tick_count = EXCLUDED.tick_count
+)
+INSERT INTO
+    rollup_watermark (resolution, rolled_up_to, updated_at)
+VALUES ($1, $4, now())
+ON CONFLICT (resolution) DO UPDATE SET
+    rolled_up_to = EXCLUDED.rolled_up_to,
+    updated_at = EXCLUDED.updated_at
+`
+
+// RollupMarket folds about batch written market rows into the given
+// resolution and returns how many it folded.
+func (q *Queries) RollupMarket(ctx context.Context, res model.Resolution, batch int64) (int64, error) {
+	var from time.Time
+	if err := q.db.QueryRow(ctx, getRollupWatermark, res.Name).Scan(&from); err != nil {
+		return 0, fmt.Errorf("get rollup watermark %s: %w", res.Name, err)
+	}
+
+	var to time.Time
+	var n int64
+	lag := int64(rollupLag.Seconds())
+	if err := q.db.QueryRow(ctx, getRollupUpperBound, from, batch, lag).Scan(&to, &n); err != nil {
+		return 0, fmt.Errorf("get rollup upper bound %s: %w", res.Name, err)
+	}
+
+	if n == 0 {
+		return 0, nil
+	}
+
+	seconds := int64(res.Step.Seconds())
+	if _, err := q.db.Exec(ctx, rollupMarket, res.Name, seconds, from, to); err != nil {
+		return 0, fmt.Errorf("rollup market %s: %w", res.Name, err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"time"
+
+	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core/model"
+)
+
+// withSamples reads the market rows and rollup buckets of every segment of a
+// range into samples. Each sample carries when it ends and how many market
+// rows it stands for, so that statistics can be taken across resolutions.
+// The segments are passed as parallel arrays of resolution, step in seconds
+// and bounds.
+const withSamples = `
+WITH segments AS (
+    SELECT *
+    FROM unnest($3::text[], $4::bigint[], $5::timestamp[], $6::timestamp[])
+        AS s (resolution, step, from_ts, to_ts)
+),
+samples AS (
+    SELECT
+        m.timestamp AS ts,
+        m.timestamp + s.step * interval '1 second' AS ts_end,
+        m.average_price,
+        m.min_price,
+        m.max_price,
+        COALESCE(m.first_price, m.average_price) AS first_price,
+        COALESCE(m.last_price, m.average_price) AS last_price,
+        m.tick_count::bigint AS tick_count,
+        1 AS sample_count
+    FROM segments s
+    JOIN market m
+      ON s.resolution = '1m'
+     AND m.pair_name = $1
+     AND m.exchange = $2
+     AND m.timestamp >= s.from_ts
+     AND m.timestamp < s.to_ts
+    UNION ALL
+    SELECT
+        r.bucket,
+        r.bucket + s.step * interval '1 second',
+        r.average_price,
+        r.min_price,
+        r.max_price,
+        COALESCE(r.first_price, r.average_price),
+        COALESCE(r.last_price, r.average_price),
+        r.tick_count,
+        r.sample_count
+    FROM segments s
+    JOIN market_rollup r
+      ON r.resolution = s.resolution
+     AND r.pair_name = $1
+     AND r.exchange = $2
+     AND r.bucket >= s.from_ts
+     AND r.bucket < s.to_ts
+)
+`
+
+// sampleArgs returns the arguments of withSamples. A range without segments
+// is read from the market rows.
+func sampleArgs(arg storage.Params) []any {
+	segments := arg.Segments
+	if len(segments) == 0 {
+		segments = []storage.Segment{{Resolution: model.MarketResolution, From: arg.From, To: arg.To}}
+	}
+
+	names := make([]string, len(segments))
+	steps := make([]int64, len(segments))
+	froms := make([]time.Time, len(segments))
+	tos := make([]time.Time, len(segments))
+	for i, s := range segments {
+		names[i] = s.Resolution.Name
+		steps[i] = int64(s.Resolution.Step.Seconds())
+		froms[i] = s.From.UTC()
+		tos[i] = s.To.UTC()
+	}
+
+	return []any{arg.PairName, arg.Exchange, names, steps, froms, tos}
+}
+
+// coarsest returns the widest resolution among the segments of arg.
+func coarsest(arg storage.Params) model.Resolution {
+	res := model.MarketResolution
+	for _, s := range arg.Segments {
+		if s.Resolution.Step > res.Step {
+			res = s.Resolution
+		}
+	}
+	return res
+}
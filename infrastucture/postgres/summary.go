@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core/model"
+)
+
+const getSummary = withSamples + `
+SELECT
+    MIN(ts),
+    MAX(ts_end),
+    MIN(min_price)::float8,
+    MAX(max_price)::float8,
+    (SUM(average_price * sample_count) / SUM(sample_count))::float8,
+    ((array_agg(first_price ORDER BY ts ASC))[1])::float8,
+    ((array_agg(last_price ORDER BY ts DESC))[1])::float8,
+    COALESCE(SUM(tick_count), 0)::bigint
+FROM samples
+`
+
+// GetSummary computes every statistic of the period in one statement. The
+// returned End is the end of the last row or bucket, not its start.
+func (q *Queries) GetSummary(ctx context.Context, arg storage.Params) (model.Summary, error) {
+	row := q.db.QueryRow(ctx, getSummary, sampleArgs(arg)...)
+
+	var start, end sql.NullTime
+	var min, max, avg, first, last sql.NullFloat64
+	var summary model.Summary
+	err := row.Scan(
+		&start,
+		&end,
+		&min,
+		&max,
+		&avg,
+		&first,
+		&last,
+		&summary.TickCount,
+	)
+	if err != nil {
+		return model.Summary{}, fmt.Errorf("get summary %s:%s: %w", arg.Exchange, arg.PairName, err)
+	}
+
+	if !start.Valid {
+		return model.Summary{}, ErrNoRows
+	}
+
+	summary.Start = start.Time
+	summary.End = end.Time
+	summary.Min = min.Float64
+	summary.Max = max.Float64
+	summary.Average = avg.Float64
+	summary.First = first.Float64
+	summary.Last = last.Float64
+
+	return summary, nil
+}
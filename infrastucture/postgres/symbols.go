@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+// upsertSymbols writes a batch of listings in one statement. first_seen only
+// ever moves back and last_tick only forward, so batches may arrive in any
+// order.
+const upsertSymbols = `
+INSERT INTO
+    symbols (exchange, pair_name, first_seen, last_tick)
+SELECT *
+FROM unnest($1::text[], $2::text[], $3::timestamp[], $4::timestamp[])
+ON CONFLICT (exchange, pair_name) DO UPDATE
+SET
+    first_seen = LEAST(symbols.first_seen, EXCLUDED.first_seen),
+    last_tick = GREATEST(symbols.last_tick, EXCLUDED.last_tick)
+`
+
+const listSymbols = `
+SELECT exchange, pair_name, first_seen, last_tick
+FROM symbols
+ORDER BY pair_name, exchange
+`
+
+func (q *Queries) UpsertSymbols(ctx context.Context, listings []model.SymbolListing) error {
+	if len(listings) == 0 {
+		return nil
+	}
+
+	exchanges := make([]string, len(listings))
+	symbols := make([]string, len(listings))
+	firstSeen := make([]time.Time, len(listings))
+	lastTick := make([]time.Time, len(listings))
+	for i, l := range listings {
+		exchanges[i] = l.Exchange
+		symbols[i] = l.Symbol
+		firstSeen[i] = l.FirstSeen.UTC()
+		lastTick[i] = l.LastTick.UTC()
+	}
+
+	if _, err := q.db.Exec(ctx, upsertSymbols, exchanges, symbols, firstSeen, lastTick); err != nil {
+		return fmt.Errorf("upsert symbols: %w", err)
+	}
+	return nil
+}
+
+func (q *Queries) ListSymbols(ctx context.Context) ([]model.SymbolListing, error) {
+	rows, err := q.db.Query(ctx, listSymbols)
+	if err != nil {
+		return nil, fmt.Errorf("list symbols: %w", err)
+	}
+	defer rows.Close()
+
+	var listings []model.SymbolListing
+	for rows.Next() {
+		var l model.SymbolListing
+		if err := rows.Scan(&l.Exchange, &l.Symbol, &l.FirstSeen, &l.LastTick); err != nil {
+			return nil, err
+		}
+		listings = append(listings, l)
+	}
+
+	return listings, rows.Err()
+}
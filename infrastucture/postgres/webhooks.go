@@ -0,0 +1,259 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"marketflow/internal/core/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const createWebhook = `
+INSERT INTO
+    webhooks (url, secret, topics, symbols, exchanges)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, created_at
+`
+
+const listWebhooks = `
+SELECT id, url, secret, topics, symbols, exchanges, created_at
+FROM webhooks
+WHERE deleted_at IS NULL
+ORDER BY id
+`
+
+const deleteWebhook = `
+UPDATE webhooks
+SET deleted_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NULL
+`
+
+const createDelivery = `
+INSERT INTO
+    webhook_deliveries (webhook_id, topic, payload, status, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, created_at, updated_at
+`
+
+// insertDeliveries skips the events another instance already queued for a
+// webhook. Deliveries without an event key are all kept.
+const insertDeliveries = `
+INSERT INTO
+    webhook_deliveries (webhook_id, topic, event_key, payload, next_attempt_at, created_at, updated_at)
+SELECT webhook_id, topic, NULLIF(event_key, ''), payload, next_attempt_at, created_at, created_at
+FROM unnest($1::bigint[], $2::text[], $3::text[], $4::text[], $5::timestamp[], $6::timestamp[])
+    AS d (webhook_id, topic, event_key, payload, next_attempt_at, created_at)
+ON CONFLICT (webhook_id, event_key) DO NOTHING
+`
+
+// claimDeliveries pushes the due deliveries back by the lease as it returns
+// them. Rows another instance is claiming at the same time are skipped.
+const claimDeliveries = `
+UPDATE webhook_deliveries d
+SET next_attempt_at = $3
+FROM (
+    SELECT id
+    FROM webhook_deliveries
+    WHERE status = 'pending'
+      AND next_attempt_at <= $1
+    ORDER BY next_attempt_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+) due
+WHERE d.id = due.id
+RETURNING
+    d.id,
+    d.webhook_id,
+    d.topic,
+    d.payload,
+    d.status,
+    d.attempts,
+    d.status_code,
+    d.error,
+    d.next_attempt_at,
+    d.created_at,
+    d.updated_at
+`
+
+const updateDelivery = `
+UPDATE webhook_deliveries
+SET
+    status = $2,
+    attempts = $3,
+    status_code = $4,
+    error = $5,
+    next_attempt_at = $6,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+const listDeliveries = `
+SELECT
+    id,
+    webhook_id,
+    topic,
+    payload,
+    status,
+    attempts,
+    status_code,
+    error,
+    next_attempt_at,
+    created_at,
+    updated_at
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+`
+
+func (q *Queries) CreateWebhook(ctx context.Context, w model.Webhook) (model.Webhook, error) {
+	err := q.db.QueryRow(ctx, createWebhook, w.URL, w.Secret, w.Topics, nonNil(w.Symbols), nonNil(w.Exchanges)).Scan(&w.ID, &w.CreatedAt)
+	if err != nil {
+		return model.Webhook{}, fmt.Errorf("create webhook: %w", err)
+	}
+	return w, nil
+}
+
+func (q *Queries) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	rows, err := q.db.Query(ctx, listWebhooks)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []model.Webhook
+	for rows.Next() {
+		var w model.Webhook
+		if err := rows.Scan(&w.ID, &w.URL, &w.Secret, &w.Topics, &w.Symbols, &w.Exchanges, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// DeleteWebhook returns ErrNoRows when there is no webhook with id left.
+func (q *Queries) DeleteWebhook(ctx context.Context, id int64) error {
+	tag, err := q.db.Exec(ctx, deleteWebhook, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook %d: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNoRows
+	}
+	return nil
+}
+
+func (q *Queries) CreateDelivery(ctx context.Context, d model.WebhookDelivery) (model.WebhookDelivery, error) {
+	err := q.db.QueryRow(ctx, createDelivery, d.WebhookID, d.Topic, string(d.Payload), d.Status, nullTime(d.NextAttempt)).
+		Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return model.WebhookDelivery{}, fmt.Errorf("create webhook delivery: %w", err)
+	}
+	return d, nil
+}
+
+func (q *Queries) InsertDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	webhookIDs := make([]int64, len(deliveries))
+	topics := make([]string, len(deliveries))
+	keys := make([]string, len(deliveries))
+	payloads := make([]string, len(deliveries))
+	nextAttempts := make([]time.Time, len(deliveries))
+	times := make([]time.Time, len(deliveries))
+	for i, d := range deliveries {
+		webhookIDs[i] = d.WebhookID
+		topics[i] = d.Topic
+		keys[i] = d.EventKey
+		payloads[i] = string(d.Payload)
+		nextAttempts[i] = d.NextAttempt.UTC()
+		times[i] = d.CreatedAt.UTC()
+	}
+
+	if _, err := q.db.Exec(ctx, insertDeliveries, webhookIDs, topics, keys, payloads, nextAttempts, times); err != nil {
+		return fmt.Errorf("insert webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func (q *Queries) ClaimDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, claimDeliveries, now.UTC(), limit, now.Add(lease).UTC())
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+func (q *Queries) UpdateDelivery(ctx context.Context, d model.WebhookDelivery) error {
+	_, err := q.db.Exec(ctx, updateDelivery, d.ID, d.Status, d.Attempts, d.StatusCode, d.Error, nullTime(d.NextAttempt))
+	if err != nil {
+		return fmt.Errorf("update webhook delivery %d: %w", d.ID, err)
+	}
+	return nil
+}
+
+func (q *Queries) ListDeliveries(ctx context.Context, webhookID int64, limit int) ([]model.WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listDeliveries, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+func scanDeliveries(rows pgx.Rows) ([]model.WebhookDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []model.WebhookDelivery
+	for rows.Next() {
+		var d model.WebhookDelivery
+		var payload string
+		var next *time.Time
+		err := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.Topic,
+			&payload,
+			&d.Status,
+			&d.Attempts,
+			&d.StatusCode,
+			&d.Error,
+			&next,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		d.Payload = []byte(payload)
+		if next != nil {
+			d.NextAttempt = *next
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// nullTime stores the zero time as NULL.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// nonNil stores a nil slice as an empty array rather than NULL.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
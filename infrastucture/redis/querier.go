@@ -15,11 +15,14 @@ import (
 )
 
 var (
-	ErrNoConnection = fmt.Errorf("no connection to redis server")
-	ErrNoData       = errors.New("no data")
+	ErrNoConnection = model.NewError(model.KindUnavailable, "cache_unavailable", "no connection to redis server")
+	ErrNoData       = model.NewError(model.KindNotFound, "no_data", "no data")
 	ErrParse        = errors.New("parse error")
 )
 
+// rawDataTTL is how long ticks are kept in the sorted sets.
+const rawDataTTL = 2 * time.Minute
+
 func mapRedisErr(err error) error {
 	if err == nil {
 		return nil
@@ -48,16 +51,23 @@ func mapRedisErr(err error) error {
 	return err
 }
 
+// SaveRawData adds a tick to the sorted set of its pair. Ticks are scored by
+// the time of the trade in milliseconds, or by the time they are written if
+// the trade carries none, so that the latest tick is the last one traded.
 func (q *Queries) SaveRawData(ctx context.Context, exchanger string, data model.Trade) error {
 	key := fmt.Sprintf("prices:%s:%s", exchanger, data.Symbol)
 
-	ts := float64(time.Now().Unix())
+	now := time.Now().UnixMilli()
+	ts := data.Timestamp
+	if ts == 0 {
+		ts = now
+	}
 
 	pipe := q.client.TxPipeline()
 
-	pipe.ZAdd(ctx, key, redis.Z{Score: float64(ts), Member: fmt.Sprintf(`{"price":%.8f,"ts":%d}`, data.Price, int64(ts))})
-	pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprint(float64(ts-120)))
-	pipe.Expire(ctx, key, 2*time.Minute)
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(ts), Member: fmt.Sprintf(`{"price":%.8f,"ts":%d}`, data.Price, ts)})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprint(now-rawDataTTL.Milliseconds()))
+	pipe.Expire(ctx, key, rawDataTTL)
 
 	_, err := pipe.Exec(ctx)
 	if err != nil {
@@ -70,10 +80,10 @@ func (q *Queries) SaveRawData(ctx context.Context, exchanger string, data model.
 func (q *Queries) GetRawData(ctx context.Context, exchanger string, symbol string, interval time.Duration) ([]model.Trade, error) {
 	key := fmt.Sprintf("prices:%s:%s", exchanger, symbol)
 
-	now := time.Now().Unix()
-	from := now - int64(interval.Seconds())
+	now := time.Now().UnixMilli()
+	from := now - interval.Milliseconds()
 
-	res, err := q.client.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{Min: fmt.Sprint(from), Max: fmt.Sprint(now)}).Result()
+	res, err := q.client.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{Min: fmt.Sprint(from), Max: "+inf"}).Result()
 	if err != nil {
 		return nil, mapRedisErr(fmt.Errorf("redis ZRangeByScore %s: %w", key, err))
 	}
@@ -135,24 +145,71 @@ func (q *Queries) GetCollection(ctx context.Context) ([]string, []string, error)
 	}
 }
 
-func (q *Queries) GetLatest(ctx context.Context, exchange string, symbol string) (float64, error) {
+// GetLatest returns the latest tick of a pair together with the time it was
+// traded.
+func (q *Queries) GetLatest(ctx context.Context, exchange string, symbol string) (model.Quote, error) {
 	key := fmt.Sprintf("prices:%s:%s", exchange, symbol)
 
 	res, err := q.client.ZRevRangeWithScores(ctx, key, 0, 0).Result()
 	if err != nil {
-		return 0, mapRedisErr(fmt.Errorf("redis ZRevRange %s: %w", key, err))
+		return model.Quote{}, mapRedisErr(fmt.Errorf("redis ZRevRange %s: %w", key, err))
 	}
 
 	if len(res) == 0 {
-		return 0, ErrNoData
+		return model.Quote{}, ErrNoData
 	}
 
 	var trade model.Trade
 
 	_, err = fmt.Sscanf(res[0].Member.(string), `{"price":%f,"ts":%d}`, &trade.Price, &trade.Timestamp)
 	if err != nil {
-		return 0, fmt.Errorf("parse latest %s:%s: %v: %w", exchange, symbol, err, ErrParse)
+		return model.Quote{}, fmt.Errorf("parse latest %s:%s: %v: %w", exchange, symbol, err, ErrParse)
+	}
+
+	return model.Quote{
+		Exchange:  exchange,
+		Symbol:    symbol,
+		Price:     trade.Price,
+		Timestamp: time.UnixMilli(trade.Timestamp),
+	}, nil
+}
+
+// GetLatestBatch looks up the latest tick of every pair in one round trip.
+// Pairs without a tick are left out of the result.
+func (q *Queries) GetLatestBatch(ctx context.Context, pairs []model.Pair) ([]model.Quote, error) {
+	pipe := q.client.Pipeline()
+
+	cmds := make([]*redis.ZSliceCmd, len(pairs))
+	for i, p := range pairs {
+		cmds[i] = pipe.ZRevRangeWithScores(ctx, fmt.Sprintf("prices:%s:%s", p.Exchange, p.Symbol), 0, 0)
 	}
 
-	return trade.Price, nil
+	// A missing key is not an error for ZREVRANGE, so any error here is one
+	// of the connection.
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, mapRedisErr(fmt.Errorf("redis pipeline ZRevRange: %w", err))
+	}
+
+	quotes := make([]model.Quote, 0, len(pairs))
+	for i, cmd := range cmds {
+		res := cmd.Val()
+		if len(res) == 0 {
+			continue
+		}
+
+		var price float64
+		var ts int64
+		_, err := fmt.Sscanf(res[0].Member.(string), `{"price":%f,"ts":%d}`, &price, &ts)
+		if err != nil {
+			return nil, fmt.Errorf("parse latest %s:%s: %v: %w", pairs[i].Exchange, pairs[i].Symbol, err, ErrParse)
+		}
+
+		quotes = append(quotes, model.Quote{
+			Exchange:  pairs[i].Exchange,
+			Symbol:    pairs[i].Symbol,
+			Price:     price,
+			Timestamp: time.UnixMilli(ts),
+		})
+	}
+	return quotes, nil
 }
@@ -22,7 +22,7 @@ func TestQuerier(t *testing.T) {
 	err = q.SaveRawData(context.Background(), "exchanger1", model.Trade{
 		Symbol:    "BTCUSDT",
 		Price:     30000,
-		Timestamp: time.Now().Unix(),
+		Timestamp: time.Now().UnixMilli(),
 	})
 	if err != nil {
 		t.Fatalf("failed to insert raw data: %s", err.Error())
@@ -0,0 +1,138 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"marketflow/internal/core/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// usageTTL is how long the usage of a day is kept, in seconds.
+const usageTTL = 35 * 24 * 60 * 60
+
+// takeToken refills and takes from a token bucket kept as a hash of tokens
+// and the time of the last request, then counts the request. It uses the
+// clock of the Redis server so that instances with skewed clocks share one
+// bucket fairly. A burst of 0 skips the bucket.
+//
+// KEYS[1] bucket, KEYS[2] usage of the day
+// ARGV[1] tokens per millisecond, ARGV[2] burst, ARGV[3] usage field prefix,
+// ARGV[4] usage TTL in seconds
+var takeToken = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local allowed = 1
+local tokens = burst
+if burst > 0 then
+	local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+	tokens = tonumber(state[1]) or burst
+	local ts = tonumber(state[2]) or now
+	tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+	if tokens >= 1 then
+		tokens = tokens - 1
+	else
+		allowed = 0
+	end
+	redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+	redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate) + 1000)
+end
+
+local field = ARGV[3] .. (allowed == 1 and '|requests' or '|limited')
+redis.call('HINCRBY', KEYS[2], field, 1)
+redis.call('EXPIRE', KEYS[2], ARGV[4])
+return {allowed, tostring(tokens)}
+`)
+
+func usageKey(day string) string {
+	return "usage:" + day
+}
+
+func (q *Queries) Take(ctx context.Context, client, group string, limit model.RateLimit, day string) (model.RateDecision, error) {
+	var rate float64
+	var burst int
+	if limit.Enabled() {
+		rate = limit.PerMillisecond()
+		burst = limit.Burst
+	}
+
+	keys := []string{fmt.Sprintf("ratelimit:%s:%s", group, client), usageKey(day)}
+	res, err := takeToken.Run(ctx, q.client, keys,
+		strconv.FormatFloat(rate, 'g', -1, 64), burst, client+"|"+group, usageTTL).Slice()
+	if err != nil {
+		return model.RateDecision{}, mapRedisErr(fmt.Errorf("take token %s: %w", keys[0], err))
+	}
+	if len(res) != 2 {
+		return model.RateDecision{}, fmt.Errorf("take token %s: %w: %v", keys[0], ErrParse, res)
+	}
+
+	allowed, _ := res[0].(int64)
+	s, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return model.RateDecision{}, fmt.Errorf("take token %s: %w: %v", keys[0], ErrParse, err)
+	}
+
+	return limit.Decide(allowed == 1, tokens), nil
+}
+
+func (q *Queries) ListUsage(ctx context.Context, day string) ([]model.Usage, error) {
+	fields, err := q.client.HGetAll(ctx, usageKey(day)).Result()
+	if err != nil {
+		return nil, mapRedisErr(fmt.Errorf("list usage %s: %w", day, err))
+	}
+	return parseUsage(fields), nil
+}
+
+// parseUsage turns "client|group|requests" and "client|group|limited"
+// counters into one entry per client and group.
+func parseUsage(fields map[string]string) []model.Usage {
+	byClient := make(map[[2]string]*model.Usage)
+	for field, value := range fields {
+		rest, counter, ok := cutLast(field)
+		if !ok {
+			continue
+		}
+		client, group, ok := cutLast(rest)
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		id := [2]string{client, group}
+		u := byClient[id]
+		if u == nil {
+			u = &model.Usage{Client: client, Group: group}
+			byClient[id] = u
+		}
+		switch counter {
+		case "requests":
+			u.Requests += n
+		case "limited":
+			u.Limited += n
+		}
+	}
+
+	usage := make([]model.Usage, 0, len(byClient))
+	for _, u := range byClient {
+		usage = append(usage, *u)
+	}
+	return usage
+}
+
+func cutLast(s string) (before, after string, ok bool) {
+	i := strings.LastIndex(s, "|")
+	if i < 0 {
+		return "", "", false
+	}
+	return s[:i], s[i+1:], true
+}
@@ -5,9 +5,20 @@ CREATE TABLE market (
     timestamp TIMESTAMP NOT NULL DEFAULT NOW(),
     average_price NUMERIC(18, 8) NOT NULL,
     min_price NUMERIC(18, 8) NOT NULL,
-    max_price NUMERIC(18, 8) NOT NULL
+    max_price NUMERIC(18, 8) NOT NULL,
+    first_price NUMERIC(18, 8),
+    last_price NUMERIC(18, 8),
+    tick_count INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
 
+CREATE UNIQUE INDEX idx_market_window ON market (pair_name, exchange, timestamp);
+
+-- The rollups follow market rows by the time they were last written.
+CREATE INDEX idx_market_updated ON market (updated_at);
+
+-- raw_data holds the ticks written while Redis is unavailable, for as long as
+-- RETENTION_RAW_DATA keeps them. It is not an archive of every tick.
 CREATE TABLE raw_data (
     pair_name VARCHAR(20) NOT NULL,
     exchange VARCHAR(50) NOT NULL,
@@ -15,4 +26,114 @@ CREATE TABLE raw_data (
     created_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
 
+CREATE INDEX idx_raw_data_pair ON raw_data (pair_name, exchange, created_at);
+
 CREATE INDEX idx_market_data_pair ON market (pair_name);
+
+CREATE TABLE market_rollup (
+    resolution VARCHAR(8) NOT NULL,
+    pair_name VARCHAR(20) NOT NULL,
+    exchange VARCHAR(50) NOT NULL,
+    bucket TIMESTAMP NOT NULL,
+    average_price NUMERIC(18, 8) NOT NULL,
+    min_price NUMERIC(18, 8) NOT NULL,
+    max_price NUMERIC(18, 8) NOT NULL,
+    sample_count INTEGER NOT NULL,
+    first_price NUMERIC(18, 8),
+    last_price NUMERIC(18, 8),
+    tick_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (resolution, pair_name, exchange, bucket)
+);
+
+CREATE TABLE rollup_watermark (
+    resolution VARCHAR(8) PRIMARY KEY,
+    rolled_up_to TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE symbols (
+    exchange VARCHAR(50) NOT NULL,
+    pair_name VARCHAR(20) NOT NULL,
+    first_seen TIMESTAMP NOT NULL,
+    last_tick TIMESTAMP NOT NULL,
+    PRIMARY KEY (exchange, pair_name)
+);
+
+-- Only the SHA-256 of a key is stored; the key itself is shown once when it
+-- is issued. prefix identifies a key in listings.
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('read', 'operator', 'admin')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP
+);
+
+-- Deleted rules are kept so that their events stay in the history.
+CREATE TABLE alert_rules (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('price', 'change', 'spread')),
+    pair_name VARCHAR(20) NOT NULL,
+    exchange VARCHAR(50) NOT NULL,
+    other_exchange VARCHAR(50) NOT NULL DEFAULT '',
+    operator VARCHAR(8) NOT NULL CHECK (operator IN ('above', 'below')),
+    threshold DOUBLE PRECISION NOT NULL,
+    hysteresis DOUBLE PRECISION NOT NULL DEFAULT 0,
+    window_seconds INTEGER NOT NULL DEFAULT 0,
+    cooldown_seconds INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE alert_events (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id BIGINT NOT NULL REFERENCES alert_rules (id),
+    type VARCHAR(16) NOT NULL CHECK (type IN ('triggered', 'resolved')),
+    value DOUBLE PRECISION NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    -- Every instance raises the same events; one per rule, type and minute
+    -- is kept.
+    bucket TIMESTAMP NOT NULL,
+    UNIQUE (rule_id, type, bucket)
+);
+
+CREATE INDEX idx_alert_events_time ON alert_events (created_at);
+CREATE INDEX idx_alert_events_rule ON alert_events (rule_id, created_at);
+
+-- The secret has to be kept as it is, since every payload is signed with it.
+CREATE TABLE webhooks (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret VARCHAR(100) NOT NULL,
+    topics TEXT[] NOT NULL,
+    symbols TEXT[] NOT NULL DEFAULT '{}',
+    exchanges TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES webhooks (id),
+    topic VARCHAR(16) NOT NULL,
+    -- Identifies an event that every instance queues; NULL for the ones
+    -- that are never merged.
+    event_key TEXT,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_event ON webhook_deliveries (webhook_id, event_key);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, created_at);
+CREATE INDEX idx_webhook_deliveries_time ON webhook_deliveries (created_at);
@@ -48,11 +48,14 @@ func (l *LiveExchanger) Stream(ctx context.Context, out chan<- conc.Task, result
 
 	conn, err := net.Dial("tcp", net.JoinHostPort(l.Host, l.Port))
 	if err != nil {
+		connectionErrors.WithLabelValues(l.Name).Inc()
 		l.sendResult(results, err)
 		return
 	}
+	countConnect(l.Name)
 
 	if err = l.handle(ctx, conn, out); err != nil {
+		connectionErrors.WithLabelValues(l.Name).Inc()
 		l.sendResult(results, err)
 		return
 	}
@@ -71,6 +74,7 @@ func (l *LiveExchanger) Stop() error {
 func (l *LiveExchanger) handle(ctx context.Context, conn net.Conn, out chan<- conc.Task) error {
 	defer conn.Close()
 	scanner := bufio.NewScanner(conn)
+	received := messagesReceived.WithLabelValues(l.Name)
 
 	for scanner.Scan() {
 		select {
@@ -78,6 +82,7 @@ func (l *LiveExchanger) handle(ctx context.Context, conn net.Conn, out chan<- co
 			return nil
 		case out <- conc.WrapTask(l.Name, scanner.Text()):
 			l.receivedTasks++
+			received.Inc()
 		}
 	}
 
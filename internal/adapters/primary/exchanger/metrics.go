@@ -0,0 +1,33 @@
+package exchanger
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	messagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "marketflow_exchange_messages_total",
+		Help: "Messages received from the exchange.",
+	}, []string{"exchange"})
+	reconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "marketflow_exchange_reconnects_total",
+		Help: "Connections to the exchange made after its first one, such as after a switch back from test mode.",
+	}, []string{"exchange"})
+	connectionErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "marketflow_exchange_connection_errors_total",
+		Help: "Connections to the exchange that failed or were lost.",
+	}, []string{"exchange"})
+
+	// connected remembers the exchanges connected to at least once.
+	connected sync.Map
+)
+
+// countConnect records a connection to the exchange name.
+func countConnect(name string) {
+	if _, again := connected.LoadOrStore(name, true); again {
+		reconnects.WithLabelValues(name).Inc()
+	}
+}
@@ -32,11 +32,13 @@ func (t *TestExchanger) Stream(ctx context.Context, out chan<- conc.Task, result
 
 	t.cancel = cancel
 	ticker := time.NewTicker(t.interval)
+	received := messagesReceived.WithLabelValues(t.Name)
 
 	for {
 		select {
 		case <-ticker.C:
 			out <- generateTestData(t.Name)
+			received.Inc()
 		case <-ctx.Done():
 			results <- Result{Name: t.Name, Err: nil}
 			return
@@ -60,37 +62,37 @@ func generateTestData(name string) conc.Task {
 		data = model.Trade{
 			Symbol:    "BTCUSDT",
 			Price:     100000 + rand.Float64()*10000,
-			Timestamp: time.Now().Unix(),
+			Timestamp: time.Now().UnixMilli(),
 		}
 	case 1:
 		data = model.Trade{
 			Symbol:    "ETHUSDT",
 			Price:     2000 + rand.Float64()*100,
-			Timestamp: time.Now().Unix(),
+			Timestamp: time.Now().UnixMilli(),
 		}
 	case 2:
 		data = model.Trade{
 			Symbol:    "SOLUSDT",
 			Price:     100 + rand.Float64()*10,
-			Timestamp: time.Now().Unix(),
+			Timestamp: time.Now().UnixMilli(),
 		}
 	case 3:
 		data = model.Trade{
 			Symbol:    "DOGEUSDT",
 			Price:     0.1 + rand.Float64()*0.1,
-			Timestamp: time.Now().Unix(),
+			Timestamp: time.Now().UnixMilli(),
 		}
 	case 4:
 		data = model.Trade{
 			Symbol:    "TONUSDT",
 			Price:     1.2 + rand.Float64(),
-			Timestamp: time.Now().Unix(),
+			Timestamp: time.Now().UnixMilli(),
 		}
 	default:
 		data = model.Trade{
 			Symbol:    "BTCUSDT",
 			Price:     100000 + rand.Float64()*1000,
-			Timestamp: time.Now().Unix(),
+			Timestamp: time.Now().UnixMilli(),
 		}
 	}
 
@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	marketflowv1 "marketflow/api/marketflow/v1"
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// apiKeyMetadata is an alternative to an "authorization: Bearer" entry.
+const apiKeyMetadata = "x-api-key"
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, secret string) (model.APIKey, error)
+}
+
+// methodRoles is the least a key must allow to call a method. Methods that
+// are missing need read.
+var methodRoles = map[string]model.Role{
+	marketflowv1.MarketFlow_SetMode_FullMethodName: model.RoleOperator,
+}
+
+// WithAuth makes every call carry an API key in its metadata. Without it the
+// server accepts any call.
+func WithAuth(a Authenticator, s *Server) {
+	s.auth = a
+}
+
+// WithAnonymousRead lets calls without a key use the methods that need read.
+func WithAnonymousRead(allow bool, s *Server) {
+	s.anonymousRead = allow
+}
+
+// authorize returns the key a call was made with, or nil when the method
+// could be called without one.
+func (s *Server) authorize(ctx context.Context, method string) (*model.APIKey, error) {
+	if s.auth == nil {
+		return nil, nil
+	}
+
+	role, ok := methodRoles[method]
+	if !ok {
+		role = model.RoleRead
+	}
+	if s.anonymousRead && role == model.RoleRead {
+		return nil, nil
+	}
+
+	if err := s.limitGroup(ctx, service.RateGroupAuth, nil); err != nil {
+		return nil, err
+	}
+	key, err := s.auth.Authenticate(ctx, secretFrom(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err := service.Authorize(key, role); err != nil {
+		return nil, toStatus(err)
+	}
+	return &key, nil
+}
+
+// guard authenticates and rate limits every call.
+func (s *Server) guard(ctx context.Context, method string) error {
+	key, err := s.authorize(ctx, method)
+	if err != nil {
+		return err
+	}
+	return s.limit(ctx, method, key)
+}
+
+func secretFrom(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if scheme, token, ok := strings.Cut(v, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if v := md.Get(apiKeyMetadata); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (s *Server) unaryGuard(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.guard(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) streamGuard(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.guard(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,288 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	marketflowv1 "marketflow/api/marketflow/v1"
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxStreamRate is the highest conflation rate a client may ask for.
+const maxStreamRate = 50
+
+// statusCodes maps error kinds onto gRPC codes.
+var statusCodes = map[model.ErrorKind]codes.Code{
+	model.KindInvalid:         codes.InvalidArgument,
+	model.KindNotFound:        codes.NotFound,
+	model.KindConflict:        codes.FailedPrecondition,
+	model.KindUnavailable:     codes.Unavailable,
+	model.KindUnauthenticated: codes.Unauthenticated,
+	model.KindForbidden:       codes.PermissionDenied,
+	model.KindRateLimited:     codes.ResourceExhausted,
+}
+
+// toStatus maps service errors onto gRPC codes.
+func toStatus(err error) error {
+	if code, ok := statusCodes[model.KindOf(err)]; ok {
+		return status.Error(code, err.Error())
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+func exchangeOf(exchange string) string {
+	if exchange == "" {
+		return model.GlobalExchange
+	}
+	return exchange
+}
+
+// timeRange resolves a request range the same way the REST API does.
+func (s *Server) timeRange(req *marketflowv1.PriceRequest) (model.TimeRange, error) {
+	var from, to string
+	if req.GetFrom() != nil {
+		from = strconv.FormatInt(req.GetFrom().AsTime().UnixMilli(), 10)
+	}
+	if req.GetTo() != nil {
+		to = strconv.FormatInt(req.GetTo().AsTime().UnixMilli(), 10)
+	}
+	return service.ParseRange(req.GetPeriod(), from, to, time.Now().In(s.location))
+}
+
+func (s *Server) GetLatestPrice(ctx context.Context, req *marketflowv1.PriceRequest) (*marketflowv1.PriceResponse, error) {
+	exchange := exchangeOf(req.GetExchange())
+
+	var maxAge time.Duration
+	if req.GetMaxAge() != nil {
+		maxAge = req.GetMaxAge().AsDuration()
+		if maxAge <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "max_age must be positive")
+		}
+	}
+
+	quote, err := s.stats.GetLatestPrice(ctx, exchange, req.GetSymbol())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	now := time.Now()
+	age := quote.Age(now)
+	if maxAge > 0 && age > maxAge {
+		return nil, status.Errorf(codes.Unavailable, "%v: it is %s old, max_age is %s", service.ErrStalePrice, age.Truncate(time.Millisecond), maxAge)
+	}
+
+	return &marketflowv1.PriceResponse{
+		Symbol:        req.GetSymbol(),
+		Exchange:      exchange,
+		Price:         quote.Price,
+		Timestamp:     timestamppb.New(now),
+		DataTimestamp: timestamppb.New(quote.Timestamp),
+		AgeMs:         age.Milliseconds(),
+		Stale:         s.staleAfter > 0 && age > s.staleAfter,
+	}, nil
+}
+
+func (s *Server) GetHighestPrice(ctx context.Context, req *marketflowv1.PriceRequest) (*marketflowv1.PriceResponse, error) {
+	return s.periodPrice(ctx, req, s.stats.GetHighestPrice)
+}
+
+func (s *Server) GetLowestPrice(ctx context.Context, req *marketflowv1.PriceRequest) (*marketflowv1.PriceResponse, error) {
+	return s.periodPrice(ctx, req, s.stats.GetLowestPrice)
+}
+
+func (s *Server) GetAveragePrice(ctx context.Context, req *marketflowv1.PriceRequest) (*marketflowv1.PriceResponse, error) {
+	return s.periodPrice(ctx, req, s.stats.GetAveragePrice)
+}
+
+type periodFunc func(ctx context.Context, exchange, symbol string, rng model.TimeRange) (float64, error)
+
+func (s *Server) periodPrice(ctx context.Context, req *marketflowv1.PriceRequest, get periodFunc) (*marketflowv1.PriceResponse, error) {
+	exchange := exchangeOf(req.GetExchange())
+
+	rng, err := s.timeRange(req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	price, err := get(ctx, exchange, req.GetSymbol(), rng)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &marketflowv1.PriceResponse{
+		Symbol:    req.GetSymbol(),
+		Exchange:  exchange,
+		Price:     price,
+		From:      timestamppb.New(rng.From),
+		To:        timestamppb.New(rng.To),
+		Timestamp: timestamppb.Now(),
+	}, nil
+}
+
+func (s *Server) GetStats(ctx context.Context, req *marketflowv1.PriceRequest) (*marketflowv1.StatsResponse, error) {
+	exchange := exchangeOf(req.GetExchange())
+
+	rng, err := s.timeRange(req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	summary, err := s.stats.GetSummary(ctx, exchange, req.GetSymbol(), rng)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &marketflowv1.StatsResponse{
+		Symbol:   req.GetSymbol(),
+		Exchange: exchange,
+		From:     timestamppb.New(rng.From),
+		To:       timestamppb.New(rng.To),
+		Start:    timestamppb.New(summary.Start),
+		End:      timestamppb.New(summary.End),
+		Min:      summary.Min,
+		Max:      summary.Max,
+		Average:  summary.Average,
+		First:    summary.First,
+		Last:     summary.Last,
+		Count:    summary.TickCount,
+	}
+	if summary.First != 0 {
+		resp.ChangePct = (summary.Last - summary.First) / summary.First * 100
+	}
+	return resp, nil
+}
+
+func (s *Server) GetCandles(ctx context.Context, req *marketflowv1.CandlesRequest) (*marketflowv1.CandlesResponse, error) {
+	exchange := exchangeOf(req.GetExchange())
+
+	candles, err := s.stats.GetCandles(ctx, exchange, req.GetSymbol(), req.GetInterval(), int(req.GetLimit()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &marketflowv1.CandlesResponse{
+		Symbol:   req.GetSymbol(),
+		Exchange: exchange,
+		Interval: req.GetInterval(),
+		Candles:  make([]*marketflowv1.Candle, 0, len(candles)),
+	}
+	if resp.Interval == "" {
+		resp.Interval = model.MarketResolution.Name
+	}
+	for _, c := range candles {
+		resp.Candles = append(resp.Candles, &marketflowv1.Candle{
+			Start:     timestamppb.New(c.Start),
+			Open:      c.Open,
+			High:      c.High,
+			Low:       c.Low,
+			Close:     c.Close,
+			Average:   c.Average,
+			TickCount: c.TickCount,
+		})
+	}
+	return resp, nil
+}
+
+func (s *Server) SetMode(ctx context.Context, req *marketflowv1.SetModeRequest) (*marketflowv1.SetModeResponse, error) {
+	var switchMode func() error
+	switch req.GetMode() {
+	case marketflowv1.Mode_MODE_LIVE:
+		switchMode = s.switchToLiveMode
+	case marketflowv1.Mode_MODE_TEST:
+		switchMode = s.switchToTestMode
+	default:
+		return nil, status.Error(codes.InvalidArgument, "mode must be MODE_LIVE or MODE_TEST")
+	}
+
+	if switchMode == nil {
+		return nil, status.Error(codes.Unavailable, "mode switching is not available yet")
+	}
+	if err := switchMode(); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &marketflowv1.SetModeResponse{Mode: req.GetMode()}, nil
+}
+
+func (s *Server) SubscribePrices(req *marketflowv1.SubscribePricesRequest, stream grpc.ServerStreamingServer[marketflowv1.Tick]) error {
+	if req.GetRate() > maxStreamRate {
+		return status.Errorf(codes.InvalidArgument, "rate must be between 0 and %d", maxStreamRate)
+	}
+
+	filter := service.Filter{
+		Topics:    []string{model.TopicTicks},
+		Symbols:   req.GetSymbols(),
+		Exchanges: req.GetExchanges(),
+	}
+	sub := s.broadcaster.Subscribe(filter, service.DefaultSubscriberBuffer)
+	if sub == nil {
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+	defer s.broadcaster.Unsubscribe(sub)
+
+	var flush <-chan time.Time
+	conflator := service.NewConflator()
+	if rate := req.GetRate(); rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(rate))
+		defer ticker.Stop()
+		flush = ticker.C
+	}
+
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				if sub.Evicted() {
+					return status.Error(codes.ResourceExhausted, "client too slow")
+				}
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+			quote, ok := e.Data.(model.Quote)
+			if !ok {
+				continue
+			}
+
+			if flush != nil {
+				conflator.Add(quote)
+				continue
+			}
+			if err := stream.Send(tick(quote)); err != nil {
+				return err
+			}
+
+		case <-flush:
+			for _, quote := range conflator.Flush() {
+				if err := stream.Send(tick(quote)); err != nil {
+					return err
+				}
+			}
+
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func tick(q model.Quote) *marketflowv1.Tick {
+	return &marketflowv1.Tick{
+		Symbol:    q.Symbol,
+		Exchange:  q.Exchange,
+		Price:     q.Price,
+		Timestamp: timestamppb.New(q.Timestamp),
+	}
+}
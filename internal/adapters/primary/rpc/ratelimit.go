@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+
+	marketflowv1 "marketflow/api/marketflow/v1"
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type Limiter interface {
+	Take(ctx context.Context, client, group string) (model.RateDecision, error)
+}
+
+// methodGroups puts every method into the rate limit group of the REST
+// routes that do the same.
+var methodGroups = map[string]string{
+	marketflowv1.MarketFlow_GetLatestPrice_FullMethodName:  service.RateGroupLatest,
+	marketflowv1.MarketFlow_GetHighestPrice_FullMethodName: service.RateGroupHistory,
+	marketflowv1.MarketFlow_GetLowestPrice_FullMethodName:  service.RateGroupHistory,
+	marketflowv1.MarketFlow_GetAveragePrice_FullMethodName: service.RateGroupHistory,
+	marketflowv1.MarketFlow_GetStats_FullMethodName:        service.RateGroupHistory,
+	marketflowv1.MarketFlow_GetCandles_FullMethodName:      service.RateGroupHistory,
+	marketflowv1.MarketFlow_SetMode_FullMethodName:         service.RateGroupAdmin,
+	marketflowv1.MarketFlow_SubscribePrices_FullMethodName: service.RateGroupStream,
+}
+
+// WithRateLimiter limits the calls of every client, which is its API key or
+// else its address. The limits are shared with the REST API.
+func WithRateLimiter(l Limiter, s *Server) {
+	s.limiter = l
+}
+
+func (s *Server) limit(ctx context.Context, method string, key *model.APIKey) error {
+	group, ok := methodGroups[method]
+	if !ok {
+		return nil
+	}
+	return s.limitGroup(ctx, group, key)
+}
+
+func (s *Server) limitGroup(ctx context.Context, group string, key *model.APIKey) error {
+	if s.limiter == nil {
+		return nil
+	}
+
+	d, err := s.limiter.Take(ctx, clientID(ctx, key), group)
+	if err != nil || d.Allowed {
+		return nil
+	}
+	retryAfter := int(math.Ceil(d.RetryAfter.Seconds()))
+	return status.Errorf(codes.ResourceExhausted, "%v, retry in %ds", service.ErrRateLimited, retryAfter)
+}
+
+// clientID matches the IDs the REST API uses, so usage is reported the same.
+func clientID(ctx context.Context, key *model.APIKey) string {
+	if key != nil {
+		return "key:" + strconv.FormatInt(key.ID, 10)
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host := p.Addr.String()
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		return "ip:" + host
+	}
+	return "ip:unknown"
+}
@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	marketflowv1 "marketflow/api/marketflow/v1"
+	"marketflow/internal/core/service"
+
+	"google.golang.org/grpc"
+)
+
+type ServerConfig struct {
+	Port string
+}
+
+func NewServerConfig(port string) (*ServerConfig, error) {
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, err
+	} else if p <= 0 || p >= 65536 {
+		return nil, errors.New("port out of range: " + port)
+	}
+
+	return &ServerConfig{
+		Port: port,
+	}, nil
+}
+
+// Server serves the gRPC API on its own port, next to the REST server.
+type Server struct {
+	marketflowv1.UnimplementedMarketFlowServer
+
+	config *ServerConfig
+	server *grpc.Server
+
+	stats            *service.Stats
+	broadcaster      *service.Broadcaster
+	location         *time.Location
+	staleAfter       time.Duration
+	switchToTestMode func() error
+	switchToLiveMode func() error
+	auth             Authenticator
+	anonymousRead    bool
+	limiter          Limiter
+}
+
+func NewServer(config *ServerConfig, stats *service.Stats) *Server {
+	s := &Server{
+		config:     config,
+		stats:      stats,
+		location:   time.UTC,
+		staleAfter: service.DefaultStaleAfter,
+	}
+	s.server = grpc.NewServer(
+		grpc.UnaryInterceptor(s.unaryGuard),
+		grpc.StreamInterceptor(s.streamGuard),
+	)
+	marketflowv1.RegisterMarketFlowServer(s.server, s)
+	return s
+}
+
+func WithBroadcaster(b *service.Broadcaster, s *Server) {
+	s.broadcaster = b
+}
+
+// WithLocation sets the time zone calendar periods such as "today" are
+// aligned to.
+func WithLocation(loc *time.Location, s *Server) {
+	s.location = loc
+}
+
+// WithStaleAfter sets how old the latest tick may be before it is flagged as
+// stale.
+func WithStaleAfter(d time.Duration, s *Server) {
+	s.staleAfter = d
+}
+
+func WithTestModeSwitch(f func() error, s *Server) {
+	s.switchToTestMode = f
+}
+
+func WithLiveModeSwitch(f func() error, s *Server) {
+	s.switchToLiveMode = f
+}
+
+func (s *Server) Start() error {
+	lis, err := net.Listen("tcp", ":"+s.config.Port)
+	if err != nil {
+		return err
+	}
+
+	if err := s.server.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		slog.Error("gRPC server error", "error", err)
+		return err
+	}
+	return nil
+}
+
+// Shutdown waits for running calls to finish until ctx is done and then
+// cancels them.
+func (s *Server) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,144 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	marketflowv1 "marketflow/api/marketflow/v1"
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func dial(t *testing.T, s *Server) marketflowv1.MarketFlowClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	go s.server.Serve(lis)
+	t.Cleanup(s.server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return marketflowv1.NewMarketFlowClient(conn)
+}
+
+func TestServer_SubscribePrices(t *testing.T) {
+	b := service.NewBroadcaster()
+	s := NewServer(&ServerConfig{}, nil)
+	WithBroadcaster(b, s)
+	client := dial(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.SubscribePrices(ctx, &marketflowv1.SubscribePricesRequest{Symbols: []string{model.BTCUSDT}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subscription is only registered once the server has the request.
+	go func() {
+		for ctx.Err() == nil {
+			b.Observe("exchange1", model.Trade{Symbol: model.ETHUSDT, Price: 1})
+			b.Observe(model.GlobalExchange, model.Trade{Symbol: model.BTCUSDT, Price: 1})
+			b.Observe("exchange2", model.Trade{Symbol: model.BTCUSDT, Price: 2})
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	tick, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tick.GetExchange() != "exchange2" || tick.GetSymbol() != model.BTCUSDT || tick.GetPrice() != 2 {
+		t.Errorf("unexpected tick %v", tick)
+	}
+}
+
+func TestServer_SetMode(t *testing.T) {
+	s := NewServer(&ServerConfig{}, nil)
+	WithTestModeSwitch(func() error { return nil }, s)
+	WithLiveModeSwitch(func() error {
+		return model.NewError(model.KindConflict, "already_in_live_mode", "already in live mode")
+	}, s)
+	client := dial(t, s)
+
+	ctx := context.Background()
+
+	resp, err := client.SetMode(ctx, &marketflowv1.SetModeRequest{Mode: marketflowv1.Mode_MODE_TEST})
+	if err != nil || resp.GetMode() != marketflowv1.Mode_MODE_TEST {
+		t.Errorf("expected test mode, got %v (%v)", resp, err)
+	}
+
+	_, err = client.SetMode(ctx, &marketflowv1.SetModeRequest{Mode: marketflowv1.Mode_MODE_LIVE})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected %v, got %v", codes.FailedPrecondition, err)
+	}
+
+	_, err = client.SetMode(ctx, &marketflowv1.SetModeRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected %v, got %v", codes.InvalidArgument, err)
+	}
+}
+
+func TestServer_InvalidRange(t *testing.T) {
+	s := NewServer(&ServerConfig{}, nil)
+	client := dial(t, s)
+
+	_, err := client.GetHighestPrice(context.Background(), &marketflowv1.PriceRequest{Symbol: model.BTCUSDT, Period: "soon"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected %v, got %v", codes.InvalidArgument, err)
+	}
+}
+
+type fakeAuth map[string]model.Role
+
+func (f fakeAuth) Authenticate(_ context.Context, secret string) (model.APIKey, error) {
+	role, ok := f[secret]
+	if !ok {
+		return model.APIKey{}, service.ErrUnauthenticated
+	}
+	return model.APIKey{Role: role}, nil
+}
+
+func TestServer_Auth(t *testing.T) {
+	s := NewServer(&ServerConfig{}, nil)
+	WithTestModeSwitch(func() error { return nil }, s)
+	WithAuth(fakeAuth{"reader": model.RoleRead, "operator": model.RoleOperator}, s)
+	client := dial(t, s)
+
+	req := &marketflowv1.SetModeRequest{Mode: marketflowv1.Mode_MODE_TEST}
+	tests := []struct {
+		key  string
+		want codes.Code
+	}{
+		{"", codes.Unauthenticated},
+		{"unknown", codes.Unauthenticated},
+		{"reader", codes.PermissionDenied},
+		{"operator", codes.OK},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.key != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+tt.key)
+		}
+		if _, err := client.SetMode(ctx, req); status.Code(err) != tt.want {
+			t.Errorf("key %q: expected %v, got %v", tt.key, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+)
+
+// maxAlertRuleBody bounds the body of a rule request.
+const maxAlertRuleBody = 4 << 10
+
+// AlertRuleRequest defines a rule. Window and Cooldown are durations such as
+// "5m"; Cooldown defaults to service.DefaultAlertCooldown.
+type AlertRuleRequest struct {
+	Name          string  `json:"name"`
+	Kind          string  `json:"kind"`
+	Symbol        string  `json:"symbol"`
+	Exchange      string  `json:"exchange"`
+	OtherExchange string  `json:"other_exchange"`
+	Operator      string  `json:"operator"`
+	Threshold     float64 `json:"threshold"`
+	Hysteresis    float64 `json:"hysteresis"`
+	Window        string  `json:"window"`
+	Cooldown      string  `json:"cooldown"`
+}
+
+type AlertRuleResponse struct {
+	ID            int64      `json:"id"`
+	Name          string     `json:"name"`
+	Kind          string     `json:"kind"`
+	PairName      string     `json:"pair_name"`
+	Exchange      string     `json:"exchange"`
+	OtherExchange string     `json:"other_exchange,omitempty"`
+	Operator      string     `json:"operator"`
+	Threshold     float64    `json:"threshold"`
+	Hysteresis    float64    `json:"hysteresis"`
+	Window        string     `json:"window,omitempty"`
+	Cooldown      string     `json:"cooldown"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Firing        bool       `json:"firing"`
+	LastTriggered *time.Time `json:"last_triggered,omitempty"`
+}
+
+type AlertEventResponse struct {
+	RuleID        int64     `json:"rule_id"`
+	RuleName      string    `json:"rule_name"`
+	Type          string    `json:"type"`
+	Kind          string    `json:"kind"`
+	PairName      string    `json:"pair_name"`
+	Exchange      string    `json:"exchange"`
+	OtherExchange string    `json:"other_exchange,omitempty"`
+	Value         float64   `json:"value"`
+	Threshold     float64   `json:"threshold"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+type AlertHistoryResponse struct {
+	From   time.Time            `json:"from"`
+	To     time.Time            `json:"to"`
+	Events []AlertEventResponse `json:"events"`
+}
+
+func WithAlerts(a *service.Alerts, h *Handler) {
+	h.alerts = a
+}
+
+func alertRuleResponse(rule model.AlertRule) AlertRuleResponse {
+	response := AlertRuleResponse{
+		ID:            rule.ID,
+		Name:          rule.Name,
+		Kind:          rule.Kind,
+		PairName:      rule.Symbol,
+		Exchange:      rule.Exchange,
+		OtherExchange: rule.OtherExchange,
+		Operator:      rule.Operator,
+		Threshold:     rule.Threshold,
+		Hysteresis:    rule.Hysteresis,
+		Cooldown:      rule.Cooldown.String(),
+		CreatedAt:     rule.CreatedAt,
+		Firing:        rule.Firing,
+	}
+	if rule.Window > 0 {
+		response.Window = rule.Window.String()
+	}
+	if !rule.LastTriggered.IsZero() {
+		response.LastTriggered = &rule.LastTriggered
+	}
+	return response
+}
+
+func alertEventResponse(e model.AlertEvent) AlertEventResponse {
+	return AlertEventResponse{
+		RuleID:        e.RuleID,
+		RuleName:      e.RuleName,
+		Type:          e.Type,
+		Kind:          e.Kind,
+		PairName:      e.Symbol,
+		Exchange:      e.Exchange,
+		OtherExchange: e.OtherExchange,
+		Value:         e.Value,
+		Threshold:     e.Threshold,
+		Timestamp:     e.Time,
+	}
+}
+
+func (h *Handler) ListAlertRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.alerts.Rules(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := make([]AlertRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		response = append(response, alertRuleResponse(rule))
+	}
+	writeJSONResponse(w, response, http.StatusOK)
+}
+
+func (h *Handler) CreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	var req AlertRuleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAlertRuleBody)).Decode(&req); err != nil {
+		writeError(w, r, invalidBody("invalid request body: %v", err))
+		return
+	}
+
+	rule := model.AlertRule{
+		Name:          req.Name,
+		Kind:          req.Kind,
+		Symbol:        req.Symbol,
+		Exchange:      req.Exchange,
+		OtherExchange: req.OtherExchange,
+		Operator:      req.Operator,
+		Threshold:     req.Threshold,
+		Hysteresis:    req.Hysteresis,
+		Cooldown:      service.DefaultAlertCooldown,
+	}
+	if req.Window != "" {
+		d, err := time.ParseDuration(req.Window)
+		if err != nil {
+			writeError(w, r, invalidBody("invalid window %q", req.Window))
+			return
+		}
+		rule.Window = d
+	}
+	if req.Cooldown != "" {
+		d, err := time.ParseDuration(req.Cooldown)
+		if err != nil {
+			writeError(w, r, invalidBody("invalid cooldown %q", req.Cooldown))
+			return
+		}
+		rule.Cooldown = d
+	}
+
+	rule, err := h.alerts.Create(r.Context(), rule)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSONResponse(w, alertRuleResponse(rule), http.StatusCreated)
+}
+
+func (h *Handler) DeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, r, invalidParameter("id", "invalid id %q", r.PathValue("id")))
+		return
+	}
+
+	if err := h.alerts.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AlertHistory lists the events of every rule, or of rule_id, over a period,
+// newest first.
+func (h *Handler) AlertHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var ruleID int64
+	if v := query.Get("rule_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(w, r, invalidParameter("rule_id", "invalid rule_id: %s", v))
+			return
+		}
+		ruleID = id
+	}
+
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, r, invalidParameter("limit", "invalid limit: %s", v))
+			return
+		}
+		limit = n
+	}
+
+	rng, err := h.parseRange(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	events, err := h.alerts.History(r.Context(), ruleID, rng, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := AlertHistoryResponse{
+		From:   rng.From,
+		To:     rng.To,
+		Events: make([]AlertEventResponse, 0, len(events)),
+	}
+	for _, e := range events {
+		response.Events = append(response.Events, alertEventResponse(e))
+	}
+	writeJSONResponse(w, response, http.StatusOK)
+}
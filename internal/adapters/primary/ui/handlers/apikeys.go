@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+)
+
+// maxAPIKeyBody bounds the body of a key request.
+const maxAPIKeyBody = 4 << 10
+
+type APIKeyRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type APIKeyResponse struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Role      string     `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// Key is only set when the key is issued.
+	Key string `json:"key,omitempty"`
+}
+
+func WithAuth(a *service.Auth, h *Handler) {
+	h.auth = a
+}
+
+func apiKeyResponse(key model.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Role:      string(key.Role),
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.auth.List(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, apiKeyResponse(key))
+	}
+	writeJSONResponse(w, response, http.StatusOK)
+}
+
+// IssueAPIKey creates a key. The response is the only place the key itself
+// ever appears.
+func (h *Handler) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req APIKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIKeyBody)).Decode(&req); err != nil {
+		writeError(w, r, invalidBody("invalid request body: %v", err))
+		return
+	}
+
+	key, secret, err := h.auth.Issue(r.Context(), req.Name, model.Role(req.Role))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := apiKeyResponse(key)
+	response.Key = secret
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSONResponse(w, response, http.StatusCreated)
+}
+
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, r, invalidParameter("id", "invalid id %q", r.PathValue("id")))
+		return
+	}
+
+	if err := h.auth.Revoke(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
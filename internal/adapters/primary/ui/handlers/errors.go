@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"marketflow/internal/adapters/primary/ui/middleware"
+	"marketflow/internal/core/model"
+)
+
+// Codes of the errors that do not come from the domain.
+const (
+	codeInvalidParameter   = "invalid_parameter"
+	codeInvalidBody        = "invalid_body"
+	codeBackendUnavailable = "backend_unavailable"
+	codeShuttingDown       = "shutting_down"
+	codeInternal           = "internal"
+)
+
+// ErrorResponse is the body of every failed request. Error is meant for
+// people, Code for programs; Details depend on the code.
+type ErrorResponse struct {
+	Error     string         `json:"error"`
+	Code      string         `json:"code"`
+	Details   map[string]any `json:"details,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+}
+
+var errShuttingDown = model.NewError(model.KindUnavailable, codeShuttingDown, "server is shutting down")
+
+// statusCodes maps error kinds onto HTTP status codes.
+var statusCodes = map[model.ErrorKind]int{
+	model.KindInvalid:         http.StatusBadRequest,
+	model.KindNotFound:        http.StatusNotFound,
+	model.KindConflict:        http.StatusConflict,
+	model.KindUnavailable:     http.StatusServiceUnavailable,
+	model.KindUnauthenticated: http.StatusUnauthorized,
+	model.KindForbidden:       http.StatusForbidden,
+	model.KindRateLimited:     http.StatusTooManyRequests,
+}
+
+// writeError writes err with the status code of its kind. Errors that are not
+// domain errors are reported as 503 when a backend could not be reached and
+// as 500 otherwise; the message of a 500 is only logged.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	response := ErrorResponse{
+		Error:     err.Error(),
+		RequestID: middleware.RequestIDFrom(r.Context()),
+	}
+
+	var status int
+	var netErr net.Error
+	if e, ok := model.AsError(err); ok {
+		response.Code = e.Code
+		response.Details = e.Details
+		status = statusCodes[e.Kind]
+	} else if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		response.Code = codeBackendUnavailable
+		status = http.StatusServiceUnavailable
+	}
+
+	if status == 0 {
+		slog.ErrorContext(r.Context(), "request failed", "request_id", response.RequestID, "error", err)
+		response.Error = "internal error"
+		response.Code = codeInternal
+		status = http.StatusInternalServerError
+	}
+
+	writeJSONResponse(w, response, status)
+}
+
+// WriteError writes err the way the handlers do, for middleware that rejects
+// requests before they reach a handler.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	writeError(w, r, err)
+}
+
+// invalidParameter reports a query or path parameter that could not be used.
+func invalidParameter(param, format string, args ...any) error {
+	return model.NewError(model.KindInvalid, codeInvalidParameter, fmt.Sprintf(format, args...)).
+		WithDetails(map[string]any{"parameter": param})
+}
+
+func invalidBody(format string, args ...any) error {
+	return model.NewError(model.KindInvalid, codeInvalidBody, fmt.Sprintf(format, args...))
+}
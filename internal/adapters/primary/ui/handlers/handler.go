@@ -3,8 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"marketflow/internal/core/model"
 	"marketflow/internal/core/service"
 )
 
@@ -13,6 +17,18 @@ type Handler struct {
 	switchToTestMode func() error
 	switchToLiveMode func() error
 	healthCheck      func() []byte
+	retention        *service.Retention
+	auth             *service.Auth
+	limiter          *service.RateLimiter
+	consensus        *service.Consensus
+	spread           *service.SpreadMonitor
+	indicators       *service.Indicators
+	symbols          *service.Symbols
+	alerts           *service.Alerts
+	webhooks         *service.Webhooks
+	broadcaster      *service.Broadcaster
+	location         *time.Location
+	staleAfter       time.Duration
 }
 
 func WithTestModeSwitch(f func() error, h *Handler) {
@@ -27,15 +43,58 @@ func WithHealthCheck(f func() []byte, h *Handler) {
 	h.healthCheck = f
 }
 
+func WithRetention(r *service.Retention, h *Handler) {
+	h.retention = r
+}
+
+func WithRateLimiter(l *service.RateLimiter, h *Handler) {
+	h.limiter = l
+}
+
+func WithConsensus(c *service.Consensus, h *Handler) {
+	h.consensus = c
+}
+
+func WithSpreadMonitor(m *service.SpreadMonitor, h *Handler) {
+	h.spread = m
+}
+
+func WithIndicators(i *service.Indicators, h *Handler) {
+	h.indicators = i
+}
+
+func WithSymbols(s *service.Symbols, h *Handler) {
+	h.symbols = s
+}
+
+// WithLocation sets the time zone calendar periods such as "today" are
+// aligned to.
+func WithLocation(loc *time.Location, h *Handler) {
+	h.location = loc
+}
+
+// WithStaleAfter sets how old the latest tick may be before it is flagged as
+// stale.
+func WithStaleAfter(d time.Duration, h *Handler) {
+	h.staleAfter = d
+}
+
 type PriceResponse struct {
-	PairName     string    `json:"pair_name"`
-	Exchange     string    `json:"exchange"`
-	Price        *float64  `json:"price,omitempty"`
-	AveragePrice *float64  `json:"average_price,omitempty"`
-	MinPrice     *float64  `json:"min_price,omitempty"`
-	MaxPrice     *float64  `json:"max_price,omitempty"`
-	Period       string    `json:"period,omitempty"`
-	Timestamp    time.Time `json:"timestamp"`
+	PairName     string     `json:"pair_name"`
+	Exchange     string     `json:"exchange"`
+	Price        *float64   `json:"price,omitempty"`
+	AveragePrice *float64   `json:"average_price,omitempty"`
+	MinPrice     *float64   `json:"min_price,omitempty"`
+	MaxPrice     *float64   `json:"max_price,omitempty"`
+	Period       string     `json:"period,omitempty"`
+	From         *time.Time `json:"from,omitempty"`
+	To           *time.Time `json:"to,omitempty"`
+	// DataTimestamp, AgeMs and Stale describe the tick behind a latest
+	// price. Timestamp is the time of the response.
+	DataTimestamp *time.Time `json:"data_timestamp,omitempty"`
+	AgeMs         *int64     `json:"age_ms,omitempty"`
+	Stale         *bool      `json:"stale,omitempty"`
+	Timestamp     time.Time  `json:"timestamp"`
 }
 
 type SystemResponse struct {
@@ -44,18 +103,145 @@ type SystemResponse struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+type StatsResponse struct {
+	PairName  string     `json:"pair_name"`
+	Exchange  string     `json:"exchange"`
+	Period    string     `json:"period,omitempty"`
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+	Start     time.Time  `json:"start"`
+	End       time.Time  `json:"end"`
+	Min       *float64   `json:"min,omitempty"`
+	Max       *float64   `json:"max,omitempty"`
+	Average   *float64   `json:"avg,omitempty"`
+	First     *float64   `json:"first,omitempty"`
+	Last      *float64   `json:"last,omitempty"`
+	Count     *int64     `json:"count,omitempty"`
+	ChangePct *float64   `json:"change_pct,omitempty"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+type DistributionResponse struct {
+	PairName           string     `json:"pair_name"`
+	Exchange           string     `json:"exchange"`
+	Period             string     `json:"period,omitempty"`
+	From               *time.Time `json:"from,omitempty"`
+	To                 *time.Time `json:"to,omitempty"`
+	Source             string     `json:"source"`
+	Approximate        bool       `json:"approximate"`
+	Samples            int64      `json:"samples"`
+	TickCount          int64      `json:"tick_count"`
+	StdDev             float64    `json:"std_dev"`
+	RealizedVolatility float64    `json:"realized_volatility"`
+	P5                 float64    `json:"p5"`
+	P50                float64    `json:"p50"`
+	P95                float64    `json:"p95"`
+	Timestamp          time.Time  `json:"timestamp"`
+}
+
+type ConsensusResponse struct {
+	PairName  string                   `json:"pair_name"`
+	Method    string                   `json:"method"`
+	Price     float64                  `json:"price"`
+	Exchanges []ConsensusVenueResponse `json:"exchanges"`
+	Excluded  []ConsensusVenueResponse `json:"excluded,omitempty"`
+	Timestamp time.Time                `json:"timestamp"`
+}
+
+type ConsensusVenueResponse struct {
+	Exchange  string    `json:"exchange"`
+	Price     float64   `json:"price"`
+	Weight    *float64  `json:"weight,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type SpreadResponse struct {
+	PairName  string               `json:"pair_name"`
+	Exchanges []SpreadVenue        `json:"exchanges"`
+	Spreads   []SpreadPairResponse `json:"spreads"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
+type SpreadVenue struct {
+	Rank     int     `json:"rank"`
+	Exchange string  `json:"exchange"`
+	Price    float64 `json:"price"`
+}
+
+type SpreadPairResponse struct {
+	High      string  `json:"high_exchange"`
+	Low       string  `json:"low_exchange"`
+	Spread    float64 `json:"spread"`
+	SpreadBps float64 `json:"spread_bps"`
+}
+
+type SymbolsResponse struct {
+	Symbols   []SymbolResponse `json:"symbols"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+type SymbolResponse struct {
+	PairName  string           `json:"pair_name"`
+	Base      string           `json:"base"`
+	Quote     string           `json:"quote,omitempty"`
+	FirstSeen time.Time        `json:"first_seen"`
+	LastTick  time.Time        `json:"last_tick"`
+	Exchanges []SymbolExchange `json:"exchanges"`
+}
+
+type SymbolExchange struct {
+	Exchange  string    `json:"exchange"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastTick  time.Time `json:"last_tick"`
+}
+
+type IndicatorResponse struct {
+	PairName string                   `json:"pair_name"`
+	Exchange string                   `json:"exchange"`
+	Type     string                   `json:"type"`
+	Window   int                      `json:"window"`
+	Interval string                   `json:"interval"`
+	Latest   IndicatorPointResponse   `json:"latest"`
+	Points   []IndicatorPointResponse `json:"points"`
+}
+
+type IndicatorPointResponse struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+	Upper     *float64  `json:"upper,omitempty"`
+	Lower     *float64  `json:"lower,omitempty"`
+	Signal    *float64  `json:"signal,omitempty"`
+	Histogram *float64  `json:"histogram,omitempty"`
+}
+
+type RetentionResponse struct {
+	Table        string     `json:"table"`
+	Resolution   string     `json:"resolution,omitempty"`
+	MaxAge       string     `json:"max_age"`
+	Cutoff       *time.Time `json:"cutoff,omitempty"`
+	PendingRows  int64      `json:"pending_rows"`
+	OldestRow    *time.Time `json:"oldest_row,omitempty"`
+	LastRun      *time.Time `json:"last_run,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastDeleted  int64      `json:"last_deleted"`
+	TotalDeleted int64      `json:"total_deleted"`
+	LastError    string     `json:"last_error,omitempty"`
 }
 
-func setCORSHeaders(w http.ResponseWriter) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+type UsageResponse struct {
+	Day   string              `json:"day"`
+	Usage []UsageResponseItem `json:"usage"`
+}
+
+type UsageResponseItem struct {
+	Client   string `json:"client"`
+	Group    string `json:"group"`
+	Requests int64  `json:"requests"`
+	Limited  int64  `json:"limited"`
 }
 
 func writeJSONResponse(w http.ResponseWriter, data interface{}, statusCode int) {
-	setCORSHeaders(w)
 	w.Header().Set("Content-Type", "application/json")
 
 	w.WriteHeader(statusCode)
@@ -65,62 +251,106 @@ func writeJSONResponse(w http.ResponseWriter, data interface{}, statusCode int)
 	}
 }
 
-func writeErrorResponse(w http.ResponseWriter, message string, statusCode int) {
-	setCORSHeaders(w)
-	writeJSONResponse(w, ErrorResponse{Error: message}, statusCode)
+// parseRange reads the period, from and to query parameters.
+func (h *Handler) parseRange(r *http.Request) (model.TimeRange, error) {
+	query := r.URL.Query()
+	return service.ParseRange(query.Get("period"), query.Get("from"), query.Get("to"), time.Now().In(h.location))
+}
+
+// CacheTTL is how long the response to a request over a period may be
+// served again, or 0 when the range is invalid and the handler has to report
+// it.
+func (h *Handler) CacheTTL(r *http.Request) time.Duration {
+	rng, err := h.parseRange(r)
+	if err != nil {
+		return 0
+	}
+	return service.CacheTTL(rng, time.Now())
 }
 
-func NewHandler(service *service.Stats) *Handler {
+func NewHandler(stats *service.Stats) *Handler {
 	return &Handler{
-		service: service,
+		service:    stats,
+		location:   time.UTC,
+		staleAfter: service.DefaultStaleAfter,
 	}
 }
 
 func (h *Handler) LatestBySymbol(w http.ResponseWriter, r *http.Request) {
-	symbol := r.PathValue("symbol")
+	h.latest(w, r, "global", r.PathValue("symbol"))
+}
+
+func (h *Handler) LatestBySymbolAndExchange(w http.ResponseWriter, r *http.Request) {
+	h.latest(w, r, r.PathValue("exchange"), r.PathValue("symbol"))
+}
 
-	price, err := h.service.GetLatestPrice(r.Context(), "global", symbol)
+// latest writes the latest price of a pair. With a max_age parameter a tick
+// that is older fails with 503; a pair without any tick fails with 404.
+func (h *Handler) latest(w http.ResponseWriter, r *http.Request, exchange, symbol string) {
+	maxAge, err := parseMaxAge(r)
 	if err != nil {
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	response := PriceResponse{
-		PairName:  symbol,
-		Exchange:  "global",
-		Price:     &price,
-		Timestamp: time.Now(),
+	quote, err := h.service.GetLatestPrice(r.Context(), exchange, symbol)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	writeJSONResponse(w, response, http.StatusOK)
-}
-
-func (h *Handler) LatestBySymbolAndExchange(w http.ResponseWriter, r *http.Request) {
-	symbol := r.PathValue("symbol")
-	exchange := r.PathValue("exchange")
-
-	price, err := h.service.GetLatestPrice(r.Context(), exchange, symbol)
-	if err != nil {
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+	now := time.Now()
+	age := quote.Age(now)
+	if maxAge > 0 && age > maxAge {
+		writeError(w, r, service.ErrStalePrice.WithDetails(map[string]any{
+			"data_timestamp": quote.Timestamp,
+			"age_ms":         age.Milliseconds(),
+			"max_age_ms":     maxAge.Milliseconds(),
+		}))
 		return
 	}
 
+	ageMs := age.Milliseconds()
+	stale := h.staleAfter > 0 && age > h.staleAfter
 	response := PriceResponse{
-		PairName:  symbol,
-		Exchange:  exchange,
-		Price:     &price,
-		Timestamp: time.Now(),
+		PairName:      symbol,
+		Exchange:      exchange,
+		Price:         &quote.Price,
+		DataTimestamp: &quote.Timestamp,
+		AgeMs:         &ageMs,
+		Stale:         &stale,
+		Timestamp:     now,
 	}
 	writeJSONResponse(w, response, http.StatusOK)
 }
 
+// parseMaxAge reads the optional max_age parameter, a duration such as "5s".
+func parseMaxAge(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("max_age")
+	if value == "" {
+		return 0, nil
+	}
+
+	maxAge, err := time.ParseDuration(value)
+	if err != nil || maxAge <= 0 {
+		return 0, invalidParameter("max_age", "invalid max_age %q: must be a positive duration such as 5s", value)
+	}
+	return maxAge, nil
+}
+
 func (h *Handler) HighestBySymbol(w http.ResponseWriter, r *http.Request) {
 	symbol := r.PathValue("symbol")
 	period := r.URL.Query().Get("period")
 
-	price, err := h.service.GetHighestPrice(r.Context(), "global", symbol, period)
+	rng, err := h.parseRange(r)
 	if err != nil {
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
+		return
+	}
+
+	price, err := h.service.GetHighestPrice(r.Context(), "global", symbol, rng)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -129,6 +359,8 @@ func (h *Handler) HighestBySymbol(w http.ResponseWriter, r *http.Request) {
 		Exchange:  "global",
 		MaxPrice:  &price,
 		Period:    period,
+		From:      &rng.From,
+		To:        &rng.To,
 		Timestamp: time.Now(),
 	}
 	writeJSONResponse(w, response, http.StatusOK)
@@ -139,9 +371,15 @@ func (h *Handler) HighestBySymbolAndExchange(w http.ResponseWriter, r *http.Requ
 	exchange := r.PathValue("exchange")
 	period := r.URL.Query().Get("period")
 
-	price, err := h.service.GetHighestPrice(r.Context(), exchange, symbol, period)
+	rng, err := h.parseRange(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	price, err := h.service.GetHighestPrice(r.Context(), exchange, symbol, rng)
 	if err != nil {
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
@@ -150,6 +388,8 @@ func (h *Handler) HighestBySymbolAndExchange(w http.ResponseWriter, r *http.Requ
 		Exchange:  exchange,
 		MaxPrice:  &price,
 		Period:    period,
+		From:      &rng.From,
+		To:        &rng.To,
 		Timestamp: time.Now(),
 	}
 	writeJSONResponse(w, response, http.StatusOK)
@@ -159,9 +399,15 @@ func (h *Handler) LowestBySymbol(w http.ResponseWriter, r *http.Request) {
 	symbol := r.PathValue("symbol")
 	period := r.URL.Query().Get("period")
 
-	price, err := h.service.GetLowestPrice(r.Context(), "global", symbol, period)
+	rng, err := h.parseRange(r)
 	if err != nil {
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
+		return
+	}
+
+	price, err := h.service.GetLowestPrice(r.Context(), "global", symbol, rng)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -170,6 +416,8 @@ func (h *Handler) LowestBySymbol(w http.ResponseWriter, r *http.Request) {
 		Exchange:  "global",
 		MinPrice:  &price,
 		Period:    period,
+		From:      &rng.From,
+		To:        &rng.To,
 		Timestamp: time.Now(),
 	}
 	writeJSONResponse(w, response, http.StatusOK)
@@ -180,9 +428,15 @@ func (h *Handler) LowestBySymbolAndExchange(w http.ResponseWriter, r *http.Reque
 	exchange := r.PathValue("exchange")
 	period := r.URL.Query().Get("period")
 
-	price, err := h.service.GetLowestPrice(r.Context(), exchange, symbol, period)
+	rng, err := h.parseRange(r)
 	if err != nil {
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
+		return
+	}
+
+	price, err := h.service.GetLowestPrice(r.Context(), exchange, symbol, rng)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -191,6 +445,8 @@ func (h *Handler) LowestBySymbolAndExchange(w http.ResponseWriter, r *http.Reque
 		Exchange:  exchange,
 		MinPrice:  &price,
 		Period:    period,
+		From:      &rng.From,
+		To:        &rng.To,
 		Timestamp: time.Now(),
 	}
 	writeJSONResponse(w, response, http.StatusOK)
@@ -200,9 +456,15 @@ func (h *Handler) AverageBySymbol(w http.ResponseWriter, r *http.Request) {
 	symbol := r.PathValue("symbol")
 	period := r.URL.Query().Get("period")
 
-	price, err := h.service.GetAveragePrice(r.Context(), "global", symbol, period)
+	rng, err := h.parseRange(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	price, err := h.service.GetAveragePrice(r.Context(), "global", symbol, rng)
 	if err != nil {
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
@@ -211,6 +473,8 @@ func (h *Handler) AverageBySymbol(w http.ResponseWriter, r *http.Request) {
 		Exchange:     "global",
 		AveragePrice: &price,
 		Period:       period,
+		From:         &rng.From,
+		To:           &rng.To,
 		Timestamp:    time.Now(),
 	}
 
@@ -222,9 +486,15 @@ func (h *Handler) AverageBySymbolAndExchange(w http.ResponseWriter, r *http.Requ
 	exchange := r.PathValue("exchange")
 	period := r.URL.Query().Get("period")
 
-	price, err := h.service.GetAveragePrice(r.Context(), exchange, symbol, period)
+	rng, err := h.parseRange(r)
 	if err != nil {
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
+		return
+	}
+
+	price, err := h.service.GetAveragePrice(r.Context(), exchange, symbol, rng)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -233,11 +503,328 @@ func (h *Handler) AverageBySymbolAndExchange(w http.ResponseWriter, r *http.Requ
 		Exchange:     exchange,
 		AveragePrice: &price,
 		Period:       period,
+		From:         &rng.From,
+		To:           &rng.To,
 		Timestamp:    time.Now(),
 	}
 	writeJSONResponse(w, response, http.StatusOK)
 }
 
+var statsMetrics = []string{"min", "max", "avg", "first", "last", "count", "change_pct"}
+
+func (h *Handler) StatsBySymbol(w http.ResponseWriter, r *http.Request) {
+	h.stats(w, r, model.GlobalExchange)
+}
+
+func (h *Handler) StatsBySymbolAndExchange(w http.ResponseWriter, r *http.Request) {
+	h.stats(w, r, r.PathValue("exchange"))
+}
+
+func (h *Handler) stats(w http.ResponseWriter, r *http.Request, exchange string) {
+	symbol := r.PathValue("symbol")
+	period := r.URL.Query().Get("period")
+
+	metrics := make(map[string]bool, len(statsMetrics))
+	if raw := r.URL.Query().Get("metrics"); raw != "" {
+		for _, m := range strings.Split(raw, ",") {
+			m = strings.TrimSpace(m)
+			if !slices.Contains(statsMetrics, m) {
+				writeError(w, r, invalidParameter("metrics", "unknown metric: %s", m))
+				return
+			}
+			metrics[m] = true
+		}
+	} else {
+		for _, m := range statsMetrics {
+			metrics[m] = true
+		}
+	}
+
+	rng, err := h.parseRange(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	summary, err := h.service.GetSummary(r.Context(), exchange, symbol, rng)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := StatsResponse{
+		PairName:  symbol,
+		Exchange:  exchange,
+		Period:    period,
+		From:      &rng.From,
+		To:        &rng.To,
+		Start:     summary.Start,
+		End:       summary.End,
+		Timestamp: time.Now(),
+	}
+	if metrics["min"] {
+		response.Min = &summary.Min
+	}
+	if metrics["max"] {
+		response.Max = &summary.Max
+	}
+	if metrics["avg"] {
+		response.Average = &summary.Average
+	}
+	if metrics["first"] {
+		response.First = &summary.First
+	}
+	if metrics["last"] {
+		response.Last = &summary.Last
+	}
+	if metrics["count"] {
+		response.Count = &summary.TickCount
+	}
+	if metrics["change_pct"] && summary.First != 0 {
+		change := (summary.Last - summary.First) / summary.First * 100
+		response.ChangePct = &change
+	}
+
+	writeJSONResponse(w, response, http.StatusOK)
+}
+
+func (h *Handler) VolatilityBySymbol(w http.ResponseWriter, r *http.Request) {
+	h.volatility(w, r, model.GlobalExchange)
+}
+
+func (h *Handler) VolatilityBySymbolAndExchange(w http.ResponseWriter, r *http.Request) {
+	h.volatility(w, r, r.PathValue("exchange"))
+}
+
+func (h *Handler) volatility(w http.ResponseWriter, r *http.Request, exchange string) {
+	symbol := r.PathValue("symbol")
+	period := r.URL.Query().Get("period")
+
+	rng, err := h.parseRange(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dist, err := h.service.GetDistribution(r.Context(), exchange, symbol, rng)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := DistributionResponse{
+		PairName:           symbol,
+		Exchange:           exchange,
+		Period:             period,
+		From:               &rng.From,
+		To:                 &rng.To,
+		Source:             dist.Source,
+		Approximate:        dist.Approximate,
+		Samples:            dist.Samples,
+		TickCount:          dist.TickCount,
+		StdDev:             dist.StdDev,
+		RealizedVolatility: dist.Volatility,
+		P5:                 dist.P5,
+		P50:                dist.P50,
+		P95:                dist.P95,
+		Timestamp:          time.Now(),
+	}
+	writeJSONResponse(w, response, http.StatusOK)
+}
+
+func (h *Handler) ConsensusBySymbol(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+
+	consensus, err := h.consensus.Price(symbol)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSONResponse(w, consensusResponse(consensus), http.StatusOK)
+}
+
+func consensusResponse(consensus model.ConsensusPrice) ConsensusResponse {
+	response := ConsensusResponse{
+		PairName:  consensus.Symbol,
+		Method:    consensus.Method,
+		Price:     consensus.Price,
+		Exchanges: make([]ConsensusVenueResponse, 0, len(consensus.Contributors)),
+		Timestamp: consensus.Timestamp,
+	}
+	for _, c := range consensus.Contributors {
+		weight := c.Weight
+		response.Exchanges = append(response.Exchanges, ConsensusVenueResponse{
+			Exchange:  c.Exchange,
+			Price:     c.Price,
+			Weight:    &weight,
+			Timestamp: c.Timestamp,
+		})
+	}
+	for _, e := range consensus.Excluded {
+		response.Excluded = append(response.Excluded, ConsensusVenueResponse{
+			Exchange:  e.Exchange,
+			Price:     e.Price,
+			Reason:    e.Reason,
+			Timestamp: e.Timestamp,
+		})
+	}
+	return response
+}
+
+func (h *Handler) SpreadsBySymbol(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+
+	report, err := h.spread.Spreads(r.Context(), symbol)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := SpreadResponse{
+		PairName:  symbol,
+		Exchanges: make([]SpreadVenue, 0, len(report.Venues)),
+		Spreads:   make([]SpreadPairResponse, 0, len(report.Pairs)),
+		Timestamp: time.Now(),
+	}
+	for i, v := range report.Venues {
+		response.Exchanges = append(response.Exchanges, SpreadVenue{
+			Rank:     i + 1,
+			Exchange: v.Exchange,
+			Price:    v.Price,
+		})
+	}
+	for _, p := range report.Pairs {
+		response.Spreads = append(response.Spreads, SpreadPairResponse{
+			High:      p.High.Exchange,
+			Low:       p.Low.Exchange,
+			Spread:    p.Spread,
+			SpreadBps: p.SpreadBps,
+		})
+	}
+
+	writeJSONResponse(w, response, http.StatusOK)
+}
+
+func (h *Handler) Symbols(w http.ResponseWriter, r *http.Request) {
+	symbols, err := h.symbols.List(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSONResponse(w, symbolsResponse(symbols), http.StatusOK)
+}
+
+func (h *Handler) SymbolsByExchange(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("name")
+
+	symbols, err := h.symbols.ListByExchange(r.Context(), exchange)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSONResponse(w, symbolsResponse(symbols), http.StatusOK)
+}
+
+func symbolsResponse(symbols []model.Symbol) SymbolsResponse {
+	response := SymbolsResponse{
+		Symbols:   make([]SymbolResponse, 0, len(symbols)),
+		Timestamp: time.Now(),
+	}
+	for _, s := range symbols {
+		sym := SymbolResponse{
+			PairName:  s.Name,
+			Base:      s.Base,
+			Quote:     s.Quote,
+			FirstSeen: s.FirstSeen,
+			LastTick:  s.LastTick,
+			Exchanges: make([]SymbolExchange, 0, len(s.Listings)),
+		}
+		for _, l := range s.Listings {
+			sym.Exchanges = append(sym.Exchanges, SymbolExchange{
+				Exchange:  l.Exchange,
+				FirstSeen: l.FirstSeen,
+				LastTick:  l.LastTick,
+			})
+		}
+		response.Symbols = append(response.Symbols, sym)
+	}
+	return response
+}
+
+// defaultIndicatorPoints is how many of the most recent indicator values are
+// returned when no limit is given.
+const defaultIndicatorPoints = 50
+
+func (h *Handler) Indicator(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	exchange := r.PathValue("exchange")
+	query := r.URL.Query()
+
+	kind := query.Get("type")
+	interval := query.Get("interval")
+
+	var window int
+	if v := query.Get("window"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, r, invalidParameter("window", "invalid window: %s", v))
+			return
+		}
+		window = n
+	}
+
+	limit := defaultIndicatorPoints
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, r, invalidParameter("limit", "invalid limit: %s", v))
+			return
+		}
+		limit = n
+	}
+
+	indicator, err := h.indicators.Compute(r.Context(), exchange, symbol, kind, window, interval)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	points := indicator.Points
+	if len(points) > limit {
+		points = points[len(points)-limit:]
+	}
+
+	response := IndicatorResponse{
+		PairName: symbol,
+		Exchange: exchange,
+		Type:     indicator.Type,
+		Window:   indicator.Window,
+		Interval: indicator.Interval,
+		Points:   make([]IndicatorPointResponse, 0, len(points)),
+	}
+	for _, p := range points {
+		point := IndicatorPointResponse{
+			Timestamp: p.Timestamp,
+			Value:     p.Value,
+		}
+		switch indicator.Type {
+		case service.IndicatorBollinger:
+			point.Upper = &p.Upper
+			point.Lower = &p.Lower
+		case service.IndicatorMACD:
+			point.Signal = &p.Signal
+			point.Histogram = &p.Histogram
+		}
+		response.Points = append(response.Points, point)
+	}
+	response.Latest = response.Points[len(response.Points)-1]
+
+	writeJSONResponse(w, response, http.StatusOK)
+}
+
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	response := h.healthCheck()
 	w.Header().Set("Content-Type", "application/json")
@@ -248,7 +835,7 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) SwitchToTestMode(w http.ResponseWriter, r *http.Request) {
 	err := h.switchToTestMode()
 	if err != nil {
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
@@ -263,7 +850,7 @@ func (h *Handler) SwitchToTestMode(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) SwitchToLiveMode(w http.ResponseWriter, r *http.Request) {
 	err := h.switchToLiveMode()
 	if err != nil {
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 	response := SystemResponse{
@@ -273,3 +860,67 @@ func (h *Handler) SwitchToLiveMode(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJSONResponse(w, response, http.StatusOK)
 }
+
+func (h *Handler) RetentionStatus(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.retention.Report(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := make([]RetentionResponse, 0, len(reports))
+	for _, report := range reports {
+		item := RetentionResponse{
+			Table:        report.Policy.Table,
+			Resolution:   report.Policy.Resolution,
+			MaxAge:       "forever",
+			PendingRows:  report.Preview.Rows,
+			OldestRow:    report.Preview.Oldest,
+			LastDeleted:  report.Stats.LastDeleted,
+			TotalDeleted: report.Stats.TotalDeleted,
+			LastError:    report.Stats.LastError,
+		}
+		if report.Policy.MaxAge > 0 {
+			item.MaxAge = report.Policy.MaxAge.String()
+			cutoff := report.Preview.Cutoff
+			item.Cutoff = &cutoff
+		}
+		if !report.Stats.LastRun.IsZero() {
+			lastRun := report.Stats.LastRun
+			item.LastRun = &lastRun
+			item.LastDuration = report.Stats.LastDuration.String()
+		}
+		response = append(response, item)
+	}
+
+	writeJSONResponse(w, response, http.StatusOK)
+}
+
+// Usage reports how many requests every client sent to every route group on
+// a day (UTC), today by default.
+func (h *Handler) Usage(w http.ResponseWriter, r *http.Request) {
+	day := r.URL.Query().Get("day")
+	if day == "" {
+		day = time.Now().UTC().Format(time.DateOnly)
+	} else if _, err := time.Parse(time.DateOnly, day); err != nil {
+		writeError(w, r, invalidParameter("day", "invalid day %q: must look like 2006-01-02", day))
+		return
+	}
+
+	usage, err := h.limiter.Usage(r.Context(), day)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := UsageResponse{Day: day, Usage: make([]UsageResponseItem, 0, len(usage))}
+	for _, u := range usage {
+		response.Usage = append(response.Usage, UsageResponseItem{
+			Client:   u.Client,
+			Group:    u.Group,
+			Requests: u.Requests,
+			Limited:  u.Limited,
+		})
+	}
+	writeJSONResponse(w, response, http.StatusOK)
+}
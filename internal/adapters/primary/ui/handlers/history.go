@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+// Formats a history can be exported in, chosen by the Accept header.
+const (
+	formatJSON   = "application/json"
+	formatNDJSON = "application/x-ndjson"
+	formatCSV    = "text/csv"
+)
+
+type HistoryResponse struct {
+	PairName   string                 `json:"pair_name"`
+	Exchange   string                 `json:"exchange"`
+	Resolution string                 `json:"resolution"`
+	From       time.Time              `json:"from"`
+	To         time.Time              `json:"to"`
+	Points     []HistoryPointResponse `json:"points"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// HistoryPointResponse is a market row or rollup bucket.
+type HistoryPointResponse struct {
+	Timestamp time.Time `json:"timestamp"`
+	Open      float64   `json:"open"`
+	High      float64   `json:"high"`
+	Low       float64   `json:"low"`
+	Close     float64   `json:"close"`
+	Average   float64   `json:"average"`
+	TickCount int64     `json:"tick_count"`
+}
+
+// History exports the rows of a pair page by page. The page comes as a JSON
+// document, as one JSON object per line or as CSV, whichever the client
+// prefers in Accept. The next page is linked in a Link header, and in the
+// JSON document as next_cursor.
+func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	query := r.URL.Query()
+
+	rng, err := h.parseRange(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, r, invalidParameter("limit", "invalid limit: %s", v))
+			return
+		}
+		limit = n
+	}
+
+	page, err := h.service.GetHistory(r.Context(), exchange, symbol, query.Get("resolution"), rng, query.Get("cursor"), limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// The format follows Accept, so shared caches must not hand one client's
+	// format to another.
+	w.Header().Add("Vary", "Accept")
+	if page.Next != "" {
+		w.Header().Set("Link", "<"+nextPageURL(r, rng, page.Next)+`>; rel="next"`)
+	}
+
+	switch format := negotiateFormat(r.Header.Get("Accept")); format {
+	case formatNDJSON:
+		w.Header().Set("Content-Type", formatNDJSON)
+		h.streamHistoryNDJSON(w, page.Points)
+	case formatCSV:
+		w.Header().Set("Content-Type", formatCSV+"; charset=utf-8")
+		h.streamHistoryCSV(w, page.Points)
+	default:
+		response := HistoryResponse{
+			PairName:   symbol,
+			Exchange:   exchange,
+			Resolution: page.Resolution,
+			From:       rng.From,
+			To:         rng.To,
+			Points:     make([]HistoryPointResponse, 0, len(page.Points)),
+			NextCursor: page.Next,
+		}
+		for _, p := range page.Points {
+			response.Points = append(response.Points, h.historyPoint(p))
+		}
+		writeJSONResponse(w, response, http.StatusOK)
+	}
+}
+
+func (h *Handler) historyPoint(p model.HistoryPoint) HistoryPointResponse {
+	return HistoryPointResponse{
+		Timestamp: p.Time.In(h.location),
+		Open:      p.Open,
+		High:      p.High,
+		Low:       p.Low,
+		Close:     p.Close,
+		Average:   p.Average,
+		TickCount: p.TickCount,
+	}
+}
+
+// streamHistoryNDJSON flushes every row as it is written, so that an export
+// is neither held back nor buffered by the ETag middleware.
+func (h *Handler) streamHistoryNDJSON(w http.ResponseWriter, points []model.HistoryPoint) {
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	for _, p := range points {
+		if err := enc.Encode(h.historyPoint(p)); err != nil {
+			return
+		}
+		rc.Flush()
+	}
+}
+
+// streamHistoryCSV writes a header line and flushes every row like
+// streamHistoryNDJSON.
+func (h *Handler) streamHistoryCSV(w http.ResponseWriter, points []model.HistoryPoint) {
+	rc := http.NewResponseController(w)
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"timestamp", "open", "high", "low", "close", "average", "tick_count"})
+
+	price := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	for _, p := range points {
+		ts := p.Time.In(h.location).Format(time.RFC3339Nano)
+		cw.Write([]string{ts, price(p.Open), price(p.High), price(p.Low), price(p.Close), price(p.Average), strconv.FormatInt(p.TickCount, 10)})
+		if cw.Flush(); cw.Error() != nil {
+			return
+		}
+		rc.Flush()
+	}
+	cw.Flush()
+}
+
+// nextPageURL links the page after the current one. The range is pinned to
+// absolute times so that a relative period does not move between pages, and
+// the API key is left out so that it does not end up in logs.
+func nextPageURL(r *http.Request, rng model.TimeRange, cursor string) string {
+	query := r.URL.Query()
+	query.Del("period")
+	query.Del("api_key")
+	query.Set("from", rng.From.Format(time.RFC3339Nano))
+	query.Set("to", rng.To.Format(time.RFC3339Nano))
+	query.Set("cursor", cursor)
+	return (&url.URL{Path: r.URL.Path, RawQuery: query.Encode()}).String()
+}
+
+// negotiateFormat picks the export format the client weighs highest in an
+// Accept header, with a named type winning a tie over a wildcard. JSON is the
+// default, including for clients that accept none of the formats.
+func negotiateFormat(header string) string {
+	best, bestQ, bestWildcard := formatJSON, 0.0, true
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		var format string
+		switch mediaType {
+		case formatJSON, "application/*", "*/*":
+			format = formatJSON
+		case formatNDJSON, "application/ndjson", "application/jsonl":
+			format = formatNDJSON
+		case formatCSV, "text/*":
+			format = formatCSV
+		default:
+			continue
+		}
+		wildcard := strings.HasSuffix(mediaType, "/*")
+		if q > bestQ || (q == bestQ && bestWildcard && !wildcard) {
+			best, bestQ, bestWildcard = format, q, wildcard
+		}
+	}
+	return best
+}
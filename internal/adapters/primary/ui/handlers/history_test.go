@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := map[string]string{
+		"":                                   formatJSON,
+		"*/*":                                formatJSON,
+		"text/html":                          formatJSON,
+		"text/csv":                           formatCSV,
+		"*/*, text/csv":                      formatCSV,
+		"application/x-ndjson":               formatNDJSON,
+		"application/json;q=0.5, text/csv":   formatCSV,
+		"text/csv;q=0.2, application/json":   formatJSON,
+		"application/ndjson, */*;q=0.1":      formatNDJSON,
+		"text/*;q=0.9, application/json;q=0": formatCSV,
+	}
+	for header, want := range tests {
+		if got := negotiateFormat(header); got != want {
+			t.Errorf("%q: expected %q, got %q", header, want, got)
+		}
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	r := httptest.NewRequest("GET", "/prices/history/binance/BTCUSDT?period=1h&resolution=1h&api_key=secret&cursor=old", nil)
+	from := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	next, err := url.Parse(nextPageURL(r, model.TimeRange{From: from, To: from.Add(time.Hour)}, "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := next.Query()
+	if next.Path != r.URL.Path || query.Has("period") || query.Has("api_key") {
+		t.Errorf("unexpected next page %s", next)
+	}
+	if query.Get("from") != "2024-01-01T12:00:00Z" || query.Get("to") != "2024-01-01T13:00:00Z" || query.Get("cursor") != "new" || query.Get("resolution") != "1h" {
+		t.Errorf("unexpected query %v", query)
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+// maxBatchBody bounds the body of a batch lookup.
+const maxBatchBody = 64 << 10
+
+// LatestMatrixResponse holds the latest price of every requested pair keyed
+// by symbol and then exchange. A pair without a tick is null.
+type LatestMatrixResponse struct {
+	Prices    map[string]map[string]*LatestPrice `json:"prices"`
+	Timestamp time.Time                          `json:"timestamp"`
+}
+
+// LatestPrice is one cell of the matrix. Timestamp is the time of the tick.
+type LatestPrice struct {
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"`
+	AgeMs     int64     `json:"age_ms"`
+	Stale     bool      `json:"stale"`
+}
+
+type LatestBatchRequest struct {
+	Pairs []LatestBatchPair `json:"pairs"`
+}
+
+type LatestBatchPair struct {
+	Symbol   string `json:"symbol"`
+	Exchange string `json:"exchange,omitempty"`
+}
+
+// LatestMatrix returns the latest prices of the symbols and exchanges given
+// as comma separated lists. Either list defaults to everything the symbol
+// registry knows; the global exchange is only included when named.
+func (h *Handler) LatestMatrix(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	symbols := splitList(query.Get("symbols"))
+	exchanges := splitList(query.Get("exchanges"))
+
+	if len(symbols) == 0 || len(exchanges) == 0 {
+		known, err := h.symbols.List(r.Context())
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		allSymbols, allExchanges := symbolsAndExchanges(known)
+		if len(symbols) == 0 {
+			symbols = allSymbols
+		}
+		if len(exchanges) == 0 {
+			exchanges = allExchanges
+		}
+	}
+
+	pairs := make([]model.Pair, 0, len(symbols)*len(exchanges))
+	for _, symbol := range symbols {
+		for _, exchange := range exchanges {
+			pairs = append(pairs, model.Pair{Exchange: exchange, Symbol: symbol})
+		}
+	}
+
+	h.writeLatestMatrix(w, r, pairs)
+}
+
+// LatestBatch returns the latest prices of the pairs in the request body. A
+// pair without an exchange is looked up on the global exchange.
+func (h *Handler) LatestBatch(w http.ResponseWriter, r *http.Request) {
+	var req LatestBatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBody)).Decode(&req); err != nil {
+		writeError(w, r, invalidBody("invalid request body: %v", err))
+		return
+	}
+
+	if len(req.Pairs) == 0 {
+		writeError(w, r, invalidBody("pairs must not be empty"))
+		return
+	}
+
+	pairs := make([]model.Pair, 0, len(req.Pairs))
+	for i, p := range req.Pairs {
+		if p.Symbol == "" {
+			writeError(w, r, invalidBody("pairs[%d]: symbol is required", i))
+			return
+		}
+		if p.Exchange == "" {
+			p.Exchange = model.GlobalExchange
+		}
+		pairs = append(pairs, model.Pair{Exchange: p.Exchange, Symbol: p.Symbol})
+	}
+
+	h.writeLatestMatrix(w, r, pairs)
+}
+
+// writeLatestMatrix writes the latest prices of pairs. A cell older than the
+// max_age parameter, or the configured threshold without one, is flagged as
+// stale.
+func (h *Handler) writeLatestMatrix(w http.ResponseWriter, r *http.Request, pairs []model.Pair) {
+	maxAge, err := parseMaxAge(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if maxAge == 0 {
+		maxAge = h.staleAfter
+	}
+
+	quotes, err := h.service.GetLatestPrices(r.Context(), pairs)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	response := LatestMatrixResponse{
+		Prices:    make(map[string]map[string]*LatestPrice),
+		Timestamp: now,
+	}
+	for _, p := range pairs {
+		if response.Prices[p.Symbol] == nil {
+			response.Prices[p.Symbol] = make(map[string]*LatestPrice)
+		}
+		response.Prices[p.Symbol][p.Exchange] = nil
+	}
+	for _, q := range quotes {
+		age := q.Age(now)
+		response.Prices[q.Symbol][q.Exchange] = &LatestPrice{
+			Price:     q.Price,
+			Timestamp: q.Timestamp,
+			AgeMs:     age.Milliseconds(),
+			Stale:     maxAge > 0 && age > maxAge,
+		}
+	}
+
+	writeJSONResponse(w, response, http.StatusOK)
+}
+
+// symbolsAndExchanges lists the symbol names and the exchanges quoting any
+// of them, both in order of first appearance.
+func symbolsAndExchanges(symbols []model.Symbol) ([]string, []string) {
+	names := make([]string, 0, len(symbols))
+	var exchanges []string
+	seen := make(map[string]bool)
+	for _, sym := range symbols {
+		names = append(names, sym.Name)
+		for _, l := range sym.Listings {
+			if !seen[l.Exchange] {
+				seen[l.Exchange] = true
+				exchanges = append(exchanges, l.Exchange)
+			}
+		}
+	}
+	return names, exchanges
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+)
+
+const (
+	// streamHeartbeat keeps idle connections from being closed by proxies.
+	streamHeartbeat = 15 * time.Second
+	// maxStreamRate is the highest conflation rate a client may ask for.
+	maxStreamRate = 50
+	// streamWriteTimeout bounds a single write to a client that stopped
+	// reading.
+	streamWriteTimeout = 10 * time.Second
+)
+
+type TickResponse struct {
+	PairName  string    `json:"pair_name"`
+	Exchange  string    `json:"exchange"`
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func WithBroadcaster(b *service.Broadcaster, h *Handler) {
+	h.broadcaster = b
+}
+
+// splitList parses a comma separated query parameter.
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// StreamPrices sends ticks as Server-Sent Events. Without a rate every tick
+// is sent; with one, each pair on each exchange is sent at most rate times per
+// second with its latest price.
+func (h *Handler) StreamPrices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var rate int
+	if v := query.Get("rate"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxStreamRate {
+			writeError(w, r, invalidParameter("rate", "rate must be between 0 and %d", maxStreamRate))
+			return
+		}
+		rate = n
+	}
+
+	filter := service.Filter{
+		Topics:    []string{model.TopicTicks},
+		Symbols:   splitList(query.Get("symbols")),
+		Exchanges: splitList(query.Get("exchanges")),
+	}
+
+	sub := h.broadcaster.Subscribe(filter, service.DefaultSubscriberBuffer)
+	if sub == nil {
+		writeError(w, r, errShuttingDown)
+		return
+	}
+	defer h.broadcaster.Unsubscribe(sub)
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	s := &sseWriter{w: w, rc: rc}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	// With conflation the latest tick of every pair is held until the next
+	// flush. A nil channel never fires, so without it ticks go out directly.
+	var flush <-chan time.Time
+	conflator := service.NewConflator()
+	if rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(rate))
+		defer ticker.Stop()
+		flush = ticker.C
+	}
+
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				if sub.Evicted() {
+					s.send("error", map[string]string{"error": "client too slow, reconnect"})
+				}
+				return
+			}
+			quote, ok := e.Data.(model.Quote)
+			if !ok {
+				continue
+			}
+
+			if rate == 0 {
+				if s.send("tick", tickResponse(quote)) != nil {
+					return
+				}
+				continue
+			}
+
+			conflator.Add(quote)
+
+		case <-flush:
+			for _, quote := range conflator.Flush() {
+				if s.send("tick", tickResponse(quote)) != nil {
+					return
+				}
+			}
+
+		case <-heartbeat.C:
+			if s.comment("ping") != nil {
+				return
+			}
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func tickResponse(q model.Quote) TickResponse {
+	return TickResponse{
+		PairName:  q.Symbol,
+		Exchange:  q.Exchange,
+		Price:     q.Price,
+		Timestamp: q.Timestamp,
+	}
+}
+
+// sseWriter writes Server-Sent Events and flushes each one.
+type sseWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+	id int64
+}
+
+func (s *sseWriter) send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.id++
+	s.deadline()
+	if _, err := fmt.Fprintf(s.w, "id: %d\nevent: %s\ndata: %s\n\n", s.id, event, payload); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func (s *sseWriter) comment(text string) error {
+	s.deadline()
+	if _, err := fmt.Fprintf(s.w, ": %s\n\n", text); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// deadline is best effort: not every ResponseWriter supports it.
+func (s *sseWriter) deadline() {
+	_ = s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+}
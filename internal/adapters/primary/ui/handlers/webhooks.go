@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+)
+
+// maxWebhookBody bounds the body of a webhook request.
+const maxWebhookBody = 8 << 10
+
+// WebhookRequest subscribes a URL to events. Topics default to alerts; empty
+// symbols and exchanges match every one.
+type WebhookRequest struct {
+	URL       string   `json:"url"`
+	Topics    []string `json:"topics"`
+	Symbols   []string `json:"symbols"`
+	Exchanges []string `json:"exchanges"`
+}
+
+// WebhookResponse only carries the secret in the response to its creation.
+type WebhookResponse struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Topics    []string  `json:"topics"`
+	Symbols   []string  `json:"symbols"`
+	Exchanges []string  `json:"exchanges"`
+	Secret    string    `json:"secret,omitempty"`
+	Circuit   string    `json:"circuit"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID          int64           `json:"id"`
+	WebhookID   int64           `json:"webhook_id"`
+	Type        string          `json:"type"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	StatusCode  int             `json:"status_code,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	NextAttempt *time.Time      `json:"next_attempt,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+func WithWebhooks(w *service.Webhooks, h *Handler) {
+	h.webhooks = w
+}
+
+func webhookResponse(hook model.Webhook) WebhookResponse {
+	response := WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Topics:    hook.Topics,
+		Symbols:   hook.Symbols,
+		Exchanges: hook.Exchanges,
+		Secret:    hook.Secret,
+		Circuit:   hook.Circuit,
+		CreatedAt: hook.CreatedAt,
+	}
+	if response.Symbols == nil {
+		response.Symbols = []string{}
+	}
+	if response.Exchanges == nil {
+		response.Exchanges = []string{}
+	}
+	return response
+}
+
+func webhookDeliveryResponse(d model.WebhookDelivery) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:         d.ID,
+		WebhookID:  d.WebhookID,
+		Type:       d.Topic,
+		Status:     d.Status,
+		Attempts:   d.Attempts,
+		StatusCode: d.StatusCode,
+		Error:      d.Error,
+		Payload:    d.Payload,
+		CreatedAt:  d.CreatedAt,
+		UpdatedAt:  d.UpdatedAt,
+	}
+	if d.Status == model.DeliveryPending && !d.NextAttempt.IsZero() {
+		response.NextAttempt = &d.NextAttempt
+	}
+	return response
+}
+
+func webhookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, r, invalidParameter("id", "invalid id %q", r.PathValue("id")))
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.webhooks.Webhooks(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := make([]WebhookResponse, 0, len(webhooks))
+	for _, hook := range webhooks {
+		response = append(response, webhookResponse(hook))
+	}
+	writeJSONResponse(w, response, http.StatusOK)
+}
+
+// CreateWebhook subscribes a URL and returns the secret its payloads are
+// signed with. The secret cannot be read again later.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req WebhookRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBody)).Decode(&req); err != nil {
+		writeError(w, r, invalidBody("invalid request body: %v", err))
+		return
+	}
+
+	hook, err := h.webhooks.Create(r.Context(), model.Webhook{
+		URL:       req.URL,
+		Topics:    req.Topics,
+		Symbols:   req.Symbols,
+		Exchanges: req.Exchanges,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSONResponse(w, webhookResponse(hook), http.StatusCreated)
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhooks.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestWebhook sends a test event to a webhook and reports how the endpoint
+// answered. An endpoint that fails still makes this a 200; the outcome is in
+// the delivery.
+func (h *Handler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := h.webhooks.Test(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSONResponse(w, webhookDeliveryResponse(d), http.StatusOK)
+}
+
+// WebhookDeliveries lists the latest deliveries of a webhook, newest first.
+func (h *Handler) WebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	var limit int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, r, invalidParameter("limit", "invalid limit: %s", v))
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.webhooks.Deliveries(r.Context(), id, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		response = append(response, webhookDeliveryResponse(d))
+	}
+	writeJSONResponse(w, response, http.StatusOK)
+}
@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsPingInterval is how often the server pings an idle client.
+	wsPingInterval = 20 * time.Second
+	// wsReadTimeout is how long a client may stay silent, pongs included.
+	wsReadTimeout  = 3 * wsPingInterval
+	wsWriteTimeout = 10 * time.Second
+	// wsMaxMessageSize is the largest message a client may send.
+	wsMaxMessageSize = 64 << 10
+)
+
+// wsUpgrader accepts every origin: clients authenticate with API keys, never
+// with cookies, so a page on another origin gains nothing it could not do
+// with the key itself.
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+const (
+	wsOpSubscribe   = "subscribe"
+	wsOpUnsubscribe = "unsubscribe"
+	wsOpPing        = "ping"
+)
+
+// wsChannels maps the channels a client can subscribe to onto broadcaster
+// topics.
+var wsChannels = map[string]string{
+	"ticks":     model.TopicTicks,
+	"candles":   model.TopicCandles,
+	"consensus": model.TopicConsensus,
+	"alerts":    model.TopicAlerts,
+}
+
+// WSRequest is a message from the client.
+type WSRequest struct {
+	Op        string   `json:"op"`
+	Channel   string   `json:"channel,omitempty"`
+	Symbols   []string `json:"symbols,omitempty"`
+	Exchanges []string `json:"exchanges,omitempty"`
+}
+
+// WSMessage is a message to the client. Type is one of subscribed,
+// unsubscribed, snapshot, update, pong or error.
+type WSMessage struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel,omitempty"`
+	Data    any    `json:"data,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type CandleResponse struct {
+	PairName  string    `json:"pair_name"`
+	Exchange  string    `json:"exchange"`
+	Interval  string    `json:"interval"`
+	Start     time.Time `json:"start"`
+	Open      float64   `json:"open"`
+	High      float64   `json:"high"`
+	Low       float64   `json:"low"`
+	Close     float64   `json:"close"`
+	Average   float64   `json:"average"`
+	TickCount int64     `json:"tick_count"`
+}
+
+type AlertResponse struct {
+	Type         string    `json:"type"`
+	PairName     string    `json:"pair_name"`
+	HighExchange string    `json:"high_exchange,omitempty"`
+	LowExchange  string    `json:"low_exchange,omitempty"`
+	SpreadBps    float64   `json:"spread_bps,omitempty"`
+	Since        time.Time `json:"since"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// wsSubscriptions is the per-connection subscription state. It is read by
+// the broadcaster's publishers and written by the connection.
+type wsSubscriptions struct {
+	mu       sync.RWMutex
+	channels map[string]service.Filter
+}
+
+func (s *wsSubscriptions) match(e model.Event) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for channel, filter := range s.channels {
+		if wsChannels[channel] == e.Topic && filter.Match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *wsSubscriptions) set(channel string, filter service.Filter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channels[channel] = filter
+}
+
+func (s *wsSubscriptions) remove(channel string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.channels[channel]
+	delete(s.channels, channel)
+	return ok
+}
+
+// WebSocket serves the subscription API. A client sends
+//
+//	{"op": "subscribe", "channel": "ticks", "symbols": ["BTCUSDT"], "exchanges": ["exchange1"]}
+//	{"op": "unsubscribe", "channel": "ticks"}
+//
+// A subscription is answered with the latest value of every matching pair as
+// a snapshot, followed by an update for every change. Updates may repeat a
+// value that was already part of the snapshot.
+func (h *Handler) WebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(hijacker(w), r, nil)
+	if err != nil {
+		return
+	}
+	conn.SetReadLimit(wsMaxMessageSize)
+
+	subs := &wsSubscriptions{channels: make(map[string]service.Filter)}
+	sub := h.broadcaster.SubscribeFunc(subs.match, service.DefaultSubscriberBuffer)
+	if sub == nil {
+		closeWebSocket(conn, websocket.CloseGoingAway, "server is shutting down")
+		return
+	}
+	defer h.broadcaster.Unsubscribe(sub)
+
+	c := &wsConn{conn: conn, subs: subs, broadcaster: h.broadcaster}
+	done := make(chan struct{})
+	defer close(done)
+	requests := c.readRequests(done)
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case req, ok := <-requests:
+			if !ok {
+				closeWebSocket(conn, websocket.CloseNormalClosure, "")
+				return
+			}
+			if err := c.handle(req); err != nil {
+				closeWebSocket(conn, websocket.CloseGoingAway, "")
+				return
+			}
+
+		case e, ok := <-sub.Events():
+			if !ok {
+				if sub.Evicted() {
+					closeWebSocket(conn, websocket.ClosePolicyViolation, "client too slow")
+				} else {
+					closeWebSocket(conn, websocket.CloseGoingAway, "server is shutting down")
+				}
+				return
+			}
+			if err := c.update(e); err != nil {
+				closeWebSocket(conn, websocket.CloseGoingAway, "")
+				return
+			}
+
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				closeWebSocket(conn, websocket.CloseGoingAway, "")
+				return
+			}
+		}
+	}
+}
+
+// wsConn writes to one client. All writes happen on the handler goroutine,
+// so a snapshot is always sent before the updates that follow it.
+type wsConn struct {
+	conn        *websocket.Conn
+	subs        *wsSubscriptions
+	broadcaster *service.Broadcaster
+}
+
+// wsRequest is a client message, or the reason it could not be parsed.
+type wsRequest struct {
+	WSRequest
+	err error
+}
+
+// readRequests reads client messages until the connection fails.
+func (c *wsConn) readRequests(done <-chan struct{}) <-chan wsRequest {
+	requests := make(chan wsRequest)
+
+	extend := func() { c.conn.SetReadDeadline(time.Now().Add(wsReadTimeout)) }
+	extend()
+	c.conn.SetPongHandler(func(string) error {
+		extend()
+		return nil
+	})
+
+	go func() {
+		defer close(requests)
+		for {
+			_, msg, err := c.conn.ReadMessage()
+			if err != nil {
+				var ce *websocket.CloseError
+				if !errors.As(err, &ce) {
+					slog.Debug("websocket read error", "error", err)
+				}
+				return
+			}
+			extend()
+
+			var req wsRequest
+			req.err = json.Unmarshal(msg, &req.WSRequest)
+
+			select {
+			case requests <- req:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return requests
+}
+
+func (c *wsConn) handle(req wsRequest) error {
+	if req.err != nil {
+		return c.send(WSMessage{Type: "error", Error: "invalid message: " + req.err.Error()})
+	}
+
+	switch req.Op {
+	case wsOpPing:
+		return c.send(WSMessage{Type: "pong"})
+
+	case wsOpSubscribe:
+		topic, ok := wsChannels[req.Channel]
+		if !ok {
+			return c.send(WSMessage{Type: "error", Channel: req.Channel, Error: fmt.Sprintf("unknown channel %q", req.Channel)})
+		}
+
+		filter := service.Filter{
+			Topics:    []string{topic},
+			Symbols:   req.Symbols,
+			Exchanges: req.Exchanges,
+		}
+		c.subs.set(req.Channel, filter)
+
+		if err := c.send(WSMessage{Type: "subscribed", Channel: req.Channel}); err != nil {
+			return err
+		}
+
+		snapshot := c.broadcaster.Snapshot(filter.Match)
+		data := make([]any, 0, len(snapshot))
+		for _, e := range snapshot {
+			if d, ok := EventData(e); ok {
+				data = append(data, d)
+			}
+		}
+		return c.send(WSMessage{Type: "snapshot", Channel: req.Channel, Data: data})
+
+	case wsOpUnsubscribe:
+		if !c.subs.remove(req.Channel) {
+			return c.send(WSMessage{Type: "error", Channel: req.Channel, Error: "not subscribed"})
+		}
+		return c.send(WSMessage{Type: "unsubscribed", Channel: req.Channel})
+
+	default:
+		return c.send(WSMessage{Type: "error", Error: fmt.Sprintf("unknown op %q", req.Op)})
+	}
+}
+
+// update sends an event to the client unless it was queued for a channel the
+// client has since left.
+func (c *wsConn) update(e model.Event) error {
+	if !c.subs.match(e) {
+		return nil
+	}
+
+	data, ok := EventData(e)
+	if !ok {
+		return nil
+	}
+
+	for channel, topic := range wsChannels {
+		if topic == e.Topic {
+			return c.send(WSMessage{Type: "update", Channel: channel, Data: data})
+		}
+	}
+	return nil
+}
+
+func (c *wsConn) send(m WSMessage) error {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return c.conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+// closeWebSocket sends a close frame, best effort, and closes the
+// connection.
+func closeWebSocket(conn *websocket.Conn, code int, reason string) {
+	msg := websocket.FormatCloseMessage(code, reason)
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	conn.Close()
+}
+
+// hijacker unwraps the response writers of the middleware down to the one
+// that can hand over the connection.
+func hijacker(w http.ResponseWriter) http.ResponseWriter {
+	for {
+		if _, ok := w.(http.Hijacker); ok {
+			return w
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return w
+		}
+		w = u.Unwrap()
+	}
+}
+
+// EventData converts an event payload into the response type it is streamed
+// and posted to webhooks as.
+func EventData(e model.Event) (any, bool) {
+	switch d := e.Data.(type) {
+	case model.Quote:
+		return tickResponse(d), true
+	case model.Candle:
+		return CandleResponse{
+			PairName:  d.Symbol,
+			Exchange:  d.Exchange,
+			Interval:  d.Resolution,
+			Start:     d.Start,
+			Open:      d.Open,
+			High:      d.High,
+			Low:       d.Low,
+			Close:     d.Close,
+			Average:   d.Average,
+			TickCount: d.TickCount,
+		}, true
+	case model.ConsensusPrice:
+		return consensusResponse(d), true
+	case model.DivergenceEvent:
+		return AlertResponse{
+			Type:         d.Type,
+			PairName:     d.High.Symbol,
+			HighExchange: d.High.Exchange,
+			LowExchange:  d.Low.Exchange,
+			SpreadBps:    d.SpreadBps,
+			Since:        d.Since,
+			Timestamp:    d.Time,
+		}, true
+	case model.AlertEvent:
+		return alertEventResponse(d), true
+	}
+	return nil, false
+}
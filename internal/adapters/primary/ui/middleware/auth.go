@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+)
+
+// APIKeyHeader is an alternative to an Authorization: Bearer header.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyParam carries the key for clients that cannot set headers, such as
+// EventSource and browser WebSockets.
+const apiKeyParam = "api_key"
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, secret string) (model.APIKey, error)
+}
+
+type apiKeyKey struct{}
+
+// RequireRole lets a request through only when it carries an active key
+// whose role includes role. Rejected requests are written with onError.
+func RequireRole(auth Authenticator, role model.Role, onError func(http.ResponseWriter, *http.Request, error)) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, err := auth.Authenticate(r.Context(), secretFrom(r))
+			if err == nil {
+				err = service.Authorize(key, role)
+			}
+			if err != nil {
+				if model.KindOf(err) == model.KindUnauthenticated {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="marketflow"`)
+				}
+				onError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyKey{}, key)))
+		})
+	}
+}
+
+// APIKeyFrom returns the key the request was authenticated with.
+func APIKeyFrom(ctx context.Context) (model.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(model.APIKey)
+	return key, ok
+}
+
+func secretFrom(r *http.Request) string {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	return r.URL.Query().Get(apiKeyParam)
+}
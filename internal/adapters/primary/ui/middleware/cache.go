@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// cachedResponse is a successful response kept by a ResponseCache.
+type cachedResponse struct {
+	contentType string
+	body        []byte
+	created     time.Time
+	expires     time.Time
+}
+
+// pendingResponse lets concurrent misses of one key wait for a single
+// request instead of all hitting the backend.
+type pendingResponse struct {
+	done chan struct{}
+	resp *cachedResponse
+}
+
+// ResponseCache keeps successful GET responses in memory for as long as a
+// route allows, up to a total body size.
+type ResponseCache struct {
+	maxBytes int
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+	pending map[string]*pendingResponse
+	size    int
+}
+
+func NewResponseCache(maxBytes int) *ResponseCache {
+	return &ResponseCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*cachedResponse),
+		pending:  make(map[string]*pendingResponse),
+	}
+}
+
+// Middleware serves responses from the cache for ttl(r); a TTL of 0 skips
+// the cache. Hits carry the time they were created as Last-Modified.
+func (c *ResponseCache) Middleware(ttl func(*http.Request) time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d := ttl(r)
+			if r.Method != http.MethodGet || d <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := cacheKey(r)
+			resp, pending := c.lookup(key)
+			switch {
+			case resp != nil:
+				writeCached(w, resp, "HIT")
+			case pending != nil:
+				select {
+				case <-pending.done:
+				case <-r.Context().Done():
+					return
+				}
+				if pending.resp == nil {
+					// The request in flight failed; try on our own.
+					next.ServeHTTP(w, r)
+					return
+				}
+				writeCached(w, pending.resp, "HIT")
+			default:
+				if resp := c.fill(w, r, key, d, next); resp != nil {
+					writeCached(w, resp, "MISS")
+				}
+			}
+		})
+	}
+}
+
+// lookup returns a fresh entry, or the request in flight for key. With
+// neither, the caller becomes the request in flight.
+func (c *ResponseCache) lookup(key string) (*cachedResponse, *pendingResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if resp, ok := c.entries[key]; ok {
+		if time.Now().Before(resp.expires) {
+			return resp, nil
+		}
+		c.remove(key)
+	}
+	if p, ok := c.pending[key]; ok {
+		return nil, p
+	}
+	c.pending[key] = &pendingResponse{done: make(chan struct{})}
+	return nil, nil
+}
+
+// fill runs next for key. A successful response is stored and returned;
+// anything else is written to the client directly and nil is returned.
+func (c *ResponseCache) fill(w http.ResponseWriter, r *http.Request, key string, ttl time.Duration, next http.Handler) (resp *cachedResponse) {
+	p := c.pendingFor(key)
+	defer func() {
+		p.resp = resp
+		c.mu.Lock()
+		delete(c.pending, key)
+		c.mu.Unlock()
+		close(p.done)
+	}()
+
+	rec := &recorder{header: make(http.Header), status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+	if rec.status != http.StatusOK {
+		rec.writeTo(w)
+		return nil
+	}
+
+	now := time.Now()
+	resp = &cachedResponse{
+		contentType: rec.header.Get("Content-Type"),
+		body:        rec.body.Bytes(),
+		created:     now,
+		expires:     now.Add(ttl),
+	}
+	c.store(key, resp)
+	return resp
+}
+
+func (c *ResponseCache) pendingFor(key string) *pendingResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending[key]
+}
+
+func (c *ResponseCache) store(key string, resp *cachedResponse) {
+	if len(resp.body) > c.maxBytes/8 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(key)
+	c.entries[key] = resp
+	c.size += len(resp.body)
+	if c.size <= c.maxBytes {
+		return
+	}
+
+	// Drop expired entries first and then the ones closest to expiring.
+	now := time.Now()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			c.remove(k)
+		}
+	}
+	for c.size > c.maxBytes {
+		var oldest string
+		for k, e := range c.entries {
+			if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		c.remove(oldest)
+	}
+}
+
+func (c *ResponseCache) remove(key string) {
+	if e, ok := c.entries[key]; ok {
+		c.size -= len(e.body)
+		delete(c.entries, key)
+	}
+}
+
+func writeCached(w http.ResponseWriter, resp *cachedResponse, status string) {
+	now := time.Now()
+	h := w.Header()
+	h.Set("Content-Type", resp.contentType)
+	h.Set("Last-Modified", resp.created.UTC().Format(http.TimeFormat))
+	h.Set("Cache-Control", "private, max-age="+strconv.Itoa(int(resp.expires.Sub(now).Seconds())))
+	h.Set("Age", strconv.Itoa(int(now.Sub(resp.created).Seconds())))
+	h.Set("X-Cache", status)
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp.body)
+}
+
+// cacheKey is the path and the sorted query, without the API key: every
+// client that may call a route sees the same response.
+func cacheKey(r *http.Request) string {
+	query := r.URL.Query()
+	query.Del(apiKeyParam)
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// recorder captures a response for the cache.
+type recorder struct {
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (rec *recorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *recorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.wroteHeader = true
+		rec.status = code
+	}
+}
+
+func (rec *recorder) Write(data []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(data)
+}
+
+func (rec *recorder) writeTo(w http.ResponseWriter) {
+	for k, v := range rec.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.status)
+	w.Write(rec.body.Bytes())
+}
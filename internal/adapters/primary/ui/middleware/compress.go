@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// minCompressSize is the smallest body worth compressing. Bodies of unknown
+// length are always compressed.
+const minCompressSize = 1024
+
+var gzipWriters = sync.Pool{
+	New: func() any {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	},
+}
+
+var zstdWriters = sync.Pool{
+	New: func() any {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedDefault))
+		return w
+	},
+}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Compress compresses responses with zstd or gzip, whichever the client
+// prefers in Accept-Encoding, with zstd winning a tie. Event streams,
+// WebSocket upgrades and small bodies are sent as they are.
+func Compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks zstd or gzip from an Accept-Encoding header, or ""
+// when the client accepts neither.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "zstd" && name != "gzip" && name != "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		if name == "*" {
+			name = "zstd"
+		}
+		if q > bestQ || (q == bestQ && name == "zstd") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	enc      encoder
+	decided  bool
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if !cw.decided {
+		cw.decided = true
+		if cw.compressible(code) {
+			h := cw.Header()
+			h.Set("Content-Encoding", cw.encoding)
+			h.Del("Content-Length")
+			cw.enc = cw.newEncoder()
+		}
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *compressWriter) compressible(code int) bool {
+	h := cw.Header()
+	if code < 200 || code == http.StatusNoContent || code == http.StatusNotModified || h.Get("Content-Encoding") != "" {
+		return false
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < minCompressSize {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		return false
+	case strings.HasPrefix(contentType, "application/json"),
+		strings.HasPrefix(contentType, "application/x-ndjson"),
+		strings.HasPrefix(contentType, "text/"):
+		return true
+	}
+	return false
+}
+
+func (cw *compressWriter) newEncoder() encoder {
+	if cw.encoding == "zstd" {
+		e := zstdWriters.Get().(*zstd.Encoder)
+		e.Reset(cw.ResponseWriter)
+		return e
+	}
+	e := gzipWriters.Get().(*gzip.Writer)
+	e.Reset(cw.ResponseWriter)
+	return e
+}
+
+func (cw *compressWriter) Write(data []byte) (int, error) {
+	if !cw.decided {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.enc == nil {
+		return cw.ResponseWriter.Write(data)
+	}
+	return cw.enc.Write(data)
+}
+
+func (cw *compressWriter) Flush() {
+	if cw.enc != nil {
+		cw.enc.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+func (cw *compressWriter) close() {
+	if cw.enc == nil {
+		return
+	}
+	cw.enc.Close()
+	switch e := cw.enc.(type) {
+	case *zstd.Encoder:
+		e.Reset(io.Discard)
+		zstdWriters.Put(e)
+	case *gzip.Writer:
+		e.Reset(io.Discard)
+		gzipWriters.Put(e)
+	}
+	cw.enc = nil
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+)
+
+const (
+	corsAllowMethods  = "GET, POST, DELETE, OPTIONS"
+	corsAllowHeaders  = "Content-Type, Authorization, X-API-Key, X-Request-ID"
+	corsExposeHeaders = "X-Request-ID, ETag, X-Cache, Link, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy"
+	corsMaxAge        = "600"
+)
+
+// CORS allows browsers on origins to call the API; "*" allows any origin.
+// Preflight requests are answered here, before they reach the router, which
+// only knows the methods of each route.
+func CORS(origins []string) Middleware {
+	anyOrigin := slices.Contains(origins, "*")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			allowed := anyOrigin || slices.Contains(origins, origin)
+			if allowed {
+				if anyOrigin {
+					h.Set("Access-Control-Allow-Origin", "*")
+				} else {
+					h.Set("Access-Control-Allow-Origin", origin)
+				}
+				h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if allowed {
+					h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+					h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+					h.Set("Access-Control-Max-Age", corsMaxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
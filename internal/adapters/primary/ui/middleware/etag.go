@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ETag tags successful GET responses with a weak ETag of their body and a
+// Last-Modified time, and answers a matching If-None-Match or
+// If-Modified-Since with 304. The tag is weak because Compress encodes the
+// same body differently per client. Event streams, responses flushed before
+// they end and WebSocket upgrades pass through untouched.
+func ETag(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(bw, r)
+		if bw.passthrough {
+			return
+		}
+
+		h := w.Header()
+		if bw.status != http.StatusOK {
+			bw.send()
+			return
+		}
+
+		sum := sha256.Sum256(bw.body.Bytes())
+		etag := `W/"` + hex.EncodeToString(sum[:12]) + `"`
+		h.Set("ETag", etag)
+		if h.Get("Last-Modified") == "" {
+			h.Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		}
+
+		if notModified(r, etag, h.Get("Last-Modified")) {
+			h.Del("Content-Type")
+			h.Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		bw.send()
+	})
+}
+
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	return err == nil && !modified.After(since)
+}
+
+// bufferedWriter holds a response back until it is complete. Event streams
+// are passed through as soon as their headers are written, and other
+// responses as soon as they are flushed.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+	passthrough bool
+}
+
+func (bw *bufferedWriter) WriteHeader(code int) {
+	if bw.wroteHeader {
+		return
+	}
+	bw.wroteHeader = true
+	bw.status = code
+
+	if strings.HasPrefix(bw.Header().Get("Content-Type"), "text/event-stream") {
+		bw.passthrough = true
+		bw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (bw *bufferedWriter) Write(data []byte) (int, error) {
+	if !bw.wroteHeader {
+		bw.WriteHeader(http.StatusOK)
+	}
+	if bw.passthrough {
+		return bw.ResponseWriter.Write(data)
+	}
+	return bw.body.Write(data)
+}
+
+// Flush passes the response through from then on, with what was held back
+// so far.
+func (bw *bufferedWriter) Flush() {
+	if !bw.wroteHeader {
+		bw.WriteHeader(http.StatusOK)
+	}
+	if !bw.passthrough {
+		bw.passthrough = true
+		bw.ResponseWriter.WriteHeader(bw.status)
+		bw.ResponseWriter.Write(bw.body.Bytes())
+		bw.body.Reset()
+	}
+	http.NewResponseController(bw.ResponseWriter).Flush()
+}
+
+// send writes the held back response with its length.
+func (bw *bufferedWriter) send() {
+	bw.Header().Set("Content-Length", strconv.Itoa(bw.body.Len()))
+	bw.ResponseWriter.WriteHeader(bw.status)
+	bw.ResponseWriter.Write(bw.body.Bytes())
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (bw *bufferedWriter) Unwrap() http.ResponseWriter {
+	return bw.ResponseWriter
+}
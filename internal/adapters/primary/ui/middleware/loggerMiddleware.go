@@ -1,11 +1,49 @@
 package middleware
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
+
+	"marketflow/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "marketflow_http_requests_total",
+		Help: "HTTP requests served, by route pattern.",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "marketflow_http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests, by route pattern. Streams count until they end.",
+		Buckets: metrics.DurationBuckets,
+	}, []string{"method", "route"})
 )
 
+// unmatchedRoute labels the requests no route served.
+const unmatchedRoute = "unmatched"
+
+type routeKey struct{}
+
+// Route records the pattern of the route serving a request, so that Logger
+// can label its metrics with the pattern rather than the path.
+func Route(pattern string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route, ok := r.Context().Value(routeKey{}).(*string); ok {
+				*route = pattern
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Logger logs every request and counts it in the HTTP metrics.
 func Logger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -15,11 +53,16 @@ func Logger(next http.Handler) http.Handler {
 			statusCode:     200,
 		}
 
-		next.ServeHTTP(lrw, r)
+		route := unmatchedRoute
+		next.ServeHTTP(lrw, r.WithContext(context.WithValue(r.Context(), routeKey{}, &route)))
 
 		duration := time.Since(start)
+		method := metricMethod(r.Method)
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(lrw.statusCode)).Inc()
+		httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
 		if lrw.statusCode > 400 {
 			slog.ErrorContext(r.Context(), "HTTP Request failed",
+				"request_id", RequestIDFrom(r.Context()),
 				"method", r.Method,
 				"path", r.URL.Path,
 				"status_code", lrw.statusCode,
@@ -27,6 +70,7 @@ func Logger(next http.Handler) http.Handler {
 			)
 		} else {
 			slog.InfoContext(r.Context(), "HTTP Request",
+				"request_id", RequestIDFrom(r.Context()),
 				"method", r.Method,
 				"path", r.URL.Path,
 				"status_code", lrw.statusCode,
@@ -56,3 +100,19 @@ func (lrw *loggingResponseWriter) Write(data []byte) (int, error) {
 	}
 	return lrw.ResponseWriter.Write(data)
 }
+
+// Unwrap lets http.ResponseController reach the underlying writer, which
+// streaming handlers need to flush.
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
+
+// metricMethod keeps the method label to the standard methods.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"identity":                "",
+		"gzip":                    "gzip",
+		"gzip, deflate, br, zstd": "zstd",
+		"gzip;q=1.0, zstd;q=0.5":  "gzip",
+		"zstd;q=0, gzip;q=0.1":    "gzip",
+		"*":                       "zstd",
+		"GZIP;q=0.8, deflate;q=1": "gzip",
+		"gzip;q=0":                "",
+	}
+	for header, want := range tests {
+		if got := negotiateEncoding(header); got != want {
+			t.Errorf("%q: expected %q, got %q", header, want, got)
+		}
+	}
+}
+
+func TestETagAndCompress(t *testing.T) {
+	body := strings.Repeat(`{"price":1.5}`, 200)
+	h := NewChainMiddleware(Compress, ETag)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+
+	req := httptest.NewRequest("GET", "/prices/average/BTCUSDT", nil)
+	req.Header.Set("Accept-Encoding", "zstd")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" || rec.Header().Get("Content-Encoding") != "zstd" {
+		t.Fatalf("unexpected response %d %v", rec.Code, rec.Header())
+	}
+	dec, _ := zstd.NewReader(rec.Body)
+	defer dec.Close()
+	if got, err := io.ReadAll(dec); err != nil || string(got) != body {
+		t.Fatalf("body did not round-trip: %v", err)
+	}
+
+	req = httptest.NewRequest("GET", "/prices/average/BTCUSDT", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("expected an empty 304, got %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+}
+
+func TestETag_Stream(t *testing.T) {
+	h := ETag(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "data: 1\n\n")
+		http.NewResponseController(w).Flush()
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/stream/prices", nil))
+	if !rec.Flushed || rec.Header().Get("ETag") != "" || rec.Body.String() != "data: 1\n\n" {
+		t.Errorf("expected the stream to pass through, got %v %q", rec.Header(), rec.Body.String())
+	}
+
+	h = ETag(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		io.WriteString(w, "{}\n")
+		http.NewResponseController(w).Flush()
+		io.WriteString(w, "{}\n")
+	}))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/prices/history/exchange1/BTCUSDT", nil))
+	if !rec.Flushed || rec.Header().Get("ETag") != "" || rec.Body.String() != "{}\n{}\n" {
+		t.Errorf("expected a flushed export to pass through, got %v %q", rec.Header(), rec.Body.String())
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	var calls atomic.Int32
+	h := NewResponseCache(1 << 20).Middleware(func(r *http.Request) time.Duration {
+		if r.URL.Query().Get("period") == "bad" {
+			return 0
+		}
+		return time.Minute
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"avg":1}`)
+	}))
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec
+	}
+
+	if rec := get("/prices/average/BTCUSDT?period=1h&api_key=a"); rec.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("expected a miss, got %v", rec.Header())
+	}
+	rec := get("/prices/average/BTCUSDT?api_key=b&period=1h")
+	if rec.Header().Get("X-Cache") != "HIT" || rec.Body.String() != `{"avg":1}` || rec.Header().Get("Last-Modified") == "" {
+		t.Errorf("expected a hit for the same query, got %v %q", rec.Header(), rec.Body.String())
+	}
+	get("/prices/average/BTCUSDT?period=24h")
+	get("/prices/average/BTCUSDT?period=bad")
+	get("/prices/average/BTCUSDT?period=bad")
+
+	if n := calls.Load(); n != 4 {
+		t.Errorf("expected 4 calls to the handler, got %d", n)
+	}
+}
+
+func TestLoggerMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /items/{id}", Route("/items/{id}")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})))
+	h := Logger(mux)
+
+	served := httpRequests.WithLabelValues("GET", "/items/{id}", "202")
+	unmatched := httpRequests.WithLabelValues("GET", unmatchedRoute, "404")
+	before, beforeUnmatched := testutil.ToFloat64(served), testutil.ToFloat64(unmatched)
+
+	for _, path := range []string{"/items/1", "/items/2", "/nowhere"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if got := testutil.ToFloat64(served) - before; got != 2 {
+		t.Errorf("expected both items to count under their pattern, got %v", got)
+	}
+	if got := testutil.ToFloat64(unmatched) - beforeUnmatched; got != 1 {
+		t.Errorf("expected the unknown path to count as unmatched, got %v", got)
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+)
+
+type Limiter interface {
+	Take(ctx context.Context, client, group string) (model.RateDecision, error)
+}
+
+// RateLimit limits the requests of every client to the routes of group. A
+// client is its API key when the request was authenticated and its IP
+// address otherwise, so it counts keys only after RequireRole. Rejected
+// requests are written with onError.
+func RateLimit(limiter Limiter, group string, onError func(http.ResponseWriter, *http.Request, error)) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d, err := limiter.Take(r.Context(), ClientID(r), group)
+			if err != nil {
+				// Failing open keeps the API up when limiting itself fails.
+				slog.ErrorContext(r.Context(), "rate limit failed", "request_id", RequestIDFrom(r.Context()), "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if d.Limit > 0 {
+				h := w.Header()
+				h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+				h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+				h.Set("RateLimit-Reset", strconv.Itoa(seconds(d.Reset)))
+				h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", d.Limit, seconds(d.Window)))
+			}
+			if !d.Allowed {
+				retryAfter := seconds(d.RetryAfter)
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				onError(w, r, service.ErrRateLimited.WithDetails(map[string]any{
+					"group":       group,
+					"retry_after": retryAfter,
+				}))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientID identifies who sent a request for limits and usage.
+func ClientID(r *http.Request) string {
+	if key, ok := APIKeyFrom(r.Context()); ok {
+		return "key:" + strconv.FormatInt(key.ID, 10)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// seconds rounds d up to whole seconds, as the headers need.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
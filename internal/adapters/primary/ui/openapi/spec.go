@@ -0,0 +1,110 @@
+// Package openapi serves the OpenAPI document of the REST API and validates
+// requests against it.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+//go:embed openapi.json
+var document []byte
+
+//go:embed docs.html
+var docsPage []byte
+
+// Spec is the part of the OpenAPI document requests are validated against.
+type Spec struct {
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Parameters map[string]*Parameter `json:"parameters"`
+		Schemas    map[string]*Schema    `json:"schemas"`
+	} `json:"components"`
+}
+
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []*Parameter `json:"parameters"`
+}
+
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref     string   `json:"$ref"`
+	Type    string   `json:"type"`
+	Enum    []string `json:"enum"`
+	Pattern string   `json:"pattern"`
+	Minimum *float64 `json:"minimum"`
+	Maximum *float64 `json:"maximum"`
+}
+
+// Load parses the embedded document and resolves the references of its
+// parameters.
+func Load() (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(document, &spec); err != nil {
+		return nil, fmt.Errorf("parse openapi document: %w", err)
+	}
+
+	for path, item := range spec.Paths {
+		for method, op := range item {
+			for i, p := range op.Parameters {
+				resolved, err := spec.parameter(p)
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+				}
+				op.Parameters[i] = resolved
+			}
+		}
+	}
+	return &spec, nil
+}
+
+func (s *Spec) parameter(p *Parameter) (*Parameter, error) {
+	if p.Ref != "" {
+		name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/")
+		if !ok || s.Components.Parameters[name] == nil {
+			return nil, fmt.Errorf("unknown parameter %q", p.Ref)
+		}
+		p = s.Components.Parameters[name]
+	}
+
+	if p.Schema != nil && p.Schema.Ref != "" {
+		name, ok := strings.CutPrefix(p.Schema.Ref, "#/components/schemas/")
+		if !ok || s.Components.Schemas[name] == nil {
+			return nil, fmt.Errorf("parameter %s: unknown schema %q", p.Name, p.Schema.Ref)
+		}
+		resolved := *p
+		resolved.Schema = s.Components.Schemas[name]
+		p = &resolved
+	}
+	return p, nil
+}
+
+// Operation returns the operation of method on path, a route pattern such as
+// "/prices/latest/{symbol}".
+func (s *Spec) Operation(method, path string) (*Operation, bool) {
+	op, ok := s.Paths[path][strings.ToLower(method)]
+	return op, ok
+}
+
+// ServeDocument serves the OpenAPI document.
+func ServeDocument(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(document)
+}
+
+// ServeDocs serves a page that renders the document.
+func ServeDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
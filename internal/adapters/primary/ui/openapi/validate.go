@@ -0,0 +1,136 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"marketflow/internal/core/model"
+)
+
+// codeInvalidParameter matches the code the handlers use for parameters they
+// reject themselves.
+const codeInvalidParameter = "invalid_parameter"
+
+// Validator checks the path and query parameters of one operation. Query
+// parameters the operation does not declare are ignored.
+type Validator struct {
+	params   []*Parameter
+	patterns map[string]*regexp.Regexp
+}
+
+func NewValidator(op *Operation) (*Validator, error) {
+	v := &Validator{patterns: make(map[string]*regexp.Regexp)}
+	for _, p := range op.Parameters {
+		if p.In != "path" && p.In != "query" {
+			continue
+		}
+		if p.Schema != nil && p.Schema.Pattern != "" {
+			re, err := regexp.Compile(p.Schema.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %w", p.Name, err)
+			}
+			v.patterns[p.Name] = re
+		}
+		v.params = append(v.params, p)
+	}
+	return v, nil
+}
+
+// Validate returns a model.KindInvalid error for the first parameter that
+// does not match the spec. Path values are only available once the request
+// was routed, so it has to run inside the mux.
+func (v *Validator) Validate(r *http.Request) error {
+	query := r.URL.Query()
+	for _, p := range v.params {
+		var values []string
+		switch p.In {
+		case "path":
+			if value := r.PathValue(p.Name); value != "" {
+				values = []string{value}
+			}
+		case "query":
+			for _, value := range query[p.Name] {
+				if value != "" {
+					values = append(values, value)
+				}
+			}
+		}
+
+		if len(values) == 0 {
+			if p.Required {
+				return invalid(p, "missing required %s parameter %s", p.In, p.Name)
+			}
+			continue
+		}
+
+		for _, value := range values {
+			if err := v.check(p, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *Validator) check(p *Parameter, value string) error {
+	s := p.Schema
+	if s == nil {
+		return nil
+	}
+
+	switch s.Type {
+	case "integer", "number":
+		var n float64
+		var err error
+		if s.Type == "integer" {
+			var i int64
+			i, err = strconv.ParseInt(value, 10, 64)
+			n = float64(i)
+		} else {
+			n, err = strconv.ParseFloat(value, 64)
+		}
+		if err != nil {
+			return invalid(p, "%s must be of type %s, got %q", p.Name, s.Type, value)
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			return invalid(p, "%s must be at least %v, got %s", p.Name, *s.Minimum, value)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			return invalid(p, "%s must be at most %v, got %s", p.Name, *s.Maximum, value)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return invalid(p, "%s must be true or false, got %q", p.Name, value)
+		}
+	}
+
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, value) {
+		return invalid(p, "%s must be one of %v, got %q", p.Name, s.Enum, value).
+			WithDetails(map[string]any{"allowed": s.Enum})
+	}
+	if re := v.patterns[p.Name]; re != nil && !re.MatchString(value) {
+		return invalid(p, "%s %q does not match %s", p.Name, value, s.Pattern)
+	}
+	return nil
+}
+
+func invalid(p *Parameter, format string, args ...any) *model.Error {
+	return model.NewError(model.KindInvalid, codeInvalidParameter, fmt.Sprintf(format, args...)).
+		WithDetails(map[string]any{"parameter": p.Name, "in": p.In})
+}
+
+// Middleware rejects requests that fail validation with onError.
+func (v *Validator) Middleware(onError func(http.ResponseWriter, *http.Request, error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := v.Validate(r); err != nil {
+				onError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
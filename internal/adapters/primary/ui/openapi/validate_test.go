@@ -0,0 +1,66 @@
+package openapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"marketflow/internal/core/model"
+)
+
+func TestValidator(t *testing.T) {
+	spec, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, ok := spec.Operation("GET", "/indicators/{exchange}/{symbol}")
+	if !ok {
+		t.Fatal("operation not found")
+	}
+	v, err := NewValidator(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		exchange string
+		symbol   string
+		query    string
+		param    string
+	}{
+		{"valid", "exchange1", "BTCUSDT", "type=rsi&window=14&interval=5m", ""},
+		{"missing required", "exchange1", "BTCUSDT", "window=14", "type"},
+		{"not in enum", "exchange1", "BTCUSDT", "type=vwap", "type"},
+		{"not an integer", "exchange1", "BTCUSDT", "type=sma&window=ten", "window"},
+		{"below minimum", "exchange1", "BTCUSDT", "type=sma&window=1", "window"},
+		{"above maximum", "exchange1", "BTCUSDT", "type=sma&window=201", "window"},
+		{"path pattern", "exchange1", "BTC-USDT", "type=sma", "symbol"},
+		{"undeclared query", "exchange1", "BTCUSDT", "type=sma&foo=bar", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/indicators/x/y?"+tt.query, nil)
+			r.SetPathValue("exchange", tt.exchange)
+			r.SetPathValue("symbol", tt.symbol)
+
+			err := v.Validate(r)
+			if tt.param == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var e *model.Error
+			if !errors.As(err, &e) || e.Kind != model.KindInvalid {
+				t.Fatalf("expected an invalid argument error, got %v", err)
+			}
+			if e.Details["parameter"] != tt.param {
+				t.Errorf("expected parameter %s, got %v", tt.param, e.Details["parameter"])
+			}
+		})
+	}
+}
@@ -1,29 +1,161 @@
 package ui
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 
 	"marketflow/internal/adapters/primary/ui/handlers"
+	"marketflow/internal/adapters/primary/ui/middleware"
+	"marketflow/internal/adapters/primary/ui/openapi"
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func RegisterRoutes(handler *handlers.Handler) http.Handler {
-	mux := http.NewServeMux()
+// route is one entry of the API. Every route needs a matching operation in
+// openapi/openapi.json, whose parameters it is validated against. role is
+// the least a key must allow to call the route; routes without one are
+// public. Routes of a rate limit group share one bucket per client.
+type route struct {
+	method  string
+	path    string
+	role    model.Role
+	group   string
+	handler http.HandlerFunc
+}
+
+func routes(handler *handlers.Handler) []route {
+	return []route{
+		{"GET", "/prices/latest", model.RoleRead, service.RateGroupLatest, handler.LatestMatrix},
+		{"POST", "/prices/latest/batch", model.RoleRead, service.RateGroupLatest, handler.LatestBatch},
+		{"GET", "/prices/latest/{symbol}", model.RoleRead, service.RateGroupLatest, handler.LatestBySymbol},
+		{"GET", "/prices/latest/{exchange}/{symbol}", model.RoleRead, service.RateGroupLatest, handler.LatestBySymbolAndExchange},
+
+		{"GET", "/prices/highest/{symbol}", model.RoleRead, service.RateGroupHistory, handler.HighestBySymbol},
+		{"GET", "/prices/highest/{exchange}/{symbol}", model.RoleRead, service.RateGroupHistory, handler.HighestBySymbolAndExchange},
+
+		{"GET", "/prices/lowest/{symbol}", model.RoleRead, service.RateGroupHistory, handler.LowestBySymbol},
+		{"GET", "/prices/lowest/{exchange}/{symbol}", model.RoleRead, service.RateGroupHistory, handler.LowestBySymbolAndExchange},
+
+		{"GET", "/prices/average/{symbol}", model.RoleRead, service.RateGroupHistory, handler.AverageBySymbol},
+		{"GET", "/prices/average/{exchange}/{symbol}", model.RoleRead, service.RateGroupHistory, handler.AverageBySymbolAndExchange},
+
+		{"GET", "/prices/stats/{symbol}", model.RoleRead, service.RateGroupHistory, handler.StatsBySymbol},
+		{"GET", "/prices/stats/{exchange}/{symbol}", model.RoleRead, service.RateGroupHistory, handler.StatsBySymbolAndExchange},
+
+		{"GET", "/prices/volatility/{symbol}", model.RoleRead, service.RateGroupHistory, handler.VolatilityBySymbol},
+		{"GET", "/prices/volatility/{exchange}/{symbol}", model.RoleRead, service.RateGroupHistory, handler.VolatilityBySymbolAndExchange},
+
+		{"GET", "/prices/history/{exchange}/{symbol}", model.RoleRead, service.RateGroupHistory, handler.History},
+
+		{"GET", "/prices/consensus/{symbol}", model.RoleRead, service.RateGroupLatest, handler.ConsensusBySymbol},
+
+		{"GET", "/spreads/{symbol}", model.RoleRead, service.RateGroupLatest, handler.SpreadsBySymbol},
+
+		{"GET", "/indicators/{exchange}/{symbol}", model.RoleRead, service.RateGroupHistory, handler.Indicator},
 
-	mux.HandleFunc("GET /prices/latest/{symbol}", handler.LatestBySymbol)
-	mux.HandleFunc("GET /prices/latest/{exchange}/{symbol}", handler.LatestBySymbolAndExchange)
+		{"GET", "/alerts/rules", model.RoleRead, service.RateGroupLatest, handler.ListAlertRules},
+		{"POST", "/alerts/rules", model.RoleOperator, service.RateGroupAdmin, handler.CreateAlertRule},
+		{"DELETE", "/alerts/rules/{id}", model.RoleOperator, service.RateGroupAdmin, handler.DeleteAlertRule},
+		{"GET", "/alerts/history", model.RoleRead, service.RateGroupHistory, handler.AlertHistory},
 
-	mux.HandleFunc("GET /prices/highest/{symbol}", handler.HighestBySymbol)
-	mux.HandleFunc("GET /prices/highest/{exchange}/{symbol}", handler.HighestBySymbolAndExchange)
+		{"GET", "/webhooks", model.RoleAdmin, service.RateGroupAdmin, handler.ListWebhooks},
+		{"POST", "/webhooks", model.RoleAdmin, service.RateGroupAdmin, handler.CreateWebhook},
+		{"DELETE", "/webhooks/{id}", model.RoleAdmin, service.RateGroupAdmin, handler.DeleteWebhook},
+		{"POST", "/webhooks/{id}/test", model.RoleAdmin, service.RateGroupAdmin, handler.TestWebhook},
+		{"GET", "/webhooks/{id}/deliveries", model.RoleAdmin, service.RateGroupAdmin, handler.WebhookDeliveries},
 
-	mux.HandleFunc("GET /prices/lowest/{symbol}", handler.LowestBySymbol)
-	mux.HandleFunc("GET /prices/lowest/{exchange}/{symbol}", handler.LowestBySymbolAndExchange)
+		{"GET", "/stream/prices", model.RoleRead, service.RateGroupStream, handler.StreamPrices},
+		{"GET", "/ws", model.RoleRead, service.RateGroupStream, handler.WebSocket},
+
+		{"GET", "/symbols", model.RoleRead, service.RateGroupLatest, handler.Symbols},
+		{"GET", "/exchanges/{name}/symbols", model.RoleRead, service.RateGroupLatest, handler.SymbolsByExchange},
+
+		{"GET", "/health", "", "", handler.HealthCheck},
+		{"GET", "/metrics", model.RoleRead, "", promhttp.Handler().ServeHTTP},
+		{"POST", "/mode/test", model.RoleOperator, service.RateGroupAdmin, handler.SwitchToTestMode},
+		{"POST", "/mode/live", model.RoleOperator, service.RateGroupAdmin, handler.SwitchToLiveMode},
+
+		{"GET", "/admin/retention", model.RoleAdmin, service.RateGroupAdmin, handler.RetentionStatus},
+		{"GET", "/admin/usage", model.RoleAdmin, service.RateGroupAdmin, handler.Usage},
+		{"GET", "/admin/keys", model.RoleAdmin, service.RateGroupAdmin, handler.ListAPIKeys},
+		{"POST", "/admin/keys", model.RoleAdmin, service.RateGroupAdmin, handler.IssueAPIKey},
+		{"DELETE", "/admin/keys/{id}", model.RoleAdmin, service.RateGroupAdmin, handler.RevokeAPIKey},
+
+		{"GET", "/openapi.json", "", "", openapi.ServeDocument},
+		{"GET", "/docs", "", "", openapi.ServeDocs},
+	}
+}
+
+// cachedPaths are the routes over a period whose responses are cached.
+var cachedPaths = map[string]bool{
+	"/prices/highest/{symbol}":               true,
+	"/prices/highest/{exchange}/{symbol}":    true,
+	"/prices/lowest/{symbol}":                true,
+	"/prices/lowest/{exchange}/{symbol}":     true,
+	"/prices/average/{symbol}":               true,
+	"/prices/average/{exchange}/{symbol}":    true,
+	"/prices/stats/{symbol}":                 true,
+	"/prices/stats/{exchange}/{symbol}":      true,
+	"/prices/volatility/{symbol}":            true,
+	"/prices/volatility/{exchange}/{symbol}": true,
+}
+
+// Options decide who may call the routes and how they are served.
+type Options struct {
+	Auth middleware.Authenticator
+	// AnonymousRead opens the read routes to requests without a key.
+	AnonymousRead bool
+	// Limiter limits the routes of every group; without one nothing is
+	// limited.
+	Limiter middleware.Limiter
+	// Cache keeps the responses of cachedPaths; without one nothing is
+	// cached.
+	Cache *middleware.ResponseCache
+}
+
+func RegisterRoutes(handler *handlers.Handler, opts Options) (http.Handler, error) {
+	if opts.Auth == nil {
+		return nil, errors.New("no authenticator for the protected routes")
+	}
+
+	spec, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	for _, rt := range routes(handler) {
+		chain := []middleware.Middleware{middleware.Route(rt.path)}
+		if rt.role != "" && !(opts.AnonymousRead && rt.role == model.RoleRead) {
+			if opts.Limiter != nil {
+				chain = append(chain, middleware.RateLimit(opts.Limiter, service.RateGroupAuth, handlers.WriteError))
+			}
+			chain = append(chain, middleware.RequireRole(opts.Auth, rt.role, handlers.WriteError))
+		}
+		if opts.Limiter != nil && rt.group != "" {
+			chain = append(chain, middleware.RateLimit(opts.Limiter, rt.group, handlers.WriteError))
+		}
 
-	mux.HandleFunc("GET /prices/average/{symbol}", handler.AverageBySymbol)
-	mux.HandleFunc("GET /prices/average/{exchange}/{symbol}", handler.AverageBySymbolAndExchange)
+		op, ok := spec.Operation(rt.method, rt.path)
+		if !ok {
+			slog.Warn("route is missing from the OpenAPI document", "method", rt.method, "path", rt.path)
+		} else {
+			validator, err := openapi.NewValidator(op)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", rt.method, rt.path, err)
+			}
+			chain = append(chain, validator.Middleware(handlers.WriteError))
+		}
+		if opts.Cache != nil && cachedPaths[rt.path] {
+			chain = append(chain, opts.Cache.Middleware(handler.CacheTTL))
+		}
 
-	mux.HandleFunc("GET /health", handler.HealthCheck)
-	mux.HandleFunc("POST /mode/test", handler.SwitchToTestMode)
-	mux.HandleFunc("POST /mode/live", handler.SwitchToLiveMode)
+		mux.Handle(rt.method+" "+rt.path, middleware.NewChainMiddleware(chain...)(rt.handler))
+	}
 
-	return mux
+	return mux, nil
 }
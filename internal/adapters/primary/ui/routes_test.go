@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/primary/ui/handlers"
+	"marketflow/internal/adapters/primary/ui/middleware"
+	"marketflow/internal/adapters/primary/ui/openapi"
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestRoutes_MatchSpec(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registered := make(map[string]bool)
+	for _, rt := range routes(handlers.NewHandler(nil)) {
+		registered[strings.ToLower(rt.method)+" "+rt.path] = true
+		if _, ok := spec.Operation(rt.method, rt.path); !ok {
+			t.Errorf("%s %s is not described in openapi.json", rt.method, rt.path)
+		}
+	}
+
+	for path, item := range spec.Paths {
+		for method := range item {
+			if !registered[method+" "+path] {
+				t.Errorf("openapi.json describes %s %s, which is not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+type fakeAuth map[string]model.Role
+
+func (f fakeAuth) Authenticate(_ context.Context, secret string) (model.APIKey, error) {
+	role, ok := f[secret]
+	if !ok {
+		return model.APIKey{}, service.ErrUnauthenticated
+	}
+	return model.APIKey{Role: role}, nil
+}
+
+func TestRegisterRoutes(t *testing.T) {
+	if _, err := RegisterRoutes(handlers.NewHandler(nil), Options{}); err == nil {
+		t.Error("expected an error without an authenticator")
+	}
+
+	handler := handlers.NewHandler(nil)
+	handlers.WithTestModeSwitch(func() error { return nil }, handler)
+	handlers.WithHealthCheck(func() []byte { return []byte("{}") }, handler)
+
+	mux, err := RegisterRoutes(handler, Options{
+		Auth: fakeAuth{"reader": model.RoleRead, "operator": model.RoleOperator},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path, key string
+		want              int
+	}{
+		{"POST", "/mode/test", "", http.StatusUnauthorized},
+		{"POST", "/mode/test", "unknown", http.StatusUnauthorized},
+		{"POST", "/mode/test", "reader", http.StatusForbidden},
+		{"POST", "/mode/test", "operator", http.StatusOK},
+		{"GET", "/admin/keys", "operator", http.StatusForbidden},
+		{"GET", "/prices/latest/BTCUSDT", "", http.StatusUnauthorized},
+		{"GET", "/health", "", http.StatusOK},
+		{"GET", "/metrics", "", http.StatusUnauthorized},
+		{"GET", "/metrics", "reader", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.key != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s with key %q: expected %d, got %d", tt.method, tt.path, tt.key, tt.want, rec.Code)
+		}
+	}
+}
+
+type countingAuth struct {
+	fakeAuth
+	calls int
+}
+
+func (c *countingAuth) Authenticate(ctx context.Context, secret string) (model.APIKey, error) {
+	c.calls++
+	return c.fakeAuth.Authenticate(ctx, secret)
+}
+
+func TestRegisterRoutes_LimitsBeforeAuth(t *testing.T) {
+	auth := &countingAuth{fakeAuth: fakeAuth{}}
+	limiter := service.NewRateLimiter(nil, map[string]model.RateLimit{
+		service.RateGroupAuth: {Requests: 1, Per: time.Minute, Burst: 2},
+	})
+	mux, err := RegisterRoutes(handlers.NewHandler(nil), Options{Auth: auth, Limiter: limiter})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var codes []int
+	for i := range 3 {
+		req := httptest.NewRequest("GET", "/prices/latest/BTCUSDT", nil)
+		req.Header.Set("Authorization", "Bearer made-up-"+strconv.Itoa(i))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+	if codes[2] != http.StatusTooManyRequests || auth.calls != 2 {
+		t.Errorf("expected the third made-up key to be limited before it is looked up, got %v after %d lookups", codes, auth.calls)
+	}
+}
+
+func TestCachedPaths_AreRegistered(t *testing.T) {
+	registered := make(map[string]bool)
+	for _, rt := range routes(handlers.NewHandler(nil)) {
+		if rt.method == "GET" {
+			registered[rt.path] = true
+		}
+	}
+	for path := range cachedPaths {
+		if !registered[path] {
+			t.Errorf("cached path %s is not a GET route", path)
+		}
+	}
+}
+
+// TestWebSocket goes through every middleware, which all wrap the response
+// writer that the upgrade has to hijack.
+func TestWebSocket(t *testing.T) {
+	broadcaster := service.NewBroadcaster()
+	defer broadcaster.Close()
+
+	handler := handlers.NewHandler(nil)
+	handlers.WithBroadcaster(broadcaster, handler)
+	mux, err := RegisterRoutes(handler, Options{
+		Auth:          fakeAuth{},
+		AnonymousRead: true,
+		Cache:         middleware.NewResponseCache(1 << 20),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	read := func() handlers.WSMessage {
+		t.Helper()
+		var m handlers.WSMessage
+		if err := conn.ReadJSON(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	if err := conn.WriteJSON(handlers.WSRequest{Op: "subscribe", Channel: "ticks", Symbols: []string{model.BTCUSDT}}); err != nil {
+		t.Fatal(err)
+	}
+	if m := read(); m.Type != "subscribed" {
+		t.Fatalf("expected the subscription to be confirmed, got %+v", m)
+	}
+	if m := read(); m.Type != "snapshot" {
+		t.Fatalf("expected a snapshot, got %+v", m)
+	}
+
+	broadcaster.Observe("exchange1", model.Trade{Symbol: model.ETHUSDT, Price: 1})
+	broadcaster.Observe("exchange1", model.Trade{Symbol: model.BTCUSDT, Price: 2})
+	if m := read(); m.Type != "update" || m.Channel != "ticks" {
+		t.Fatalf("expected only the subscribed symbol, got %+v", m)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("{")); err != nil {
+		t.Fatal(err)
+	}
+	if m := read(); m.Type != "error" {
+		t.Fatalf("expected an invalid message to be reported, got %+v", m)
+	}
+
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("expected the close to be answered, got %v", err)
+	}
+}
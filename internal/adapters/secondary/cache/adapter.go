@@ -16,8 +16,8 @@ type CacheAdapter struct {
 
 func NewCacheAdapter(cache Cache, fallback Cache) *CacheAdapter {
 	return &CacheAdapter{
-		cache:    cache,
-		fallback: fallback,
+		cache:    Instrument("redis", cache),
+		fallback: Instrument("postgres", fallback),
 	}
 }
 
@@ -25,6 +25,7 @@ func (c *CacheAdapter) GetCollection(ctx context.Context) ([]string, []string, e
 	exchangers, symbols, err := c.cache.GetCollection(ctx)
 	if err != nil {
 		if errors.Is(err, redis.ErrNoConnection) {
+			CountFallback("cache", "get_collection")
 			exchangers, symbols, err = c.fallback.GetCollection(ctx)
 			if err != nil {
 				return nil, nil, err
@@ -41,6 +42,7 @@ func (c *CacheAdapter) SaveRawData(ctx context.Context, exchanger string, data m
 	err := c.cache.SaveRawData(ctx, exchanger, data)
 	if err != nil {
 		if errors.Is(err, redis.ErrNoConnection) {
+			CountFallback("cache", "save_raw_data")
 			if err := c.fallback.SaveRawData(ctx, exchanger, data); err != nil {
 				return err
 			}
@@ -56,6 +58,7 @@ func (c *CacheAdapter) GetRawData(ctx context.Context, exchanger string, symbol
 	data, err := c.cache.GetRawData(ctx, exchanger, symbol, interval)
 	if err != nil {
 		if errors.Is(err, redis.ErrNoConnection) {
+			CountFallback("cache", "get_raw_data")
 			return c.fallback.GetRawData(ctx, exchanger, symbol, interval)
 		}
 		return nil, err
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"marketflow/internal/core/model"
+	"marketflow/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The storage adapter records its own calls in the same metrics, through
+// Observe and CountFallback.
+var (
+	callDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "marketflow_store_call_duration_seconds",
+		Help:    "Latency of the calls to Redis and Postgres.",
+		Buckets: metrics.DurationBuckets,
+	}, []string{"backend", "operation"})
+	callErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "marketflow_store_call_errors_total",
+		Help: "Calls to Redis and Postgres that failed, not counting the ones that found no data.",
+	}, []string{"backend", "operation"})
+	fallbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "marketflow_store_fallbacks_total",
+		Help: "Calls that fell back to Postgres after Redis failed.",
+	}, []string{"adapter", "operation"})
+)
+
+// Observe records a call to backend that started at start.
+func Observe(backend, operation string, start time.Time, err error) {
+	callDuration.WithLabelValues(backend, operation).Observe(time.Since(start).Seconds())
+	if err != nil && model.KindOf(err) != model.KindNotFound {
+		callErrors.WithLabelValues(backend, operation).Inc()
+	}
+}
+
+// CountFallback records a call of adapter that fell back to Postgres.
+func CountFallback(adapter, operation string) {
+	fallbacks.WithLabelValues(adapter, operation).Inc()
+}
+
+// instrumented times every call to a Cache.
+type instrumented struct {
+	backend string
+	next    Cache
+}
+
+// Instrument records the latency and errors of the calls to c under backend.
+func Instrument(backend string, c Cache) Cache {
+	return &instrumented{backend: backend, next: c}
+}
+
+func (i *instrumented) GetCollection(ctx context.Context) ([]string, []string, error) {
+	start := time.Now()
+	exchangers, symbols, err := i.next.GetCollection(ctx)
+	Observe(i.backend, "get_collection", start, err)
+	return exchangers, symbols, err
+}
+
+func (i *instrumented) SaveRawData(ctx context.Context, exchanger string, data model.Trade) error {
+	start := time.Now()
+	err := i.next.SaveRawData(ctx, exchanger, data)
+	Observe(i.backend, "save_raw_data", start, err)
+	return err
+}
+
+func (i *instrumented) GetRawData(ctx context.Context, exchanger string, symbol string, interval time.Duration) ([]model.Trade, error) {
+	start := time.Now()
+	data, err := i.next.GetRawData(ctx, exchanger, symbol, interval)
+	Observe(i.backend, "get_raw_data", start, err)
+	return data, err
+}
+
+func (i *instrumented) GetLatest(ctx context.Context, exchange string, symbol string) (model.Quote, error) {
+	start := time.Now()
+	quote, err := i.next.GetLatest(ctx, exchange, symbol)
+	Observe(i.backend, "get_latest", start, err)
+	return quote, err
+}
+
+func (i *instrumented) GetLatestBatch(ctx context.Context, pairs []model.Pair) ([]model.Quote, error) {
+	start := time.Now()
+	quotes, err := i.next.GetLatestBatch(ctx, pairs)
+	Observe(i.backend, "get_latest_batch", start, err)
+	return quotes, err
+}
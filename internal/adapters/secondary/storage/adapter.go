@@ -3,8 +3,9 @@ package storage
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log/slog"
+	"math"
+	"slices"
 	"time"
 
 	"marketflow/infrastucture/redis"
@@ -12,7 +13,17 @@ import (
 	"marketflow/internal/core/model"
 )
 
-var ErrNoData = fmt.Errorf("no data found")
+var ErrNoData = model.NewError(model.KindNotFound, "no_data", "no data found")
+
+// rawTickWindow is how far back the raw ticks are kept around. Ranges that
+// start inside it are computed from the ticks instead of the market rows.
+const rawTickWindow = 1 * time.Minute
+
+// rollupDelay is how far the rollups may trail the clock: a window is written
+// to the market rows once its minute is over, and folded into the rollups by
+// the next run after the rollup lag. Buckets that end later than this are
+// read from finer resolutions.
+const rollupDelay = 3 * time.Minute
 
 type StorageAdapter struct {
 	cache      cache.Cache
@@ -20,24 +31,25 @@ type StorageAdapter struct {
 	repository DBRepository
 }
 
-func NewStorageAdapter(cache cache.Cache, fallback cache.Cache, repository DBRepository) *StorageAdapter {
+func NewStorageAdapter(primary cache.Cache, fallback cache.Cache, repository DBRepository) *StorageAdapter {
 	return &StorageAdapter{
-		cache:      cache,
-		fallback:   fallback,
-		repository: repository,
+		cache:      cache.Instrument("redis", primary),
+		fallback:   cache.Instrument("postgres", fallback),
+		repository: instrumented{next: repository},
 	}
 }
 
 func (s *StorageAdapter) GetAverage(ctx context.Context, arg Params) (float64, error) {
-	if arg.Interval <= 1*time.Minute {
-		data, err := s.getFromCache(ctx, arg.Exchange, arg.PairName, arg.Interval)
+	if inRawTickWindow(arg) {
+		data, err := s.getFromCache(ctx, arg)
 		if err != nil {
 			return 0, err
 		}
 
-		return data.AveragePrice, nil
+		return data.Average, nil
 	}
 
+	arg.Segments = planSegments(arg.From, arg.To, time.Now())
 	value, err := s.repository.GetAverage(ctx, arg)
 	if err != nil {
 		slog.Error("failed to get average from db", "error", err, "params", arg)
@@ -48,60 +60,237 @@ func (s *StorageAdapter) GetAverage(ctx context.Context, arg Params) (float64, e
 }
 
 func (s *StorageAdapter) GetMax(ctx context.Context, arg Params) (float64, error) {
-	if arg.Interval <= 1*time.Minute {
-		data, err := s.getFromCache(ctx, arg.Exchange, arg.PairName, arg.Interval)
+	if inRawTickWindow(arg) {
+		data, err := s.getFromCache(ctx, arg)
 		if err != nil {
 			return 0, err
 		}
 
-		return data.MaxPrice, nil
+		return data.Max, nil
 	}
+	arg.Segments = planSegments(arg.From, arg.To, time.Now())
 	return s.repository.GetMax(ctx, arg)
 }
 
 func (s *StorageAdapter) GetMin(ctx context.Context, arg Params) (float64, error) {
-	if arg.Interval <= 1*time.Minute {
-		if data, err := s.getFromCache(ctx, arg.Exchange, arg.PairName, arg.Interval); err == nil {
-			return data.MinPrice, nil
+	if inRawTickWindow(arg) {
+		if data, err := s.getFromCache(ctx, arg); err == nil {
+			return data.Min, nil
 		} else {
 			return 0, err
 		}
 	}
+	arg.Segments = planSegments(arg.From, arg.To, time.Now())
 	return s.repository.GetMin(ctx, arg)
 }
 
-func (s *StorageAdapter) GetLatest(ctx context.Context, exchange string, symbol string) (float64, error) {
+func (s *StorageAdapter) GetLatest(ctx context.Context, exchange string, symbol string) (model.Quote, error) {
 	data, err := s.cache.GetLatest(ctx, exchange, symbol)
 	if err != nil {
 		if errors.Is(err, redis.ErrNoConnection) {
+			cache.CountFallback("storage", "get_latest")
 			data, err = s.fallback.GetLatest(ctx, exchange, symbol)
+			if errors.Is(err, ErrNoData) {
+				return model.Quote{}, ErrNoData
+			}
 			if err != nil {
-				return 0, ErrNoData
+				return model.Quote{}, err
 			}
 			return data, nil
 		}
+		if errors.Is(err, redis.ErrNoData) {
+			return model.Quote{}, ErrNoData
+		}
 
-		return 0, err
+		return model.Quote{}, err
 	}
 
 	return data, nil
 }
 
+// GetLatestBatch returns the latest tick of every pair that has one.
+func (s *StorageAdapter) GetLatestBatch(ctx context.Context, pairs []model.Pair) ([]model.Quote, error) {
+	quotes, err := s.cache.GetLatestBatch(ctx, pairs)
+	if err != nil {
+		if errors.Is(err, redis.ErrNoConnection) {
+			cache.CountFallback("storage", "get_latest_batch")
+			return s.fallback.GetLatestBatch(ctx, pairs)
+		}
+		return nil, err
+	}
+
+	return quotes, nil
+}
+
 func (s *StorageAdapter) InsertMarket(ctx context.Context, arg InsertMarketParams) (model.AgregetedData, error) {
 	return s.repository.InsertMarket(ctx, arg)
 }
 
-func (s *StorageAdapter) getFromCache(ctx context.Context, exchanger string, symbol string, interval time.Duration) (model.AgregetedData, error) {
-	data, err := s.cache.GetRawData(ctx, exchanger, symbol, interval)
+func (s *StorageAdapter) GetCandles(ctx context.Context, arg CandleParams) ([]model.Candle, error) {
+	candles, err := s.repository.GetCandles(ctx, arg)
 	if err != nil {
-		data, err = s.fallback.GetRawData(ctx, exchanger, symbol, interval)
+		return nil, err
+	}
+
+	if len(candles) == 0 {
+		return nil, ErrNoData
+	}
+
+	return candles, nil
+}
+
+// GetHistory reads a page of rows straight from the database. An empty page
+// is not an error: it ends the history.
+func (s *StorageAdapter) GetHistory(ctx context.Context, arg HistoryParams) ([]model.HistoryPoint, error) {
+	return s.repository.GetHistory(ctx, arg)
+}
+
+// GetSummary computes every statistic of the period in a single pass over the
+// raw ticks or a single query against the market rows or rollups.
+func (s *StorageAdapter) GetSummary(ctx context.Context, arg Params) (model.Summary, error) {
+	if inRawTickWindow(arg) {
+		return s.getFromCache(ctx, arg)
+	}
+
+	arg.Segments = planSegments(arg.From, arg.To, time.Now())
+	return s.repository.GetSummary(ctx, arg)
+}
+
+// GetDistribution computes short periods from the raw ticks and longer ones
+// from the market rows or the coarsest rollup that fits the period.
+func (s *StorageAdapter) GetDistribution(ctx context.Context, arg Params) (model.Distribution, error) {
+	if inRawTickWindow(arg) {
+		data, err := s.getRawTicks(ctx, arg)
 		if err != nil {
-			return model.AgregetedData{}, err
+			return model.Distribution{}, err
+		}
+
+		return tickDistribution(data), nil
+	}
+
+	arg.Segments = planSegments(arg.From, arg.To, time.Now())
+	return s.repository.GetDistribution(ctx, arg)
+}
+
+func tickDistribution(data []model.Trade) model.Distribution {
+	prices := make([]float64, len(data))
+	var sum float64
+	for i, trade := range data {
+		prices[i] = trade.Price
+		sum += trade.Price
+	}
+	mean := sum / float64(len(prices))
+
+	var variance, squaredReturns float64
+	for i, p := range prices {
+		variance += (p - mean) * (p - mean)
+		if i > 0 && prices[i-1] > 0 && p > 0 {
+			r := math.Log(p / prices[i-1])
+			squaredReturns += r * r
 		}
 	}
 
-	if len(data) == 0 {
-		return model.AgregetedData{}, ErrNoData
+	slices.Sort(prices)
+
+	return model.Distribution{
+		Source:     "ticks",
+		Samples:    int64(len(prices)),
+		TickCount:  int64(len(prices)),
+		StdDev:     math.Sqrt(variance / float64(len(prices))),
+		Volatility: math.Sqrt(squaredReturns),
+		P5:         percentile(prices, 0.05),
+		P50:        percentile(prices, 0.50),
+		P95:        percentile(prices, 0.95),
+	}
+}
+
+// percentile interpolates linearly between the closest ranks of sorted, like
+// percentile_cont in Postgres.
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+func inRawTickWindow(arg Params) bool {
+	return time.Since(arg.From) <= rawTickWindow
+}
+
+// planSegments reads as much of the range as it can from the widest rollup,
+// whichever period it spans. Only the buckets that lie entirely inside the
+// range, and that the rollups have caught up with by now, are read from the
+// rollup; the parts before and after them are planned the same way with the
+// next finer resolution, down to the market rows.
+func planSegments(from, to, now time.Time) []Segment {
+	return plan(from, to, now.Add(-rollupDelay), len(model.Resolutions)-1)
+}
+
+func plan(from, to, settled time.Time, level int) []Segment {
+	if !from.Before(to) {
+		return nil
+	}
+
+	for ; level >= 0; level-- {
+		res := model.Resolutions[level]
+		start := ceilTime(from, res.Step)
+		end := to
+		if settled.Before(end) {
+			end = settled
+		}
+		end = end.Truncate(res.Step)
+		if !start.Before(end) {
+			continue
+		}
+
+		segments := plan(from, start, settled, level-1)
+		segments = append(segments, Segment{Resolution: res, From: start, To: end})
+		return append(segments, plan(end, to, settled, level-1)...)
+	}
+
+	return []Segment{{Resolution: model.MarketResolution, From: from, To: to}}
+}
+
+// ceilTime rounds t up to a multiple of d.
+func ceilTime(t time.Time, d time.Duration) time.Time {
+	if c := t.Truncate(d); c.Before(t) {
+		return c.Add(d)
+	}
+	return t
+}
+
+// getRawTicks returns the raw ticks in the range, which has to start inside
+// the raw tick window.
+func (s *StorageAdapter) getRawTicks(ctx context.Context, arg Params) ([]model.Trade, error) {
+	interval := time.Since(arg.From)
+	data, err := s.cache.GetRawData(ctx, arg.Exchange, arg.PairName, interval)
+	if err != nil {
+		cache.CountFallback("storage", "get_raw_data")
+		data, err = s.fallback.GetRawData(ctx, arg.Exchange, arg.PairName, interval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ticks := data[:0]
+	for _, trade := range data {
+		if trade.Timestamp > arg.To.UnixMilli() {
+			continue
+		}
+		ticks = append(ticks, trade)
+	}
+
+	if len(ticks) == 0 {
+		return nil, ErrNoData
+	}
+
+	return ticks, nil
+}
+
+func (s *StorageAdapter) getFromCache(ctx context.Context, arg Params) (model.Summary, error) {
+	data, err := s.getRawTicks(ctx, arg)
+	if err != nil {
+		return model.Summary{}, err
 	}
 
 	var sum float64
@@ -118,11 +307,16 @@ func (s *StorageAdapter) getFromCache(ctx context.Context, exchanger string, sym
 	}
 	avg := sum / float64(len(data))
 
-	return model.AgregetedData{
-		PairName:     symbol,
-		Exchange:     exchanger,
-		AveragePrice: avg,
-		MinPrice:     min,
-		MaxPrice:     max,
+	first, last := data[0], data[len(data)-1]
+
+	return model.Summary{
+		Start:     time.UnixMilli(first.Timestamp),
+		End:       time.UnixMilli(last.Timestamp),
+		Min:       min,
+		Max:       max,
+		Average:   avg,
+		First:     first.Price,
+		Last:      last.Price,
+		TickCount: int64(len(data)),
 	}, nil
 }
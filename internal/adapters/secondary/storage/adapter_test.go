@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{0, 1},
+		{0.5, 3},
+		{1, 5},
+		{0.1, 1.4},
+		{0.95, 4.8},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("p%v: expected %v, got %v", tt.p*100, tt.want, got)
+		}
+	}
+
+	if got := percentile([]float64{7}, 0.95); got != 7 {
+		t.Errorf("expected a single price to be every percentile, got %v", got)
+	}
+}
+
+func TestTickDistribution(t *testing.T) {
+	trades := []model.Trade{{Price: 100}, {Price: 110}, {Price: 100}, {Price: 90}}
+	dist := tickDistribution(trades)
+
+	if dist.Source != "ticks" || dist.Approximate || dist.Samples != 4 || dist.TickCount != 4 {
+		t.Errorf("unexpected header %+v", dist)
+	}
+	if math.Abs(dist.StdDev-math.Sqrt(50)) > 1e-9 {
+		t.Errorf("expected the population standard deviation %v, got %v", math.Sqrt(50), dist.StdDev)
+	}
+
+	var squared float64
+	for _, r := range []float64{math.Log(1.1), math.Log(100.0 / 110), math.Log(0.9)} {
+		squared += r * r
+	}
+	if math.Abs(dist.Volatility-math.Sqrt(squared)) > 1e-12 {
+		t.Errorf("expected a realized volatility of %v, got %v", math.Sqrt(squared), dist.Volatility)
+	}
+	if dist.P50 != 100 || math.Abs(dist.P5-91.5) > 1e-9 || math.Abs(dist.P95-108.5) > 1e-9 {
+		t.Errorf("unexpected percentiles %v, %v, %v", dist.P5, dist.P50, dist.P95)
+	}
+	if trades[1].Price != 110 {
+		t.Error("expected the ticks to be left in their order")
+	}
+}
+
+func TestPlanSegments(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fiveMinutes, hour := model.Resolutions[0], model.Resolutions[1]
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+	later := day.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []Segment
+	}{
+		{
+			name: "aligned",
+			from: at(0, 0), to: at(6, 0),
+			want: []Segment{{Resolution: hour, From: at(0, 0), To: at(6, 0)}},
+		},
+		{
+			name: "unaligned edges come from finer resolutions",
+			from: at(0, 7), to: at(2, 17),
+			want: []Segment{
+				{Resolution: model.MarketResolution, From: at(0, 7), To: at(0, 10)},
+				{Resolution: fiveMinutes, From: at(0, 10), To: at(1, 0)},
+				{Resolution: hour, From: at(1, 0), To: at(2, 0)},
+				{Resolution: fiveMinutes, From: at(2, 0), To: at(2, 15)},
+				{Resolution: model.MarketResolution, From: at(2, 15), To: at(2, 17)},
+			},
+		},
+		{
+			name: "shorter than a bucket",
+			from: at(0, 1), to: at(0, 4),
+			want: []Segment{{Resolution: model.MarketResolution, From: at(0, 1), To: at(0, 4)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planSegments(tt.from, tt.to, later); !slices.Equal(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+// A calendar period ends now, so it never divides evenly into buckets; it is
+// still read from the rollups but for the last minutes.
+func TestPlanSegments_ThisMonth(t *testing.T) {
+	fiveMinutes, hour, day := model.Resolutions[0], model.Resolutions[1], model.Resolutions[2]
+	now := time.Date(2024, 3, 17, 15, 42, 10, 0, time.UTC)
+	monthStart := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)
+
+	want := []Segment{
+		{Resolution: day, From: monthStart, To: today},
+		{Resolution: hour, From: today, To: today.Add(15 * time.Hour)},
+		{Resolution: fiveMinutes, From: today.Add(15 * time.Hour), To: today.Add(15*time.Hour + 35*time.Minute)},
+		{Resolution: model.MarketResolution, From: today.Add(15*time.Hour + 35*time.Minute), To: now},
+	}
+	if got := planSegments(monthStart, now, now); !slices.Equal(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	// In a time zone ahead of UTC the month starts an hour before a day
+	// bucket does; that hour comes from the hour rollup.
+	zone := time.FixedZone("UTC+1", 60*60)
+	got := planSegments(time.Date(2024, 3, 1, 0, 0, 0, 0, zone), now, now)
+	if len(got) != 5 || got[0].Resolution != hour || !got[0].To.Equal(monthStart) || got[1].Resolution != day {
+		t.Errorf("expected the first hour from the hour rollup, got %+v", got)
+	}
+}
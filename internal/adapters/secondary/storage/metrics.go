@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"marketflow/internal/adapters/secondary/cache"
+	"marketflow/internal/core/model"
+)
+
+// observe records a call to Postgres in the metrics of the cache adapter.
+func observe(operation string, start time.Time, err error) {
+	cache.Observe("postgres", operation, start, err)
+}
+
+// instrumented times every call to a DBRepository.
+type instrumented struct {
+	next DBRepository
+}
+
+func (i instrumented) GetAverage(ctx context.Context, arg Params) (float64, error) {
+	start := time.Now()
+	v, err := i.next.GetAverage(ctx, arg)
+	observe("get_average", start, err)
+	return v, err
+}
+
+func (i instrumented) GetMax(ctx context.Context, arg Params) (float64, error) {
+	start := time.Now()
+	v, err := i.next.GetMax(ctx, arg)
+	observe("get_max", start, err)
+	return v, err
+}
+
+func (i instrumented) GetMin(ctx context.Context, arg Params) (float64, error) {
+	start := time.Now()
+	v, err := i.next.GetMin(ctx, arg)
+	observe("get_min", start, err)
+	return v, err
+}
+
+func (i instrumented) InsertMarket(ctx context.Context, arg InsertMarketParams) (model.AgregetedData, error) {
+	start := time.Now()
+	v, err := i.next.InsertMarket(ctx, arg)
+	observe("insert_market", start, err)
+	return v, err
+}
+
+func (i instrumented) GetCandles(ctx context.Context, arg CandleParams) ([]model.Candle, error) {
+	start := time.Now()
+	v, err := i.next.GetCandles(ctx, arg)
+	observe("get_candles", start, err)
+	return v, err
+}
+
+func (i instrumented) GetHistory(ctx context.Context, arg HistoryParams) ([]model.HistoryPoint, error) {
+	start := time.Now()
+	v, err := i.next.GetHistory(ctx, arg)
+	observe("get_history", start, err)
+	return v, err
+}
+
+func (i instrumented) GetDistribution(ctx context.Context, arg Params) (model.Distribution, error) {
+	start := time.Now()
+	v, err := i.next.GetDistribution(ctx, arg)
+	observe("get_distribution", start, err)
+	return v, err
+}
+
+func (i instrumented) GetSummary(ctx context.Context, arg Params) (model.Summary, error) {
+	start := time.Now()
+	v, err := i.next.GetSummary(ctx, arg)
+	observe("get_summary", start, err)
+	return v, err
+}
@@ -7,18 +7,58 @@ import (
 	"marketflow/internal/core/model"
 )
 
+// Params selects the data of one pair on one exchange in [From, To).
 type Params struct {
 	PairName string
 	Exchange string
-	Interval time.Duration
+	From     time.Time
+	To       time.Time
+	// Segments split [From, To) into the parts that are read from the market
+	// rows and from the rollups. Empty reads the whole range from the market
+	// rows.
+	Segments []Segment
+}
+
+// Segment is the part of a range that is read from one resolution. The
+// bounds of a rollup segment are multiples of its step, so that it only
+// covers whole buckets.
+type Segment struct {
+	Resolution model.Resolution
+	From       time.Time
+	To         time.Time
 }
 
 type InsertMarketParams struct {
 	PairName     string
 	Exchange     string
+	Timestamp    time.Time
 	AveragePrice float64
 	MinPrice     float64
 	MaxPrice     float64
+	FirstPrice   float64
+	LastPrice    float64
+	TickCount    int64
+}
+
+// CandleParams selects the Limit most recent candles of one resolution.
+type CandleParams struct {
+	PairName   string
+	Exchange   string
+	Resolution string
+	Limit      int
+}
+
+// HistoryParams selects up to Limit rows of one resolution in [From, To),
+// oldest first, that come after the row at After. A zero After starts at
+// From.
+type HistoryParams struct {
+	PairName   string
+	Exchange   string
+	Resolution string
+	From       time.Time
+	To         time.Time
+	After      time.Time
+	Limit      int
 }
 
 type DBRepository interface {
@@ -26,4 +66,8 @@ type DBRepository interface {
 	GetMax(ctx context.Context, arg Params) (float64, error)
 	GetMin(ctx context.Context, arg Params) (float64, error)
 	InsertMarket(ctx context.Context, arg InsertMarketParams) (model.AgregetedData, error)
+	GetCandles(ctx context.Context, arg CandleParams) ([]model.Candle, error)
+	GetHistory(ctx context.Context, arg HistoryParams) ([]model.HistoryPoint, error)
+	GetDistribution(ctx context.Context, arg Params) (model.Distribution, error)
+	GetSummary(ctx context.Context, arg Params) (model.Summary, error)
 }
@@ -15,11 +15,13 @@ import (
 	"marketflow/infrastucture/postgres"
 	iredis "marketflow/infrastucture/redis"
 	"marketflow/internal/adapters/primary/exchanger"
+	"marketflow/internal/adapters/primary/rpc"
 	"marketflow/internal/adapters/primary/ui"
 	"marketflow/internal/adapters/primary/ui/handlers"
 	"marketflow/internal/adapters/primary/ui/middleware"
 	"marketflow/internal/adapters/secondary/cache"
 	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core/model"
 	"marketflow/internal/core/service"
 
 	"marketflow/pkg/conc"
@@ -33,8 +35,9 @@ type App struct {
 	mode   string
 	modeMu *sync.Mutex
 
-	server *ui.Server
-	redis  *iredis.Queries
+	server    *ui.Server
+	rpcServer *rpc.Server
+	redis     *iredis.Queries
 
 	postgres *postgres.Client
 	repo     *postgres.Queries
@@ -49,7 +52,18 @@ type App struct {
 
 	tradeHandler *service.TradeHandler
 	aggregator   *service.Aggregator
+	rollup       *service.Rollup
+	retention    *service.Retention
+	consensus    *service.Consensus
+	spread       *service.SpreadMonitor
+	indicators   *service.Indicators
+	symbols      *service.Symbols
+	alerts       *service.Alerts
+	webhooks     *service.Webhooks
+	broadcaster  *service.Broadcaster
 	stats        *service.Stats
+	auth         *service.Auth
+	limiter      *service.RateLimiter
 
 	storageAdapter *storage.StorageAdapter
 	cacheAdapter   *cache.CacheAdapter
@@ -76,21 +90,83 @@ func (a *App) di() error {
 	a.cacheAdapter = cache.NewCacheAdapter(a.redis, a.repo)
 
 	a.aggregator = service.NewAggregator(a.cacheAdapter, a.storageAdapter)
-	a.tradeHandler = service.NewTradeHandler(a.cacheAdapter)
+	a.rollup = service.NewRollup(a.repo)
+	a.retention = service.NewRetention(a.repo, a.config.retention)
+	a.consensus = service.NewConsensus(a.config.consensus)
+	a.symbols = service.NewSymbols(a.repo)
+	a.broadcaster = service.NewBroadcaster()
+	a.alerts = service.NewAlerts(a.repo)
+	a.tradeHandler = service.NewTradeHandler(a.cacheAdapter, a.aggregator, a.consensus, a.symbols, a.broadcaster, a.alerts)
 	a.stats = service.NewStats(a.storageAdapter)
+	a.spread = service.NewSpreadMonitor(a.storageAdapter, a.cacheAdapter, a.config.spread)
+	a.indicators = service.NewIndicators(a.storageAdapter, a.symbols)
+	a.aggregator.OnFinalized(a.indicators.Observe)
+	a.aggregator.OnFinalized(a.broadcaster.PublishCandle)
+	a.aggregator.OnFinalized(a.alerts.ObserveCandle)
+	a.consensus.OnPrice(a.broadcaster.PublishConsensus)
+	a.spread.OnDivergence(a.broadcaster.PublishDivergence)
+	a.alerts.OnAlert(a.broadcaster.PublishAlert)
+	a.webhooks = service.NewWebhooks(a.repo, a.broadcaster, handlers.EventData)
+
+	a.auth = service.NewAuth(a.repo)
+	a.limiter = service.NewRateLimiter(a.redis, a.config.rateLimits)
 
 	a.handler = handlers.NewHandler(a.stats)
-	routes := ui.RegisterRoutes(a.handler)
-	a.server = ui.NewServer(a.serverConfig, routes)
-	routesWithLogger := middleware.Logger(routes)
-	a.server = ui.NewServer(a.serverConfig, routesWithLogger)
+	handlers.WithAuth(a.auth, a.handler)
+	handlers.WithRateLimiter(a.limiter, a.handler)
+	handlers.WithRetention(a.retention, a.handler)
+	handlers.WithConsensus(a.consensus, a.handler)
+	handlers.WithSpreadMonitor(a.spread, a.handler)
+	handlers.WithIndicators(a.indicators, a.handler)
+	handlers.WithSymbols(a.symbols, a.handler)
+	handlers.WithAlerts(a.alerts, a.handler)
+	handlers.WithWebhooks(a.webhooks, a.handler)
+	handlers.WithBroadcaster(a.broadcaster, a.handler)
+	handlers.WithLocation(a.config.location, a.handler)
+	handlers.WithStaleAfter(a.config.staleAfter, a.handler)
+	opts := ui.Options{
+		Auth:          a.auth,
+		AnonymousRead: a.config.anonymousRead,
+		Limiter:       a.limiter,
+	}
+	if a.config.responseCacheMB > 0 {
+		opts.Cache = middleware.NewResponseCache(a.config.responseCacheMB << 20)
+	}
+	routes, err := ui.RegisterRoutes(a.handler, opts)
+	if err != nil {
+		return fmt.Errorf("register routes: %w", err)
+	}
+	chain := middleware.NewChainMiddleware(
+		middleware.RequestID,
+		middleware.Logger,
+		middleware.CORS(a.config.corsOrigins),
+		middleware.Compress,
+		middleware.ETag,
+	)
+	a.server = ui.NewServer(a.serverConfig, chain(routes))
+
+	rpcConfig, err := rpc.NewServerConfig(a.config.grpcPort)
+	if err != nil {
+		return fmt.Errorf("invalid GRPC_PORT: %w", err)
+	}
+	a.rpcServer = rpc.NewServer(rpcConfig, a.stats)
+	rpc.WithBroadcaster(a.broadcaster, a.rpcServer)
+	rpc.WithLocation(a.config.location, a.rpcServer)
+	rpc.WithStaleAfter(a.config.staleAfter, a.rpcServer)
+	rpc.WithAuth(a.auth, a.rpcServer)
+	rpc.WithAnonymousRead(a.config.anonymousRead, a.rpcServer)
+	rpc.WithRateLimiter(a.limiter, a.rpcServer)
 
 	return nil
 }
 
 func (a *App) Run(ctx context.Context) error {
 	var err error
-	conf, _ := LoadConfig()
+	conf, err := LoadConfig()
+	if err != nil {
+		slog.Error("invalid configuration", "error", err)
+		return err
+	}
 	a.config = *conf
 
 	if err = a.initRedis(ctx); err != nil {
@@ -105,6 +181,13 @@ func (a *App) Run(ctx context.Context) error {
 		return err
 	}
 
+	if a.config.adminAPIKey != "" {
+		if err = a.auth.Bootstrap(ctx, a.config.adminAPIKey); err != nil {
+			slog.Error("failed to set up the admin api key", "error", err)
+			return err
+		}
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -126,6 +209,17 @@ func (a *App) Run(ctx context.Context) error {
 
 	handlers.WithHealthCheck(a.HealthCheck(), a.handler)
 
+	rpc.WithTestModeSwitch(a.SwitchToTest(ctx), a.rpcServer)
+	rpc.WithLiveModeSwitch(a.SwitchToLive(ctx), a.rpcServer)
+
+	// Rebuild the windows that were open when the last process stopped
+	// before the worker pools start feeding the aggregator.
+	recoverCtx, recoverCancel := context.WithTimeout(ctx, 5*time.Second)
+	if err := a.aggregator.Recover(recoverCtx); err != nil {
+		slog.Error("aggregator recovery error", "error", err)
+	}
+	recoverCancel()
+
 	for i := range a.fanoutChannels {
 		a.fanoutChannels[i] = make(chan conc.Task)
 		a.workerChannels[i] = make(chan conc.Result)
@@ -182,6 +276,60 @@ func (a *App) Run(ctx context.Context) error {
 		return nil
 	})
 
+	// Start rollup scheduler
+	g.Go(func() error {
+		if err := a.rollup.Start(gCtx, service.RollupTicker); err != nil {
+			slog.Error("rollup error", "error", err)
+			return err
+		}
+		return nil
+	})
+
+	// Start retention job
+	g.Go(func() error {
+		if err := a.retention.Start(gCtx, a.config.retentionInterval); err != nil {
+			slog.Error("retention error", "error", err)
+			return err
+		}
+		return nil
+	})
+
+	// Start spread monitor
+	g.Go(func() error {
+		if err := a.spread.Start(gCtx, service.SpreadTicker); err != nil {
+			slog.Error("spread monitor error", "error", err)
+			return err
+		}
+		return nil
+	})
+
+	// Start symbols registry
+	g.Go(func() error {
+		if err := a.symbols.Start(gCtx, service.SymbolsTicker); err != nil {
+			slog.Error("symbols registry error", "error", err)
+			return err
+		}
+		return nil
+	})
+
+	// Start alert rules engine
+	g.Go(func() error {
+		if err := a.alerts.Start(gCtx, service.AlertsTicker); err != nil {
+			slog.Error("alerts error", "error", err)
+			return err
+		}
+		return nil
+	})
+
+	// Start webhook delivery
+	g.Go(func() error {
+		if err := a.webhooks.Start(gCtx, service.WebhooksTicker); err != nil {
+			slog.Error("webhooks error", "error", err)
+			return err
+		}
+		return nil
+	})
+
 	// Start HTTP server
 	g.Go(func() error {
 		slog.Info("starting server on port: " + a.serverConfig.Port)
@@ -194,6 +342,18 @@ func (a *App) Run(ctx context.Context) error {
 		return nil
 	})
 
+	// Start gRPC server
+	g.Go(func() error {
+		slog.Info("starting gRPC server on port: " + a.config.grpcPort)
+		if err := a.rpcServer.Start(); err != nil {
+			slog.Error("gRPC server error", "error", err)
+			cancel()
+			return err
+		}
+
+		return nil
+	})
+
 	// Graceful Shutdown
 	g.Go(func() error {
 		c := make(chan os.Signal, 1)
@@ -219,12 +379,21 @@ func (a *App) Run(ctx context.Context) error {
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer shutdownCancel()
 
+		// Streams never go idle on their own, so end them before the
+		// server waits for open connections.
+		a.broadcaster.Close()
+
 		slog.Info("shutting down server...")
 		if err := a.server.Shutdown(shutdownCtx); err != nil {
 			slog.Error("server shutdown error", "error", err)
 			return err
 		}
 
+		slog.Info("shutting down gRPC server...")
+		if err := a.rpcServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("gRPC server shutdown error", "error", err)
+		}
+
 		a.redis.Close()
 		a.postgres.Close()
 
@@ -285,7 +454,7 @@ func (a *App) handleTasks(id int, numOfWorkers int, taskChan chan conc.Task, res
 	}(id, numOfWorkers, taskChan, resultChan)
 }
 
-var ErrAlreadyInLiveMode = fmt.Errorf("already in live mode")
+var ErrAlreadyInLiveMode = model.NewError(model.KindConflict, "already_in_live_mode", "already in live mode")
 
 func (a *App) SwitchToLive(ctx context.Context) func() error {
 	return func() error {
@@ -318,7 +487,7 @@ func (a *App) SwitchToLive(ctx context.Context) func() error {
 	}
 }
 
-var ErrAlreadyInTestMode = fmt.Errorf("already in test mode")
+var ErrAlreadyInTestMode = model.NewError(model.KindConflict, "already_in_test_mode", "already in test mode")
 
 func (a *App) SwitchToTest(ctx context.Context) func() error {
 	return func() error {
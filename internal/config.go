@@ -1,10 +1,17 @@
 package internal
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"marketflow/infrastucture/postgres"
 	"marketflow/infrastucture/redis"
+	"marketflow/internal/core/model"
+	"marketflow/internal/core/service"
 )
 
 type config struct {
@@ -13,6 +20,33 @@ type config struct {
 	exchange1 string
 	exchange2 string
 	exchange3 string
+
+	retentionInterval time.Duration
+	retention         []model.RetentionPolicy
+
+	consensus service.ConsensusConfig
+	spread    service.SpreadConfig
+
+	// location is the time zone calendar periods are aligned to.
+	location *time.Location
+	// staleAfter is how old the latest tick may be before it is flagged.
+	staleAfter time.Duration
+
+	grpcPort string
+
+	// adminAPIKey is made an admin key at startup, so the first keys can be
+	// issued.
+	adminAPIKey string
+	// anonymousRead opens the read-only routes to requests without a key.
+	anonymousRead bool
+	// corsOrigins may call the API from a browser; "*" allows any origin.
+	corsOrigins []string
+
+	rateLimits map[string]model.RateLimit
+
+	// responseCacheMB bounds the bodies the response cache keeps; 0 turns it
+	// off.
+	responseCacheMB int
 }
 
 func LoadConfig() (*config, error) {
@@ -21,12 +55,215 @@ func LoadConfig() (*config, error) {
 	exchanger1Host := getEnv("EXCHANGE1_HOST", "localhost")
 	exchanger2Host := getEnv("EXCHANGE2_HOST", "localhost")
 	exchanger3Host := getEnv("EXCHANGE3_HOST", "localhost")
+
+	retentionInterval, err := getEnvDuration("RETENTION_INTERVAL", "30s")
+	if err != nil {
+		return nil, err
+	}
+
+	retention, err := loadRetentionPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	consensus, err := loadConsensusConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	spread, err := loadSpreadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := time.LoadLocation(getEnv("TIMEZONE", "UTC"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TIMEZONE: %w", err)
+	}
+
+	staleAfter, err := getEnvDuration("STALE_AFTER", service.DefaultStaleAfter.String())
+	if err != nil {
+		return nil, err
+	}
+
+	anonymousRead, err := getEnvBool("ALLOW_ANONYMOUS_READ", "false")
+	if err != nil {
+		return nil, err
+	}
+
+	adminAPIKey := getEnv("ADMIN_API_KEY", "")
+	if adminAPIKey != "" && len(adminAPIKey) < 32 {
+		return nil, errors.New("invalid ADMIN_API_KEY: must be at least 32 characters")
+	}
+
+	rateLimits, err := loadRateLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	responseCacheMB, err := strconv.Atoi(getEnv("RESPONSE_CACHE_MB", "64"))
+	if err != nil || responseCacheMB < 0 {
+		return nil, errors.New("invalid RESPONSE_CACHE_MB: must be a number of megabytes")
+	}
+
 	return &config{
-		postgres:  postgresConfig,
-		redis:     redisConfig,
-		exchange1: exchanger1Host,
-		exchange2: exchanger2Host,
-		exchange3: exchanger3Host,
+		postgres:          postgresConfig,
+		redis:             redisConfig,
+		exchange1:         exchanger1Host,
+		exchange2:         exchanger2Host,
+		exchange3:         exchanger3Host,
+		retentionInterval: retentionInterval,
+		retention:         retention,
+		consensus:         consensus,
+		spread:            spread,
+		location:          location,
+		staleAfter:        staleAfter,
+		grpcPort:          getEnv("GRPC_PORT", "50051"),
+		adminAPIKey:       adminAPIKey,
+		anonymousRead:     anonymousRead,
+		corsOrigins:       getEnvList("CORS_ALLOWED_ORIGINS", "*"),
+		rateLimits:        rateLimits,
+		responseCacheMB:   responseCacheMB,
+	}, nil
+}
+
+// loadRetentionPolicies reads how long each table and rollup resolution is
+// kept. A value of 0 keeps the rows forever.
+func loadRetentionPolicies() ([]model.RetentionPolicy, error) {
+	defaults := []struct {
+		env    string
+		policy model.RetentionPolicy
+		maxAge string
+	}{
+		{"RETENTION_RAW_DATA", model.RetentionPolicy{Table: "raw_data"}, "2m"},
+		{"RETENTION_MARKET", model.RetentionPolicy{Table: "market"}, "168h"},
+		{"RETENTION_ROLLUP_5M", model.RetentionPolicy{Table: "market_rollup", Resolution: "5m"}, "720h"},
+		{"RETENTION_ROLLUP_1H", model.RetentionPolicy{Table: "market_rollup", Resolution: "1h"}, "8760h"},
+		{"RETENTION_ROLLUP_1D", model.RetentionPolicy{Table: "market_rollup", Resolution: "1d"}, "0"},
+		{"RETENTION_ALERT_EVENTS", model.RetentionPolicy{Table: "alert_events"}, "2160h"},
+		{"RETENTION_WEBHOOK_DELIVERIES", model.RetentionPolicy{Table: "webhook_deliveries"}, "720h"},
+	}
+
+	policies := make([]model.RetentionPolicy, 0, len(defaults))
+	for _, d := range defaults {
+		maxAge, err := getEnvDuration(d.env, d.maxAge)
+		if err != nil {
+			return nil, err
+		}
+		d.policy.MaxAge = maxAge
+		policies = append(policies, d.policy)
+	}
+
+	return policies, nil
+}
+
+// loadRateLimits reads the limit of every route group from
+// RATE_LIMIT_<GROUP>, such as "20/1s" or "600/1m", and its burst from
+// RATE_LIMIT_<GROUP>_BURST, which defaults to the number of requests. A
+// limit of 0 turns limiting off for the group.
+func loadRateLimits() (map[string]model.RateLimit, error) {
+	limits := make(map[string]model.RateLimit, len(service.RateGroups))
+	for _, group := range service.RateGroups {
+		env := "RATE_LIMIT_" + strings.ToUpper(group)
+		def := service.DefaultRateLimits[group]
+
+		raw := getEnv(env, fmt.Sprintf("%d/%s", def.Requests, def.Per))
+		if raw == "0" {
+			limits[group] = model.RateLimit{}
+			continue
+		}
+
+		n, per, ok := strings.Cut(raw, "/")
+		requests, err := strconv.Atoi(n)
+		if !ok || err != nil || requests <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must look like 20/1s", env, raw)
+		}
+		window, err := time.ParseDuration(per)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must look like 20/1s", env, raw)
+		}
+
+		burstDef := strconv.Itoa(requests)
+		if _, set := os.LookupEnv(env); !set {
+			burstDef = strconv.Itoa(def.Burst)
+		}
+		burst, err := strconv.Atoi(getEnv(env+"_BURST", burstDef))
+		if err != nil || burst <= 0 {
+			return nil, fmt.Errorf("invalid %s_BURST: must be a positive number", env)
+		}
+
+		limits[group] = model.RateLimit{Requests: requests, Per: window, Burst: burst}
+	}
+	return limits, nil
+}
+
+func loadConsensusConfig() (service.ConsensusConfig, error) {
+	maxAge, err := getEnvDuration("CONSENSUS_MAX_AGE", "10s")
+	if err != nil {
+		return service.ConsensusConfig{}, err
+	}
+
+	maxDeviation, err := getEnvFloat("CONSENSUS_MAX_DEVIATION_BPS", "100")
+	if err != nil {
+		return service.ConsensusConfig{}, err
+	}
+
+	trim, err := getEnvFloat("CONSENSUS_TRIM_FRACTION", "0.2")
+	if err != nil {
+		return service.ConsensusConfig{}, err
+	}
+
+	// CONSENSUS_WEIGHTS looks like "exchange1=2,exchange2=1,exchange3=0.5".
+	weights := make(map[string]float64)
+	if raw := getEnv("CONSENSUS_WEIGHTS", ""); raw != "" {
+		for _, pair := range strings.Split(raw, ",") {
+			name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return service.ConsensusConfig{}, fmt.Errorf("invalid CONSENSUS_WEIGHTS entry %q", pair)
+			}
+			w, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return service.ConsensusConfig{}, fmt.Errorf("invalid CONSENSUS_WEIGHTS entry %q: %w", pair, err)
+			}
+			weights[name] = w
+		}
+	}
+
+	cfg := service.ConsensusConfig{
+		Method:          getEnv("CONSENSUS_METHOD", service.ConsensusMedian),
+		Weights:         weights,
+		MaxAge:          maxAge,
+		MaxDeviationBps: maxDeviation,
+		TrimFraction:    trim,
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return service.ConsensusConfig{}, fmt.Errorf("invalid consensus config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func loadSpreadConfig() (service.SpreadConfig, error) {
+	threshold, err := getEnvFloat("SPREAD_THRESHOLD_BPS", "50")
+	if err != nil {
+		return service.SpreadConfig{}, err
+	}
+
+	minDuration, err := getEnvDuration("SPREAD_MIN_DURATION", "5s")
+	if err != nil {
+		return service.SpreadConfig{}, err
+	}
+
+	maxQuoteAge, err := getEnvDuration("SPREAD_MAX_QUOTE_AGE", "30s")
+	if err != nil {
+		return service.SpreadConfig{}, err
+	}
+
+	return service.SpreadConfig{
+		ThresholdBps: threshold,
+		MinDuration:  minDuration,
+		MaxQuoteAge:  maxQuoteAge,
 	}, nil
 }
 
@@ -36,3 +273,38 @@ func getEnv(key, def string) string {
 	}
 	return def
 }
+
+func getEnvDuration(key, def string) (time.Duration, error) {
+	d, err := time.ParseDuration(getEnv(key, def))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+func getEnvBool(key, def string) (bool, error) {
+	b, err := strconv.ParseBool(getEnv(key, def))
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// getEnvList reads a comma-separated list.
+func getEnvList(key, def string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, def), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvFloat(key, def string) (float64, error) {
+	f, err := strconv.ParseFloat(getEnv(key, def), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,101 @@
+package model
+
+import "errors"
+
+// ErrorKind tells a client how to react to an error.
+type ErrorKind int
+
+const (
+	// KindInternal is any error that is not a domain error.
+	KindInternal ErrorKind = iota
+	// KindInvalid means the request itself is wrong and must not be retried
+	// as is.
+	KindInvalid
+	// KindNotFound means there is no data for the request.
+	KindNotFound
+	// KindConflict means the request clashes with the current state.
+	KindConflict
+	// KindUnavailable means a backend is down or the data is too old; the
+	// request may succeed later.
+	KindUnavailable
+	// KindUnauthenticated means the request carries no valid credentials.
+	KindUnauthenticated
+	// KindForbidden means the credentials do not allow the request.
+	KindForbidden
+	// KindRateLimited means the client sent too many requests and has to
+	// wait before retrying.
+	KindRateLimited
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindInvalid:
+		return "invalid"
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindUnavailable:
+		return "unavailable"
+	case KindUnauthenticated:
+		return "unauthenticated"
+	case KindForbidden:
+		return "forbidden"
+	case KindRateLimited:
+		return "rate_limited"
+	}
+	return "internal"
+}
+
+// Error is a domain error with a stable, machine-readable code. Errors with
+// the same code match with errors.Is, so a sentinel still matches after
+// details were added to it.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Details map[string]any
+}
+
+func NewError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetails returns a copy of e carrying details in addition to its own.
+func (e *Error) WithDetails(details map[string]any) *Error {
+	c := *e
+	c.Details = make(map[string]any, len(e.Details)+len(details))
+	for k, v := range e.Details {
+		c.Details[k] = v
+	}
+	for k, v := range details {
+		c.Details[k] = v
+	}
+	return &c
+}
+
+// AsError finds the first domain error in err's chain.
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// KindOf returns the kind of the first domain error in err's chain.
+func KindOf(err error) ErrorKind {
+	if e, ok := AsError(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError_Is(t *testing.T) {
+	sentinel := NewError(KindNotFound, "no_data", "no data found")
+	detailed := sentinel.WithDetails(map[string]any{"symbol": BTCUSDT})
+	err := fmt.Errorf("get latest: %w", detailed)
+
+	if !errors.Is(err, sentinel) {
+		t.Error("expected a sentinel with details to match the sentinel")
+	}
+	if errors.Is(err, NewError(KindNotFound, "no_spread", "no spread")) {
+		t.Error("expected errors with different codes not to match")
+	}
+	if sentinel.Details != nil {
+		t.Errorf("expected the sentinel to stay untouched, got %v", sentinel.Details)
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorKind
+	}{
+		{NewError(KindInvalid, "invalid_period", "invalid period"), KindInvalid},
+		{fmt.Errorf("wrapped: %w", NewError(KindUnavailable, "cache_unavailable", "down")), KindUnavailable},
+		{errors.New("plain"), KindInternal},
+	}
+
+	for _, tt := range tests {
+		if got := KindOf(tt.err); got != tt.want {
+			t.Errorf("KindOf(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
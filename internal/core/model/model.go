@@ -1,6 +1,8 @@
 package model
 
 import (
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,14 +16,27 @@ type AgregetedData struct {
 	AveragePrice float64
 	MinPrice     float64
 	MaxPrice     float64
+	FirstPrice   float64
+	LastPrice    float64
+	TickCount    int64
 }
 
+// Trade is a tick as the exchanges send it. Timestamp is the time of the trade
+// in Unix milliseconds, or zero if the exchange did not send one.
 type Trade struct {
 	Symbol    string  `json:"symbol"`
 	Price     float64 `json:"price"`
 	Timestamp int64   `json:"timestamp"`
 }
 
+// At returns the time of t, or now if t has none.
+func (t Trade) At(now time.Time) time.Time {
+	if t.Timestamp == 0 {
+		return now
+	}
+	return time.UnixMilli(t.Timestamp)
+}
+
 const (
 	BTCUSDT  = "BTCUSDT"
 	DOGEUSDT = "DOGEUSDT"
@@ -29,3 +44,522 @@ const (
 	SOLUSDT  = "SOLUSDT"
 	ETHUSDT  = "ETHUSDT"
 )
+
+// Resolution is a bucket width that the one-minute market rows are rolled up
+// into.
+type Resolution struct {
+	Name string
+	Step time.Duration
+}
+
+// MarketResolution is the resolution of the market rows themselves.
+var MarketResolution = Resolution{Name: "1m", Step: TimeOfAverage}
+
+var Resolutions = []Resolution{
+	{Name: "5m", Step: 5 * time.Minute},
+	{Name: "1h", Step: time.Hour},
+	{Name: "1d", Step: 24 * time.Hour},
+}
+
+// ResolutionByName looks up the market resolution or one of the rollups.
+func ResolutionByName(name string) (Resolution, bool) {
+	if name == MarketResolution.Name {
+		return MarketResolution, true
+	}
+	for _, res := range Resolutions {
+		if res.Name == name {
+			return res, true
+		}
+	}
+	return Resolution{}, false
+}
+
+// Candle is one market row or rollup bucket.
+type Candle struct {
+	Exchange   string
+	Symbol     string
+	Resolution string
+	Start      time.Time
+	Open       float64
+	High       float64
+	Low        float64
+	Close      float64
+	Average    float64
+	TickCount  int64
+}
+
+// Key identifies c across instances.
+func (c Candle) Key() string {
+	return "candle:" + c.Exchange + ":" + c.Symbol + ":" + strconv.FormatInt(c.Start.Unix(), 10)
+}
+
+// RawResolution names the raw ticks. They are not archived, so a history
+// cannot be read from them.
+const RawResolution = "raw"
+
+// HistoryPoint is one row of a price history: a market row or a rollup
+// bucket.
+type HistoryPoint struct {
+	Time      time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Average   float64
+	TickCount int64
+}
+
+// HistoryPage is one page of a history, oldest first. Next is the cursor of
+// the following page and empty on the last one.
+type HistoryPage struct {
+	Resolution string
+	Points     []HistoryPoint
+	Next       string
+}
+
+// RetentionPolicy keeps the rows of Table, restricted to Resolution for the
+// rollup table, for MaxAge. A zero MaxAge keeps them forever.
+type RetentionPolicy struct {
+	Table      string
+	Resolution string
+	MaxAge     time.Duration
+}
+
+func (p RetentionPolicy) Name() string {
+	if p.Resolution == "" {
+		return p.Table
+	}
+	return p.Table + ":" + p.Resolution
+}
+
+// RetentionPreview describes the rows a policy would delete if it ran now.
+type RetentionPreview struct {
+	Cutoff time.Time
+	Rows   int64
+	Oldest *time.Time
+}
+
+// RetentionStats is what the retention job has done for one policy so far.
+type RetentionStats struct {
+	LastRun      time.Time
+	LastDuration time.Duration
+	LastDeleted  int64
+	TotalDeleted int64
+	LastError    string
+}
+
+type RetentionReport struct {
+	Policy  RetentionPolicy
+	Preview RetentionPreview
+	Stats   RetentionStats
+}
+
+// GlobalExchange is the pseudo exchange that every tick is also recorded
+// under.
+const GlobalExchange = "global"
+
+// Quote is the latest known price of a pair on one exchange.
+type Quote struct {
+	Exchange  string
+	Symbol    string
+	Price     float64
+	Timestamp time.Time
+}
+
+// Age is how long before now the quote was recorded. Clock skew never makes
+// it negative.
+func (q Quote) Age(now time.Time) time.Duration {
+	return max(now.Sub(q.Timestamp), 0)
+}
+
+// Pair names one symbol on one exchange.
+type Pair struct {
+	Exchange string
+	Symbol   string
+}
+
+type ConsensusContributor struct {
+	Quote
+	Weight float64
+}
+
+type ConsensusExclusion struct {
+	Quote
+	Reason string
+}
+
+// ConsensusPrice is a single price for a pair derived from the latest price on
+// every exchange that quotes it.
+type ConsensusPrice struct {
+	Symbol       string
+	Method       string
+	Price        float64
+	Timestamp    time.Time
+	Contributors []ConsensusContributor
+	Excluded     []ConsensusExclusion
+}
+
+// PairSpread is the price difference between two exchanges quoting the same
+// pair. Low is the cheaper exchange.
+type PairSpread struct {
+	High      Quote
+	Low       Quote
+	Spread    float64
+	SpreadBps float64
+}
+
+type SpreadReport struct {
+	Symbol string
+	// Venues are ordered from the highest price to the lowest.
+	Venues []Quote
+	Pairs  []PairSpread
+}
+
+const (
+	DivergenceStarted = "divergence_started"
+	DivergenceEnded   = "divergence_ended"
+)
+
+// DivergenceEvent is raised when the spread between two exchanges stays above
+// the configured threshold for long enough, and again when it falls back.
+type DivergenceEvent struct {
+	Type  string
+	Since time.Time
+	Time  time.Time
+	PairSpread
+}
+
+// Kinds of alert rule, by the value they watch.
+const (
+	// AlertPrice watches the price of a pair.
+	AlertPrice = "price"
+	// AlertChange watches how far the price of a pair moved over a window,
+	// in percent either way.
+	AlertChange = "change"
+	// AlertSpread watches the spread of a pair between two exchanges, in
+	// basis points of the lower price.
+	AlertSpread = "spread"
+)
+
+// Directions in which an alert rule's value crosses its threshold.
+const (
+	AlertAbove = "above"
+	AlertBelow = "below"
+)
+
+// AlertRule fires when its value crosses Threshold in the direction of
+// Operator. It fires again only after the value came back past Threshold by
+// Hysteresis, and at most once per Cooldown. OtherExchange is only set for
+// spreads and Window only for changes.
+type AlertRule struct {
+	ID            int64
+	Name          string
+	Kind          string
+	Symbol        string
+	Exchange      string
+	OtherExchange string
+	Operator      string
+	Threshold     float64
+	Hysteresis    float64
+	Window        time.Duration
+	Cooldown      time.Duration
+	CreatedAt     time.Time
+	// Firing and LastTriggered are the state the latest events left the
+	// rule in.
+	Firing        bool
+	LastTriggered time.Time
+}
+
+const (
+	AlertTriggered = "triggered"
+	AlertResolved  = "resolved"
+)
+
+// AlertEventBucket is the span in which a rule records at most one event of
+// each type. Every instance evaluates every rule, so an event is raised once
+// per instance; it is stored and delivered once per bucket. Events are timed
+// by the tick that raised them, so instances agree on the bucket, and a
+// cooldown is never shorter than a bucket, so no trigger is lost to one.
+const AlertEventBucket = time.Minute
+
+// AlertEvent is a rule starting or stopping to fire. Value is what the rule
+// watches at that time.
+type AlertEvent struct {
+	ID            int64
+	RuleID        int64
+	RuleName      string
+	Type          string
+	Kind          string
+	Symbol        string
+	Exchange      string
+	OtherExchange string
+	Value         float64
+	Threshold     float64
+	Time          time.Time
+	// Triggered is when the trigger a resolved event ends happened. It is
+	// not stored.
+	Triggered time.Time
+}
+
+// Bucket is the start of the AlertEventBucket that e falls into. A resolved
+// event falls into the bucket of its trigger, so every trigger is resolved
+// once however close the next one follows.
+func (e AlertEvent) Bucket() time.Time {
+	if e.Type == AlertResolved && !e.Triggered.IsZero() {
+		return e.Triggered.Truncate(AlertEventBucket)
+	}
+	return e.Time.Truncate(AlertEventBucket)
+}
+
+// Key identifies e across instances.
+func (e AlertEvent) Key() string {
+	return "alert:" + strconv.FormatInt(e.RuleID, 10) + ":" + e.Type + ":" + strconv.FormatInt(e.Bucket().Unix(), 10)
+}
+
+// IndicatorPoint is one value of an indicator. Upper and Lower are only set
+// for Bollinger bands, Signal and Histogram only for MACD.
+type IndicatorPoint struct {
+	Timestamp time.Time
+	Value     float64
+	Upper     float64
+	Lower     float64
+	Signal    float64
+	Histogram float64
+}
+
+type Indicator struct {
+	Exchange string
+	Symbol   string
+	Type     string
+	Window   int
+	Interval string
+	// Points are ordered oldest first.
+	Points []IndicatorPoint
+}
+
+// Distribution describes how prices were spread over a period. Source is
+// "ticks" when it was computed from raw ticks, otherwise the resolution of
+// the rows it was computed from. Approximate is set when the standard
+// deviation and percentiles were taken over the average prices of those rows
+// rather than over the ticks.
+type Distribution struct {
+	Source      string
+	Approximate bool
+	Samples     int64
+	TickCount   int64
+	StdDev      float64
+	Volatility  float64
+	P5          float64
+	P50         float64
+	P95         float64
+}
+
+// Summary holds every scalar statistic of a pair over a period. Start and End
+// are the bounds of the data that was actually found.
+type Summary struct {
+	Start     time.Time
+	End       time.Time
+	Min       float64
+	Max       float64
+	Average   float64
+	First     float64
+	Last      float64
+	TickCount int64
+}
+
+// TimeRange is the half-open interval [From, To).
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+func (r TimeRange) Duration() time.Duration {
+	return r.To.Sub(r.From)
+}
+
+// SymbolListing records that an exchange quotes a pair.
+type SymbolListing struct {
+	Exchange  string
+	Symbol    string
+	FirstSeen time.Time
+	LastTick  time.Time
+}
+
+// Symbol is a pair together with every exchange that quotes it. FirstSeen and
+// LastTick span all of those exchanges.
+type Symbol struct {
+	Name      string
+	Base      string
+	Quote     string
+	FirstSeen time.Time
+	LastTick  time.Time
+	Listings  []SymbolListing
+}
+
+// quoteAssets are matched against the end of a pair name; the longest match
+// wins.
+var quoteAssets = []string{"USDT", "USDC", "BUSD", "FDUSD", "TUSD", "USD", "EUR", "BTC", "ETH", "BNB"}
+
+// SplitSymbol splits a pair such as "BTCUSDT" into its base and quote asset.
+// An unknown quote asset leaves quote empty.
+func SplitSymbol(name string) (base, quote string) {
+	best := ""
+	for _, q := range quoteAssets {
+		if len(q) > len(best) && len(name) > len(q) && strings.HasSuffix(name, q) {
+			best = q
+		}
+	}
+	return name[:len(name)-len(best)], best
+}
+
+// Topics an Event can be published on.
+const (
+	TopicTicks     = "ticks"
+	TopicCandles   = "candles"
+	TopicConsensus = "consensus"
+	TopicAlerts    = "alerts"
+)
+
+// Event is a message published to the subscribers of a topic. Exchange and
+// Symbol are set when the event concerns a single pair. Key, when set,
+// identifies the event across instances, which each publish it; a webhook
+// gets one delivery per key.
+type Event struct {
+	Topic    string
+	Exchange string
+	Symbol   string
+	Time     time.Time
+	Data     any
+	Key      string
+}
+
+// Role is what an API key may do. Each role includes the ones before it.
+type Role string
+
+const (
+	RoleRead     Role = "read"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+var roleRanks = map[Role]int{RoleRead: 1, RoleOperator: 2, RoleAdmin: 3}
+
+func (r Role) Valid() bool {
+	return roleRanks[r] > 0
+}
+
+// Allows reports whether r includes required.
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[required]
+}
+
+// APIKey describes an issued key. The key itself is never stored.
+type APIKey struct {
+	ID        int64
+	Name      string
+	Prefix    string
+	Role      Role
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// RateLimit is a token bucket holding up to Burst requests, refilled with
+// Requests every Per. A zero limit does not limit anything.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+func (l RateLimit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0 && l.Burst > 0
+}
+
+// PerMillisecond is the number of tokens added per millisecond.
+func (l RateLimit) PerMillisecond() float64 {
+	return float64(l.Requests) / (float64(l.Per) / float64(time.Millisecond))
+}
+
+// Decide describes the bucket after a request was let through or not, with
+// tokens left in it.
+func (l RateLimit) Decide(allowed bool, tokens float64) RateDecision {
+	d := RateDecision{Allowed: allowed, Limit: l.Burst, Window: l.Per}
+	if !l.Enabled() {
+		return d
+	}
+
+	d.Remaining = int(tokens)
+	rate := l.PerMillisecond()
+	d.Reset = time.Duration((float64(l.Burst) - tokens) / rate * float64(time.Millisecond))
+	if !allowed {
+		d.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Millisecond))
+	}
+	return d
+}
+
+// RateDecision is the outcome of taking a token. Limit is 0 when the request
+// was not limited at all.
+type RateDecision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Window    time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until a rejected request would be let through.
+	RetryAfter time.Duration
+}
+
+// Usage counts the requests of one client to one route group on a day.
+type Usage struct {
+	Client   string
+	Group    string
+	Requests int64
+	Limited  int64
+}
+
+// Webhook is an endpoint that receives the events matching its topics,
+// symbols and exchanges. Empty symbols and exchanges match every one.
+type Webhook struct {
+	ID        int64
+	URL       string
+	Secret    string
+	Topics    []string
+	Symbols   []string
+	Exchanges []string
+	CreatedAt time.Time
+	// Circuit is the state of the endpoint's circuit breaker; it is kept in
+	// memory only.
+	Circuit string
+}
+
+// States of a webhook's circuit breaker.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event on its way to one webhook. A pending delivery
+// is attempted again at NextAttempt; StatusCode and Error describe the latest
+// attempt.
+type WebhookDelivery struct {
+	ID          int64
+	WebhookID   int64
+	Topic       string
+	EventKey    string
+	Payload     []byte
+	Status      string
+	Attempts    int
+	StatusCode  int
+	Error       string
+	NextAttempt time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -12,8 +12,13 @@ type Repository interface {
 	GetAverage(ctx context.Context, arg storage.Params) (float64, error)
 	GetMax(ctx context.Context, arg storage.Params) (float64, error)
 	GetMin(ctx context.Context, arg storage.Params) (float64, error)
-	GetLatest(ctx context.Context, exchange string, symbol string) (float64, error)
+	GetLatest(ctx context.Context, exchange string, symbol string) (model.Quote, error)
+	GetLatestBatch(ctx context.Context, pairs []model.Pair) ([]model.Quote, error)
 	InsertMarket(ctx context.Context, arg storage.InsertMarketParams) (model.AgregetedData, error)
+	GetCandles(ctx context.Context, arg storage.CandleParams) ([]model.Candle, error)
+	GetHistory(ctx context.Context, arg storage.HistoryParams) ([]model.HistoryPoint, error)
+	GetDistribution(ctx context.Context, arg storage.Params) (model.Distribution, error)
+	GetSummary(ctx context.Context, arg storage.Params) (model.Summary, error)
 }
 
 type Cache interface {
@@ -21,3 +26,61 @@ type Cache interface {
 	SaveRawData(ctx context.Context, exchanger string, data model.Trade) error
 	GetRawData(ctx context.Context, exchanger string, symbol string, interval time.Duration) ([]model.Trade, error)
 }
+
+type RollupRepository interface {
+	RollupMarket(ctx context.Context, res model.Resolution, batch int64) (int64, error)
+}
+
+type RetentionRepository interface {
+	DeleteExpired(ctx context.Context, p model.RetentionPolicy, batch int64) (int64, error)
+	PreviewExpired(ctx context.Context, p model.RetentionPolicy) (model.RetentionPreview, error)
+}
+
+type SymbolRepository interface {
+	UpsertSymbols(ctx context.Context, listings []model.SymbolListing) error
+	ListSymbols(ctx context.Context) ([]model.SymbolListing, error)
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key model.APIKey, hash string) (model.APIKey, error)
+	// GetAPIKeyByHash only returns keys that were not revoked.
+	GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+}
+
+type AlertRepository interface {
+	CreateAlertRule(ctx context.Context, rule model.AlertRule) (model.AlertRule, error)
+	// ListAlertRules returns the rules that were not deleted, with the state
+	// their latest events left them in.
+	ListAlertRules(ctx context.Context) ([]model.AlertRule, error)
+	DeleteAlertRule(ctx context.Context, id int64) error
+	InsertAlertEvents(ctx context.Context, events []model.AlertEvent) error
+	// ListAlertEvents returns the events in rng, newest first, of one rule or
+	// of every rule when ruleID is 0.
+	ListAlertEvents(ctx context.Context, ruleID int64, rng model.TimeRange, limit int) ([]model.AlertEvent, error)
+}
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, w model.Webhook) (model.Webhook, error)
+	// ListWebhooks returns the webhooks that were not deleted, secrets
+	// included.
+	ListWebhooks(ctx context.Context) ([]model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	CreateDelivery(ctx context.Context, d model.WebhookDelivery) (model.WebhookDelivery, error)
+	InsertDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	// ClaimDeliveries returns up to limit pending deliveries due at now and
+	// moves them lease into the future, so that no other instance sends
+	// them in the meantime.
+	ClaimDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, d model.WebhookDelivery) error
+	// ListDeliveries returns the latest deliveries of a webhook, newest first.
+	ListDeliveries(ctx context.Context, webhookID int64, limit int) ([]model.WebhookDelivery, error)
+}
+
+type RateLimitStore interface {
+	// Take takes a token from the bucket of client in group and counts the
+	// request in the usage of day. A disabled limit only counts it.
+	Take(ctx context.Context, client, group string, limit model.RateLimit, day string) (model.RateDecision, error)
+	ListUsage(ctx context.Context, day string) ([]model.Usage, error)
+}
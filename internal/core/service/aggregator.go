@@ -8,112 +8,313 @@ import (
 
 	"marketflow/internal/adapters/secondary/storage"
 	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+	"marketflow/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const TimeTicker = 250 * time.Millisecond
+
+// maxFlushAge is how long a finished window is retried before it is dropped.
+const maxFlushAge = 10 * time.Minute
+
+// windowGrace is how long a window stays open after it ends, for ticks that
+// were delayed on their way from the exchange.
+const windowGrace = 2 * time.Second
+
+var (
+	aggregatorCycle = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "marketflow_aggregator_cycle_duration_seconds",
+		Help:    "Time the aggregator took to close and write the finished windows, per tick.",
+		Buckets: metrics.DurationBuckets,
+	})
+	aggregatorErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "marketflow_aggregator_errors_total",
+		Help: "Finished windows the aggregator failed to write.",
+	})
+	aggregatorDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "marketflow_aggregator_dropped_windows_total",
+		Help: "Finished windows dropped after failing to be written for too long.",
+	})
+	aggregatorLate = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "marketflow_aggregator_late_ticks_total",
+		Help: "Ticks dropped because the window of their time was already closed.",
+	})
 )
 
-const TimeTicker = 1 * time.Second
+type windowKey struct {
+	exchange string
+	symbol   string
+}
+
+// window keeps the running aggregates of one pair on one exchange for one
+// model.TimeOfAverage period.
+type window struct {
+	start time.Time
+	min   float64
+	max   float64
+	sum   float64
+	first float64
+	last  float64
+	count int64
+}
+
+func (w *window) add(price float64) {
+	if w.count == 0 {
+		w.min = price
+		w.max = price
+		w.first = price
+	}
+	if price < w.min {
+		w.min = price
+	}
+	if price > w.max {
+		w.max = price
+	}
+	w.sum += price
+	w.last = price
+	w.count++
+}
+
+type closedWindow struct {
+	key windowKey
+	window
+}
 
+// Aggregator folds the ticks it observes from the worker pools into
+// one-minute windows by the time of each tick and writes every finished window
+// to the repository. A window is never reopened once it is closed, so every
+// instance writes the same rows. Redis is only read on startup to rebuild the
+// windows the previous process had not written yet.
 type Aggregator struct {
 	cache core.Cache
 	repo  core.Repository
-	err   error
+	now   func() time.Time
+
+	mu     sync.Mutex
+	open   map[windowKey]*window
+	closed []closedWindow
+	// done is the start of the last window closed per pair.
+	done      map[windowKey]time.Time
+	listeners []func(model.Candle)
 }
 
 func NewAggregator(cache core.Cache, repo core.Repository) *Aggregator {
 	return &Aggregator{
 		cache: cache,
 		repo:  repo,
+		now:   time.Now,
+		open:  make(map[windowKey]*window),
+		done:  make(map[windowKey]time.Time),
 	}
 }
 
-func (a *Aggregator) Start(ctx context.Context, interval time.Duration) error {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-	for {
-		select {
+// OnFinalized registers f to be called with every window once it has been
+// written to the repository.
+func (a *Aggregator) OnFinalized(f func(model.Candle)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.listeners = append(a.listeners, f)
+}
 
-		case <-ticker.C:
+// Observe adds a tick to the window of its time. Ticks without a time, or
+// with one ahead of the clock, count as arriving now.
+func (a *Aggregator) Observe(exchange string, trade model.Trade) {
+	at := a.now()
+	if trade.Timestamp != 0 && trade.Timestamp < at.UnixMilli() {
+		at = time.UnixMilli(trade.Timestamp)
+	}
+	start := at.Truncate(model.TimeOfAverage)
+	key := windowKey{exchange: exchange, symbol: trade.Symbol}
 
-			aCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-			defer cancel()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if done, ok := a.done[key]; ok && !start.After(done) {
+		aggregatorLate.Inc()
+		return
+	}
+
+	w, ok := a.open[key]
+	if ok && start.Before(w.start) {
+		aggregatorLate.Inc()
+		return
+	}
+	if ok && start.After(w.start) {
+		a.close(key, w)
+		ok = false
+	}
+	if !ok {
+		w = &window{start: start}
+		a.open[key] = w
+	}
+	w.add(trade.Price)
+}
 
-			if err := a.aggregate(aCtx, time.Minute); err != nil {
+// close moves the open window w of key to the ones waiting to be written.
+func (a *Aggregator) close(key windowKey, w *window) {
+	a.closed = append(a.closed, closedWindow{key: key, window: *w})
+	a.done[key] = w.start
+	delete(a.open, key)
+}
+
+// Recover rebuilds the current windows from the raw ticks still in the cache,
+// and the previous ones when they have no row yet. It has to run before any
+// tick is observed.
+func (a *Aggregator) Recover(ctx context.Context) error {
+	exchangers, symbols, err := a.cache.GetCollection(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := a.now()
+	start := now.Truncate(model.TimeOfAverage)
+	previous := start.Add(-model.TimeOfAverage)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, exchanger := range exchangers {
+		for _, symbol := range symbols {
+			key := windowKey{exchange: exchanger, symbol: symbol}
+
+			rawData, err := a.cache.GetRawData(ctx, exchanger, symbol, now.Sub(previous))
+			if err != nil {
+				slog.Error("failed to get raw data", "error", err)
 				continue
 			}
 
-			if a.err != nil {
-				slog.Error("aggregation error", "error", a.err)
-				a.err = nil
-				continue
+			prev := &window{start: previous}
+			w := &window{start: start}
+			for _, data := range rawData {
+				switch {
+				case data.Timestamp >= start.UnixMilli():
+					w.add(data.Price)
+				case data.Timestamp >= previous.UnixMilli():
+					prev.add(data.Price)
+				}
+			}
+
+			if prev.count > 0 && !a.written(ctx, key, previous) {
+				a.closed = append(a.closed, closedWindow{key: key, window: *prev})
 			}
+			a.done[key] = previous
+			if w.count > 0 {
+				a.open[key] = w
+			}
+		}
+	}
+
+	slog.Info("aggregator recovered windows", "open", len(a.open), "closed", len(a.closed))
+	return nil
+}
+
+// written reports whether the window of key at start already has a row. When
+// that cannot be told the window is assumed written, as rewriting it from
+// fewer ticks would be worse than leaving it out.
+func (a *Aggregator) written(ctx context.Context, key windowKey, start time.Time) bool {
+	candles, err := a.repo.GetCandles(ctx, storage.CandleParams{
+		PairName:   key.symbol,
+		Exchange:   key.exchange,
+		Resolution: model.MarketResolution.Name,
+		Limit:      1,
+	})
+	if err != nil {
+		slog.Error("failed to look up the last window", "exchange", key.exchange, "symbol", key.symbol, "error", err)
+		return true
+	}
+	return len(candles) > 0 && !candles[len(candles)-1].Start.Before(start)
+}
+
+func (a *Aggregator) Start(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			start := time.Now()
+			a.flush(ctx, a.finished(a.now().Add(-windowGrace)))
+			aggregatorCycle.Observe(time.Since(start).Seconds())
 
 		case <-ctx.Done():
-			aCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			aCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 			defer cancel()
 
-			a.aggregate(aCtx, time.Minute)
+			a.flush(aCtx, a.finished(time.Time{}))
 
 			return nil
 		}
 	}
 }
 
-func (a *Aggregator) aggregate(ctx context.Context, interval time.Duration) error {
-	exchangers, symbols, err := a.cache.GetCollection(ctx)
-	if err != nil {
-		return err
-	}
+// finished takes every window that ended before now out of the aggregator. A
+// zero now takes all windows, including the ones still open.
+func (a *Aggregator) finished(now time.Time) []closedWindow {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-	wg.Add(len(exchangers) * len(symbols))
-
-	for _, exchanger := range exchangers {
-		for _, symbol := range symbols {
-			go func(exchanger, symbol string) {
-				defer wg.Done()
+	for key, w := range a.open {
+		if now.IsZero() || !w.start.Add(model.TimeOfAverage).After(now) {
+			a.close(key, w)
+		}
+	}
 
-				rawData, err := a.cache.GetRawData(ctx, exchanger, symbol, interval)
-				if err != nil {
-					slog.Error("failed to get raw data", "error", err)
-					return
-				}
+	closed := a.closed
+	a.closed = nil
+	return closed
+}
 
-				if len(rawData) == 0 {
-					return
-				}
+func (a *Aggregator) flush(ctx context.Context, closed []closedWindow) {
+	var retry []closedWindow
+	var finalized []model.Candle
+	for _, c := range closed {
+		candle := model.Candle{
+			Exchange:   c.key.exchange,
+			Symbol:     c.key.symbol,
+			Resolution: model.MarketResolution.Name,
+			Start:      c.start,
+			Open:       c.first,
+			High:       c.max,
+			Low:        c.min,
+			Close:      c.last,
+			Average:    c.sum / float64(c.count),
+			TickCount:  c.count,
+		}
 
-				var sum float64
-				var min, max float64
-				min = rawData[0].Price
-				max = rawData[0].Price
-				for _, data := range rawData {
-					sum += data.Price
-					if data.Price < min {
-						min = data.Price
-					}
-					if data.Price > max {
-						max = data.Price
-					}
-				}
-				average := sum / float64(len(rawData))
-				_, err = a.repo.InsertMarket(ctx, storage.InsertMarketParams{
-					PairName:     symbol,
-					Exchange:     exchanger,
-					AveragePrice: average,
-					MinPrice:     min,
-					MaxPrice:     max,
-				})
-				if err != nil {
-					mu.Lock()
-					a.err = err
-					mu.Unlock()
-					return
-				}
-			}(exchanger, symbol)
+		_, err := a.repo.InsertMarket(ctx, storage.InsertMarketParams{
+			PairName:     candle.Symbol,
+			Exchange:     candle.Exchange,
+			Timestamp:    candle.Start,
+			AveragePrice: candle.Average,
+			MinPrice:     candle.Low,
+			MaxPrice:     candle.High,
+			FirstPrice:   candle.Open,
+			LastPrice:    candle.Close,
+			TickCount:    candle.TickCount,
+		})
+		if err != nil {
+			slog.Error("aggregation error", "exchange", c.key.exchange, "symbol", c.key.symbol, "error", err)
+			aggregatorErrors.Inc()
+			if a.now().Sub(c.start) < maxFlushAge {
+				retry = append(retry, c)
+			} else {
+				aggregatorDropped.Inc()
+			}
+			continue
 		}
+		finalized = append(finalized, candle)
 	}
 
-	wg.Wait()
+	a.mu.Lock()
+	a.closed = append(a.closed, retry...)
+	listeners := a.listeners
+	a.mu.Unlock()
 
-	return nil
+	for _, candle := range finalized {
+		for _, f := range listeners {
+			f(candle)
+		}
+	}
 }
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+type marketRepo struct {
+	core.Repository
+	fail    bool
+	written []storage.InsertMarketParams
+	// last is the start of the latest stored window per exchange.
+	last map[string]time.Time
+}
+
+func (r *marketRepo) GetCandles(ctx context.Context, arg storage.CandleParams) ([]model.Candle, error) {
+	start, ok := r.last[arg.Exchange]
+	if !ok {
+		return nil, nil
+	}
+	return []model.Candle{{Exchange: arg.Exchange, Symbol: arg.PairName, Start: start}}, nil
+}
+
+func (r *marketRepo) InsertMarket(ctx context.Context, arg storage.InsertMarketParams) (model.AgregetedData, error) {
+	if r.fail {
+		return model.AgregetedData{}, errors.New("insert failed")
+	}
+	r.written = append(r.written, arg)
+	return model.AgregetedData{}, nil
+}
+
+type rawCache struct {
+	core.Cache
+	trades map[string][]model.Trade
+}
+
+func (c rawCache) GetCollection(ctx context.Context) ([]string, []string, error) {
+	return []string{"exchange1", "exchange2"}, []string{model.BTCUSDT}, nil
+}
+
+func (c rawCache) GetRawData(ctx context.Context, exchanger string, symbol string, interval time.Duration) ([]model.Trade, error) {
+	return c.trades[exchanger], nil
+}
+
+func TestAggregator_Windows(t *testing.T) {
+	repo := &marketRepo{}
+	a := NewAggregator(nil, repo)
+	minute := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := minute.Add(10 * time.Second)
+	a.now = func() time.Time { return now }
+
+	var finalized []model.Candle
+	a.OnFinalized(func(c model.Candle) { finalized = append(finalized, c) })
+
+	for _, price := range []float64{3, 1, 4, 2} {
+		a.Observe("exchange1", model.Trade{Symbol: model.BTCUSDT, Price: price})
+	}
+	a.Observe("exchange2", model.Trade{Symbol: model.BTCUSDT, Price: 10})
+
+	if closed := a.finished(minute.Add(model.TimeOfAverage - time.Nanosecond)); len(closed) != 0 {
+		t.Fatalf("expected no window to finish before its end, got %d", len(closed))
+	}
+
+	// The first tick after the boundary closes the window of its pair at
+	// once and opens the next one.
+	now = minute.Add(model.TimeOfAverage)
+	a.Observe("exchange1", model.Trade{Symbol: model.BTCUSDT, Price: 5})
+
+	a.flush(context.Background(), a.finished(now))
+	if len(repo.written) != 2 {
+		t.Fatalf("expected both windows of the first minute to be written, got %+v", repo.written)
+	}
+	var first storage.InsertMarketParams
+	for _, w := range repo.written {
+		if w.Exchange == "exchange1" {
+			first = w
+		}
+	}
+	want := storage.InsertMarketParams{
+		PairName:     model.BTCUSDT,
+		Exchange:     "exchange1",
+		Timestamp:    minute,
+		AveragePrice: 2.5,
+		MinPrice:     1,
+		MaxPrice:     4,
+		FirstPrice:   3,
+		LastPrice:    2,
+		TickCount:    4,
+	}
+	if first != want {
+		t.Errorf("expected %+v, got %+v", want, first)
+	}
+	if len(finalized) != 2 {
+		t.Errorf("expected the written windows to be finalized, got %+v", finalized)
+	}
+
+	// A tick stamped in a minute that was already written is dropped, while
+	// a tick of a pair without a closed window still goes by its own time.
+	now = minute.Add(model.TimeOfAverage + time.Second)
+	a.Observe("exchange2", model.Trade{Symbol: model.BTCUSDT, Price: 11, Timestamp: minute.Add(59 * time.Second).UnixMilli()})
+	a.Observe("exchange3", model.Trade{Symbol: model.BTCUSDT, Price: 12, Timestamp: minute.Add(59 * time.Second).UnixMilli()})
+	a.flush(context.Background(), a.finished(time.Time{}))
+
+	if len(repo.written) != 4 {
+		t.Fatalf("expected the open windows to be written on the final flush, got %+v", repo.written)
+	}
+	for _, w := range repo.written[2:] {
+		switch w.Exchange {
+		case "exchange1":
+			if !w.Timestamp.Equal(minute.Add(model.TimeOfAverage)) || w.TickCount != 1 {
+				t.Errorf("expected a window of the second minute with one tick, got %+v", w)
+			}
+		case "exchange3":
+			if !w.Timestamp.Equal(minute) || w.TickCount != 1 {
+				t.Errorf("expected a window of the first minute with one tick, got %+v", w)
+			}
+		default:
+			t.Errorf("expected the late tick of exchange2 to be dropped, got %+v", w)
+		}
+	}
+}
+
+func TestAggregator_FailedWrite(t *testing.T) {
+	repo := &marketRepo{fail: true}
+	a := NewAggregator(nil, repo)
+	minute := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := minute
+	a.now = func() time.Time { return now }
+
+	var finalized int
+	a.OnFinalized(func(model.Candle) { finalized++ })
+
+	a.Observe("exchange1", model.Trade{Symbol: model.BTCUSDT, Price: 1})
+	now = minute.Add(model.TimeOfAverage)
+	a.flush(context.Background(), a.finished(now))
+	if finalized != 0 {
+		t.Fatal("expected a window that failed to be written not to be finalized")
+	}
+
+	repo.fail = false
+	a.flush(context.Background(), a.finished(now))
+	if len(repo.written) != 1 || finalized != 1 {
+		t.Fatalf("expected the window to be retried on the next tick, got %+v", repo.written)
+	}
+
+	repo.fail = true
+	a.Observe("exchange1", model.Trade{Symbol: model.BTCUSDT, Price: 2})
+	now = now.Add(maxFlushAge)
+	a.flush(context.Background(), a.finished(now))
+	if closed := a.finished(now); len(closed) != 0 {
+		t.Errorf("expected a window failing for %s to be dropped, got %+v", maxFlushAge, closed)
+	}
+}
+
+func TestAggregator_Recover(t *testing.T) {
+	minute := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	previous := []model.Trade{
+		{Symbol: model.BTCUSDT, Price: 50, Timestamp: minute.Add(-time.Minute).UnixMilli()},
+		{Symbol: model.BTCUSDT, Price: 100, Timestamp: minute.Add(-time.Second).UnixMilli()},
+	}
+	cache := rawCache{trades: map[string][]model.Trade{
+		"exchange1": append(previous,
+			model.Trade{Symbol: model.BTCUSDT, Price: 2, Timestamp: minute.UnixMilli()},
+			model.Trade{Symbol: model.BTCUSDT, Price: 4, Timestamp: minute.Add(20 * time.Second).UnixMilli()},
+		),
+		"exchange2": previous,
+	}}
+	repo := &marketRepo{last: map[string]time.Time{"exchange2": minute.Add(-time.Minute)}}
+	a := NewAggregator(cache, repo)
+	now := minute.Add(30 * time.Second)
+	a.now = func() time.Time { return now }
+
+	if err := a.Recover(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	a.Observe("exchange1", model.Trade{Symbol: model.BTCUSDT, Price: 6, Timestamp: now.UnixMilli()})
+	a.Observe("exchange2", model.Trade{Symbol: model.BTCUSDT, Price: 7, Timestamp: minute.Add(-time.Second).UnixMilli()})
+	a.flush(context.Background(), a.finished(time.Time{}))
+
+	if len(repo.written) != 2 {
+		t.Fatalf("expected the previous and current window of exchange1 only, got %+v", repo.written)
+	}
+	for _, w := range repo.written {
+		if w.Exchange != "exchange1" {
+			t.Errorf("expected the written window of exchange2 to stay closed, got %+v", w)
+			continue
+		}
+		if w.Timestamp.Equal(minute) && (w.TickCount != 3 || w.FirstPrice != 2 || w.AveragePrice != 4) {
+			t.Errorf("expected the recovered ticks of the current minute to be kept, got %+v", w)
+		}
+		if w.Timestamp.Equal(minute.Add(-time.Minute)) && (w.TickCount != 2 || w.AveragePrice != 75) {
+			t.Errorf("expected the previous minute to be rebuilt, got %+v", w)
+		}
+	}
+}
@@ -0,0 +1,489 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+const AlertsTicker = 1 * time.Second
+
+const (
+	DefaultAlertCooldown = 5 * time.Minute
+
+	// maxAlertWindow is the longest window a change rule may look back.
+	maxAlertWindow = 24 * time.Hour
+	maxAlertRules  = 1000
+	maxAlertName   = 100
+
+	// alertReloadInterval is how often the rules are read again, to pick up
+	// the ones other instances defined.
+	alertReloadInterval = time.Minute
+	// maxSpreadQuoteAge is how old the price of the other exchange of a
+	// spread may be before the spread is no longer evaluated.
+	maxSpreadQuoteAge = 30 * time.Second
+	// maxPendingAlertEvents bounds the events held while the repository
+	// cannot be written to.
+	maxPendingAlertEvents = 10000
+
+	defaultAlertHistoryLimit = 100
+	maxAlertHistoryLimit     = 1000
+)
+
+var (
+	ErrInvalidAlertRule  = model.NewError(model.KindInvalid, "invalid_alert_rule", "invalid alert rule")
+	ErrUnknownAlertRule  = model.NewError(model.KindNotFound, "unknown_alert_rule", "no alert rule with this id")
+	ErrTooManyAlertRules = model.NewError(model.KindConflict, "too_many_alert_rules", fmt.Sprintf("at most %d alert rules can be defined", maxAlertRules))
+)
+
+// closeHistory keeps the closes of the finalized one-minute windows of a pair,
+// oldest first, for as long as the longest change window.
+type closeHistory struct {
+	ends   []time.Time
+	closes []float64
+}
+
+func (h *closeHistory) add(end time.Time, close float64) {
+	if n := len(h.ends); n > 0 && !end.After(h.ends[n-1]) {
+		return
+	}
+	h.ends = append(h.ends, end)
+	h.closes = append(h.closes, close)
+
+	cutoff := end.Add(-maxAlertWindow - model.TimeOfAverage)
+	i := sort.Search(len(h.ends), func(i int) bool { return h.ends[i].After(cutoff) })
+	h.ends = h.ends[i:]
+	h.closes = h.closes[i:]
+}
+
+// at returns the close of the window that ended last at or before t. A window
+// that ended more than one window before t is too far off to be used.
+func (h *closeHistory) at(t time.Time) (float64, bool) {
+	i := sort.Search(len(h.ends), func(i int) bool { return h.ends[i].After(t) }) - 1
+	if i < 0 || t.Sub(h.ends[i]) >= model.TimeOfAverage {
+		return 0, false
+	}
+	return h.closes[i], true
+}
+
+// Alerts evaluates the alert rules against every tick. Prices and spreads
+// are compared with the tick itself; changes compare it with the close of the
+// finalized window at the start of their window. Events are published as
+// they happen and written to the repository in batches.
+type Alerts struct {
+	repo core.AlertRepository
+
+	mu        sync.Mutex
+	loaded    bool
+	rules     map[int64]*model.AlertRule
+	bySymbol  map[string][]*model.AlertRule
+	latest    map[model.Pair]model.Quote
+	closes    map[model.Pair]*closeHistory
+	pending   []model.AlertEvent
+	listeners []func(model.AlertEvent)
+}
+
+func NewAlerts(repo core.AlertRepository) *Alerts {
+	return &Alerts{
+		repo:     repo,
+		rules:    make(map[int64]*model.AlertRule),
+		bySymbol: make(map[string][]*model.AlertRule),
+		latest:   make(map[model.Pair]model.Quote),
+		closes:   make(map[model.Pair]*closeHistory),
+	}
+}
+
+// OnAlert registers f to be called for every alert event.
+func (a *Alerts) OnAlert(f func(model.AlertEvent)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.listeners = append(a.listeners, f)
+}
+
+// Observe evaluates the rules of the tick's symbol at the time of the tick, so
+// that every instance raises the same events.
+func (a *Alerts) Observe(exchange string, trade model.Trade) {
+	now := trade.At(time.Now())
+	pair := model.Pair{Exchange: exchange, Symbol: trade.Symbol}
+
+	a.mu.Lock()
+	a.latest[pair] = model.Quote{
+		Exchange:  exchange,
+		Symbol:    trade.Symbol,
+		Price:     trade.Price,
+		Timestamp: now,
+	}
+
+	var events []model.AlertEvent
+	for _, rule := range a.bySymbol[trade.Symbol] {
+		value, ok := a.value(rule, exchange, trade.Price, now)
+		if !ok {
+			continue
+		}
+		if e, ok := evaluate(rule, value, now); ok {
+			events = append(events, e)
+		}
+	}
+	if len(events) == 0 {
+		a.mu.Unlock()
+		return
+	}
+	a.queue(events)
+	listeners := a.listeners
+	a.mu.Unlock()
+
+	for _, e := range events {
+		slog.Info("alert", "rule", e.RuleID, "name", e.RuleName, "type", e.Type, "value", e.Value, "threshold", e.Threshold)
+		for _, f := range listeners {
+			f(e)
+		}
+	}
+}
+
+// ObserveCandle remembers the close of a finalized window for the change
+// rules.
+func (a *Alerts) ObserveCandle(c model.Candle) {
+	pair := model.Pair{Exchange: c.Exchange, Symbol: c.Symbol}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	h, ok := a.closes[pair]
+	if !ok {
+		h = &closeHistory{}
+		a.closes[pair] = h
+	}
+	h.add(c.Start.Add(model.TimeOfAverage), c.Close)
+}
+
+// value is what rule watches after a tick of price on exchange, if the tick
+// concerns it and there is enough data to tell.
+func (a *Alerts) value(rule *model.AlertRule, exchange string, price float64, now time.Time) (float64, bool) {
+	switch rule.Kind {
+	case model.AlertPrice:
+		return price, rule.Exchange == exchange
+	case model.AlertChange:
+		if rule.Exchange != exchange {
+			return 0, false
+		}
+		h, ok := a.closes[model.Pair{Exchange: exchange, Symbol: rule.Symbol}]
+		if !ok {
+			return 0, false
+		}
+		ref, ok := h.at(now.Add(-rule.Window))
+		if !ok || ref <= 0 {
+			return 0, false
+		}
+		return math.Abs(price-ref) / ref * 100, true
+	case model.AlertSpread:
+		var other string
+		switch exchange {
+		case rule.Exchange:
+			other = rule.OtherExchange
+		case rule.OtherExchange:
+			other = rule.Exchange
+		default:
+			return 0, false
+		}
+		q, ok := a.latest[model.Pair{Exchange: other, Symbol: rule.Symbol}]
+		if !ok || q.Age(now) > maxSpreadQuoteAge {
+			return 0, false
+		}
+		low := min(price, q.Price)
+		if low <= 0 {
+			return 0, false
+		}
+		return math.Abs(price-q.Price) / low * 10000, true
+	}
+	return 0, false
+}
+
+// evaluate moves rule on to value and returns the event this raises, if any.
+// A firing rule resolves once value is back past the threshold by the
+// hysteresis; a rule that is not firing triggers once value crosses the
+// threshold and the cooldown since its last trigger, at least
+// model.AlertEventBucket, is over.
+func evaluate(rule *model.AlertRule, value float64, now time.Time) (model.AlertEvent, bool) {
+	var crossed, cleared bool
+	if rule.Operator == model.AlertBelow {
+		crossed = value < rule.Threshold
+		cleared = value >= rule.Threshold+rule.Hysteresis
+	} else {
+		crossed = value > rule.Threshold
+		cleared = value <= rule.Threshold-rule.Hysteresis
+	}
+
+	var typ string
+	var triggered time.Time
+	switch {
+	case rule.Firing && cleared:
+		rule.Firing = false
+		typ = model.AlertResolved
+		triggered = rule.LastTriggered
+	case !rule.Firing && crossed && now.Sub(rule.LastTriggered) >= max(rule.Cooldown, model.AlertEventBucket):
+		rule.Firing = true
+		rule.LastTriggered = now
+		typ = model.AlertTriggered
+	default:
+		return model.AlertEvent{}, false
+	}
+
+	return model.AlertEvent{
+		RuleID:        rule.ID,
+		RuleName:      rule.Name,
+		Type:          typ,
+		Kind:          rule.Kind,
+		Symbol:        rule.Symbol,
+		Exchange:      rule.Exchange,
+		OtherExchange: rule.OtherExchange,
+		Value:         value,
+		Threshold:     rule.Threshold,
+		Time:          now,
+		Triggered:     triggered,
+	}, true
+}
+
+// queue holds events until the next flush, dropping the oldest ones when the
+// repository has been unreachable for too long.
+func (a *Alerts) queue(events []model.AlertEvent) {
+	a.pending = append(a.pending, events...)
+	if over := len(a.pending) - maxPendingAlertEvents; over > 0 {
+		slog.Warn("dropping alert events that could not be saved", "count", over)
+		a.pending = slices.Delete(a.pending, 0, over)
+	}
+}
+
+func (a *Alerts) Start(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	reload := time.NewTicker(alertReloadInterval)
+	defer reload.Stop()
+
+	if err := a.load(ctx); err != nil {
+		slog.Error("failed to load alert rules", "error", err)
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			aCtx, cancel := context.WithTimeout(ctx, interval)
+			a.flush(aCtx)
+			cancel()
+		case <-reload.C:
+			aCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			if err := a.load(aCtx); err != nil {
+				slog.Error("failed to reload alert rules", "error", err)
+			}
+			cancel()
+		case <-ctx.Done():
+			aCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			a.flush(aCtx)
+			cancel()
+			return nil
+		}
+	}
+}
+
+// flush writes the pending events. They are put back if the write fails.
+func (a *Alerts) flush(ctx context.Context) {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = nil
+	a.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	if err := a.repo.InsertAlertEvents(ctx, pending); err != nil {
+		slog.Error("failed to save alert events", "error", err)
+
+		a.mu.Lock()
+		newer := a.pending
+		a.pending = pending
+		a.queue(newer)
+		a.mu.Unlock()
+	}
+}
+
+// load replaces the rules with the ones in the repository. Rules this
+// instance already evaluates keep their state, which may be ahead of the
+// events written so far.
+func (a *Alerts) load(ctx context.Context) error {
+	rules, err := a.repo.ListAlertRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	loaded := make(map[int64]*model.AlertRule, len(rules))
+	for _, rule := range rules {
+		if current, ok := a.rules[rule.ID]; ok {
+			rule.Firing = current.Firing
+			rule.LastTriggered = current.LastTriggered
+		}
+		loaded[rule.ID] = &rule
+	}
+	a.rules = loaded
+	a.index()
+	a.loaded = true
+	return nil
+}
+
+// index rebuilds the rules by symbol. It must be called with mu held.
+func (a *Alerts) index() {
+	clear(a.bySymbol)
+	for _, rule := range a.rules {
+		a.bySymbol[rule.Symbol] = append(a.bySymbol[rule.Symbol], rule)
+	}
+}
+
+// Rules returns every rule with its state, ordered by id.
+func (a *Alerts) Rules(ctx context.Context) ([]model.AlertRule, error) {
+	a.mu.Lock()
+	loaded := a.loaded
+	a.mu.Unlock()
+	if !loaded {
+		if err := a.load(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rules := make([]model.AlertRule, 0, len(a.rules))
+	for _, rule := range a.rules {
+		rules = append(rules, *rule)
+	}
+	slices.SortFunc(rules, func(a, b model.AlertRule) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return rules, nil
+}
+
+// Create validates rule, stores it and starts evaluating it.
+func (a *Alerts) Create(ctx context.Context, rule model.AlertRule) (model.AlertRule, error) {
+	rule, err := normalizeAlertRule(rule)
+	if err != nil {
+		return model.AlertRule{}, err
+	}
+
+	rules, err := a.Rules(ctx)
+	if err != nil {
+		return model.AlertRule{}, err
+	}
+	if len(rules) >= maxAlertRules {
+		return model.AlertRule{}, ErrTooManyAlertRules
+	}
+
+	rule, err = a.repo.CreateAlertRule(ctx, rule)
+	if err != nil {
+		return model.AlertRule{}, err
+	}
+
+	a.mu.Lock()
+	a.rules[rule.ID] = &rule
+	a.index()
+	a.mu.Unlock()
+	return rule, nil
+}
+
+func (a *Alerts) Delete(ctx context.Context, id int64) error {
+	err := a.repo.DeleteAlertRule(ctx, id)
+	if model.KindOf(err) == model.KindNotFound {
+		return fmt.Errorf("%w: %d", ErrUnknownAlertRule, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	delete(a.rules, id)
+	a.index()
+	a.mu.Unlock()
+	return nil
+}
+
+// History returns the events in rng, newest first, of one rule or of every
+// rule when ruleID is 0.
+func (a *Alerts) History(ctx context.Context, ruleID int64, rng model.TimeRange, limit int) ([]model.AlertEvent, error) {
+	if limit <= 0 {
+		limit = defaultAlertHistoryLimit
+	}
+	limit = min(limit, maxAlertHistoryLimit)
+	return a.repo.ListAlertEvents(ctx, ruleID, rng, limit)
+}
+
+// normalizeAlertRule checks a new rule. Prices and changes default to the
+// global exchange.
+func normalizeAlertRule(rule model.AlertRule) (model.AlertRule, error) {
+	invalid := func(format string, args ...any) (model.AlertRule, error) {
+		return model.AlertRule{}, fmt.Errorf("%w: %s", ErrInvalidAlertRule, fmt.Sprintf(format, args...))
+	}
+
+	if rule.Name == "" || len(rule.Name) > maxAlertName {
+		return invalid("name must be between 1 and %d characters", maxAlertName)
+	}
+	if rule.Symbol == "" {
+		return invalid("symbol is required")
+	}
+	if rule.Operator != model.AlertAbove && rule.Operator != model.AlertBelow {
+		return invalid("operator must be above or below")
+	}
+	if math.IsNaN(rule.Threshold) || math.IsInf(rule.Threshold, 0) {
+		return invalid("threshold must be a number")
+	}
+	if rule.Hysteresis < 0 || math.IsNaN(rule.Hysteresis) || math.IsInf(rule.Hysteresis, 0) {
+		return invalid("hysteresis must not be negative")
+	}
+	if rule.Cooldown < model.AlertEventBucket {
+		return invalid("cooldown must be at least %s", model.AlertEventBucket)
+	}
+
+	switch rule.Kind {
+	case model.AlertPrice, model.AlertChange:
+		if rule.Exchange == "" {
+			rule.Exchange = model.GlobalExchange
+		}
+		if rule.OtherExchange != "" {
+			return invalid("other_exchange is only used by spread rules")
+		}
+		if rule.Threshold <= 0 {
+			return invalid("threshold must be positive")
+		}
+	case model.AlertSpread:
+		if rule.Exchange == "" || rule.OtherExchange == "" || rule.Exchange == rule.OtherExchange {
+			return invalid("spread rules need two different exchanges")
+		}
+		if rule.Exchange == model.GlobalExchange || rule.OtherExchange == model.GlobalExchange {
+			return invalid("spread rules compare real exchanges, not %s", model.GlobalExchange)
+		}
+		if rule.Threshold < 0 {
+			return invalid("threshold must not be negative")
+		}
+	default:
+		return invalid("kind must be price, change or spread")
+	}
+
+	if rule.Kind == model.AlertChange {
+		if rule.Window < model.TimeOfAverage || rule.Window > maxAlertWindow || rule.Window%model.TimeOfAverage != 0 {
+			return invalid("window must be whole minutes between %s and %s", model.TimeOfAverage, maxAlertWindow)
+		}
+	} else if rule.Window != 0 {
+		return invalid("window is only used by change rules")
+	}
+
+	return rule, nil
+}
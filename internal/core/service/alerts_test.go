@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+func TestEvaluate(t *testing.T) {
+	rule := &model.AlertRule{Operator: model.AlertAbove, Threshold: 100, Hysteresis: 5, Cooldown: time.Minute}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		after time.Duration
+		value float64
+		want  string
+	}{
+		{0, 99, ""},
+		{time.Second, 101, model.AlertTriggered},
+		{2 * time.Second, 99, ""}, // within the hysteresis
+		{3 * time.Second, 102, ""},
+		{4 * time.Second, 95, model.AlertResolved},
+		{5 * time.Second, 101, ""}, // cooling down
+		{61 * time.Second, 101, model.AlertTriggered},
+	}
+	keys := make(map[string]bool)
+	for _, s := range steps {
+		e, ok := evaluate(rule, s.value, now.Add(s.after))
+		if e.Type != s.want {
+			t.Errorf("%v at %s: expected %q, got %q", s.value, s.after, s.want, e.Type)
+		}
+		if ok {
+			keys[e.Key()] = true
+		}
+	}
+	if len(keys) != 3 {
+		t.Errorf("expected every event to have a key of its own, got %v", keys)
+	}
+
+	// Both resolves fall into 12:01, but each ends a trigger of its own.
+	flapping := &model.AlertRule{Operator: model.AlertAbove, Threshold: 100, Cooldown: time.Minute}
+	keys = make(map[string]bool)
+	for _, s := range []struct {
+		after time.Duration
+		value float64
+	}{{30 * time.Second, 101}, {80 * time.Second, 99}, {90 * time.Second, 101}, {100 * time.Second, 99}} {
+		if e, ok := evaluate(flapping, s.value, now.Add(s.after)); ok {
+			keys[e.Key()] = true
+		}
+	}
+	if len(keys) != 4 {
+		t.Errorf("expected two triggers and two resolves with keys of their own, got %v", keys)
+	}
+
+	below := &model.AlertRule{Operator: model.AlertBelow, Threshold: 10, Hysteresis: 1}
+	if _, ok := evaluate(below, 9, now); !ok {
+		t.Error("expected a below rule to trigger under its threshold")
+	}
+	if _, ok := evaluate(below, 10.5, now); ok {
+		t.Error("expected a below rule to stay firing within its hysteresis")
+	}
+}
+
+func TestCloseHistory(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var h closeHistory
+	for i := range 10 {
+		h.add(start.Add(time.Duration(i)*time.Minute), float64(100+i))
+	}
+
+	if c, ok := h.at(start.Add(3*time.Minute + 30*time.Second)); !ok || c != 103 {
+		t.Errorf("expected the close of 12:03, got %v %v", c, ok)
+	}
+	if _, ok := h.at(start.Add(-time.Second)); ok {
+		t.Error("expected no close before the first window")
+	}
+	if _, ok := h.at(start.Add(11 * time.Minute)); ok {
+		t.Error("expected a close two windows old to be too far off")
+	}
+}
+
+type alertRepo struct {
+	rules  []model.AlertRule
+	events []model.AlertEvent
+}
+
+func (r *alertRepo) CreateAlertRule(ctx context.Context, rule model.AlertRule) (model.AlertRule, error) {
+	rule.ID = int64(len(r.rules) + 1)
+	r.rules = append(r.rules, rule)
+	return rule, nil
+}
+
+func (r *alertRepo) ListAlertRules(ctx context.Context) ([]model.AlertRule, error) {
+	return r.rules, nil
+}
+
+func (r *alertRepo) DeleteAlertRule(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (r *alertRepo) InsertAlertEvents(ctx context.Context, events []model.AlertEvent) error {
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func (r *alertRepo) ListAlertEvents(ctx context.Context, ruleID int64, rng model.TimeRange, limit int) ([]model.AlertEvent, error) {
+	return r.events, nil
+}
+
+func TestAlerts(t *testing.T) {
+	repo := &alertRepo{}
+	alerts := NewAlerts(repo)
+	ctx := context.Background()
+
+	var published []model.AlertEvent
+	alerts.OnAlert(func(e model.AlertEvent) { published = append(published, e) })
+
+	spread, err := alerts.Create(ctx, model.AlertRule{
+		Name:          "exchange1 vs exchange2",
+		Kind:          model.AlertSpread,
+		Symbol:        "BTCUSDT",
+		Exchange:      "exchange1",
+		OtherExchange: "exchange2",
+		Operator:      model.AlertAbove,
+		Threshold:     30,
+		Cooldown:      DefaultAlertCooldown,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, err := alerts.Create(ctx, model.AlertRule{
+		Name:      "ETH moves",
+		Kind:      model.AlertChange,
+		Symbol:    "ETHUSDT",
+		Operator:  model.AlertAbove,
+		Threshold: 2,
+		Window:    5 * time.Minute,
+		Cooldown:  DefaultAlertCooldown,
+	})
+	if err != nil || change.Exchange != model.GlobalExchange {
+		t.Fatalf("expected a change rule on the global exchange, got %+v (%v)", change, err)
+	}
+
+	alerts.Observe("exchange1", model.Trade{Symbol: "BTCUSDT", Price: 100000})
+	alerts.Observe("exchange2", model.Trade{Symbol: "BTCUSDT", Price: 100200})
+	alerts.Observe("exchange2", model.Trade{Symbol: "BTCUSDT", Price: 100500})
+
+	// A close from five minutes ago, then a 3% move.
+	now := time.Now()
+	alerts.ObserveCandle(model.Candle{Exchange: model.GlobalExchange, Symbol: "ETHUSDT", Start: now.Add(-6*time.Minute - time.Second), Close: 3000})
+	alerts.Observe(model.GlobalExchange, model.Trade{Symbol: "ETHUSDT", Price: 3090})
+
+	if len(published) != 2 || published[0].RuleID != spread.ID || published[1].RuleID != change.ID {
+		t.Fatalf("expected the spread and the change to trigger, got %+v", published)
+	}
+	if published[0].Value < 49 || published[0].Value > 51 {
+		t.Errorf("expected a spread of 50bps, got %v", published[0].Value)
+	}
+
+	alerts.flush(ctx)
+	if len(repo.events) != 2 {
+		t.Errorf("expected the events to be saved, got %d", len(repo.events))
+	}
+
+	rules, err := alerts.Rules(ctx)
+	if err != nil || len(rules) != 2 || !rules[0].Firing {
+		t.Errorf("expected the rules to report their state, got %+v (%v)", rules, err)
+	}
+
+	_, err = alerts.Create(ctx, model.AlertRule{Name: "x", Kind: model.AlertSpread, Symbol: "BTCUSDT", Exchange: "exchange1", Operator: model.AlertAbove, Cooldown: DefaultAlertCooldown})
+	if !errors.Is(err, ErrInvalidAlertRule) {
+		t.Errorf("expected a spread with one exchange to be rejected, got %v", err)
+	}
+	_, err = alerts.Create(ctx, model.AlertRule{Name: "x", Kind: model.AlertPrice, Symbol: "BTCUSDT", Operator: model.AlertAbove, Threshold: 1, Cooldown: time.Second})
+	if !errors.Is(err, ErrInvalidAlertRule) {
+		t.Errorf("expected a cooldown shorter than an event bucket to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+// apiKeyPrefix starts every issued key, so keys are easy to spot in leaks.
+const apiKeyPrefix = "mf_"
+
+// authCacheTTL is how long a verified key is trusted without asking the
+// repository again. A key revoked on another instance keeps working for at
+// most this long.
+const authCacheTTL = 30 * time.Second
+
+var (
+	ErrUnauthenticated = model.NewError(model.KindUnauthenticated, "unauthenticated", "a valid api key is required")
+	ErrForbidden       = model.NewError(model.KindForbidden, "forbidden", "the api key does not allow this request")
+	ErrInvalidRole     = model.NewError(model.KindInvalid, "invalid_role", "role must be read, operator or admin")
+	ErrInvalidKeyName  = model.NewError(model.KindInvalid, "invalid_key_name", "name must be between 1 and 100 characters")
+	ErrUnknownAPIKey   = model.NewError(model.KindNotFound, "unknown_api_key", "no active api key has this id")
+)
+
+type authEntry struct {
+	key     model.APIKey
+	expires time.Time
+}
+
+// Auth issues, verifies and revokes API keys. Only the SHA-256 of a key is
+// stored; keys are random enough that a slow hash would add nothing.
+type Auth struct {
+	repo core.APIKeyRepository
+
+	mu    sync.Mutex
+	cache map[string]authEntry
+}
+
+func NewAuth(repo core.APIKeyRepository) *Auth {
+	return &Auth{
+		repo:  repo,
+		cache: make(map[string]authEntry),
+	}
+}
+
+func hashKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Issue creates a key. The returned secret is not stored and cannot be
+// recovered later.
+func (a *Auth) Issue(ctx context.Context, name string, role model.Role) (model.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return model.APIKey{}, "", ErrInvalidKeyName
+	}
+	if !role.Valid() {
+		return model.APIKey{}, "", ErrInvalidRole
+	}
+
+	var b [24]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return model.APIKey{}, "", err
+	}
+	secret := apiKeyPrefix + hex.EncodeToString(b[:])
+
+	key, err := a.repo.CreateAPIKey(ctx, model.APIKey{
+		Name:   name,
+		Prefix: secret[:len(apiKeyPrefix)+8],
+		Role:   role,
+	}, hashKey(secret))
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+	return key, secret, nil
+}
+
+// Bootstrap makes sure secret is a usable admin key, so a fresh deployment
+// can issue its first keys. A key that was revoked stays revoked.
+func (a *Auth) Bootstrap(ctx context.Context, secret string) error {
+	_, err := a.repo.GetAPIKeyByHash(ctx, hashKey(secret))
+	if err == nil {
+		return nil
+	}
+	if model.KindOf(err) != model.KindNotFound {
+		return err
+	}
+
+	prefix := secret
+	if len(prefix) > 8 {
+		prefix = prefix[:8]
+	}
+	_, err = a.repo.CreateAPIKey(ctx, model.APIKey{
+		Name:   "bootstrap",
+		Prefix: prefix,
+		Role:   model.RoleAdmin,
+	}, hashKey(secret))
+	if model.KindOf(err) == model.KindConflict {
+		return errors.New("the bootstrap api key was revoked, issue a new admin key or change ADMIN_API_KEY")
+	}
+	return err
+}
+
+// Authenticate returns the active key matching secret.
+func (a *Auth) Authenticate(ctx context.Context, secret string) (model.APIKey, error) {
+	if secret == "" {
+		return model.APIKey{}, ErrUnauthenticated
+	}
+
+	hash := hashKey(secret)
+	now := time.Now()
+
+	a.mu.Lock()
+	entry, ok := a.cache[hash]
+	a.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.key, nil
+	}
+
+	key, err := a.repo.GetAPIKeyByHash(ctx, hash)
+	if model.KindOf(err) == model.KindNotFound {
+		return model.APIKey{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	a.mu.Lock()
+	for h, e := range a.cache {
+		if now.After(e.expires) {
+			delete(a.cache, h)
+		}
+	}
+	a.cache[hash] = authEntry{key: key, expires: now.Add(authCacheTTL)}
+	a.mu.Unlock()
+
+	return key, nil
+}
+
+// Authorize checks that key may do what required allows.
+func Authorize(key model.APIKey, required model.Role) error {
+	if !key.Role.Allows(required) {
+		return ErrForbidden.WithDetails(map[string]any{"role": string(key.Role), "required_role": string(required)})
+	}
+	return nil
+}
+
+func (a *Auth) List(ctx context.Context) ([]model.APIKey, error) {
+	return a.repo.ListAPIKeys(ctx)
+}
+
+// Revoke disables a key. It stops working on this instance at once.
+func (a *Auth) Revoke(ctx context.Context, id int64) error {
+	err := a.repo.RevokeAPIKey(ctx, id)
+	if model.KindOf(err) == model.KindNotFound {
+		return fmt.Errorf("%w: %d", ErrUnknownAPIKey, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	for h, e := range a.cache {
+		if e.key.ID == id {
+			delete(a.cache, h)
+		}
+	}
+	a.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"marketflow/internal/core/model"
+)
+
+var errNoKey = model.NewError(model.KindNotFound, "no_data", "no data found")
+
+type memoryAPIKeys struct {
+	keys    []model.APIKey
+	hashes  []string
+	revoked map[int64]bool
+	lookups int
+}
+
+func (m *memoryAPIKeys) CreateAPIKey(ctx context.Context, key model.APIKey, hash string) (model.APIKey, error) {
+	key.ID = int64(len(m.keys) + 1)
+	m.keys = append(m.keys, key)
+	m.hashes = append(m.hashes, hash)
+	return key, nil
+}
+
+func (m *memoryAPIKeys) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	m.lookups++
+	for i, h := range m.hashes {
+		if h == hash && !m.revoked[m.keys[i].ID] {
+			return m.keys[i], nil
+		}
+	}
+	return model.APIKey{}, errNoKey
+}
+
+func (m *memoryAPIKeys) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	return m.keys, nil
+}
+
+func (m *memoryAPIKeys) RevokeAPIKey(ctx context.Context, id int64) error {
+	if id < 1 || id > int64(len(m.keys)) || m.revoked[id] {
+		return errNoKey
+	}
+	m.revoked[id] = true
+	return nil
+}
+
+func TestAuth(t *testing.T) {
+	repo := &memoryAPIKeys{revoked: make(map[int64]bool)}
+	auth := NewAuth(repo)
+	ctx := context.Background()
+
+	key, secret, err := auth.Issue(ctx, "dashboard", model.RoleOperator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, key.Prefix) || repo.hashes[0] == secret {
+		t.Errorf("unexpected key %+v for secret %q", key, secret)
+	}
+
+	for range 2 {
+		got, err := auth.Authenticate(ctx, secret)
+		if err != nil || got.ID != key.ID {
+			t.Fatalf("expected key %d, got %+v (%v)", key.ID, got, err)
+		}
+	}
+	if repo.lookups != 1 {
+		t.Errorf("expected the second lookup to be cached, got %d lookups", repo.lookups)
+	}
+
+	if _, err := auth.Authenticate(ctx, secret+"x"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected %v, got %v", ErrUnauthenticated, err)
+	}
+
+	if err := auth.Revoke(ctx, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.Authenticate(ctx, secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected a revoked key to fail with %v, got %v", ErrUnauthenticated, err)
+	}
+	if err := auth.Revoke(ctx, key.ID); !errors.Is(err, ErrUnknownAPIKey) {
+		t.Errorf("expected %v, got %v", ErrUnknownAPIKey, err)
+	}
+
+	if _, _, err := auth.Issue(ctx, "x", "root"); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("expected %v, got %v", ErrInvalidRole, err)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		role, required model.Role
+		ok             bool
+	}{
+		{model.RoleRead, model.RoleRead, true},
+		{model.RoleRead, model.RoleOperator, false},
+		{model.RoleOperator, model.RoleOperator, true},
+		{model.RoleOperator, model.RoleAdmin, false},
+		{model.RoleAdmin, model.RoleOperator, true},
+		{"", model.RoleRead, false},
+	}
+	for _, tt := range tests {
+		err := Authorize(model.APIKey{Role: tt.role}, tt.required)
+		if (err == nil) != tt.ok {
+			t.Errorf("%q for %q: unexpected result %v", tt.role, tt.required, err)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+// DefaultSubscriberBuffer is how many events a subscriber may fall behind
+// before it is evicted.
+const DefaultSubscriberBuffer = 256
+
+// Filter selects the events a subscriber receives. Empty fields match
+// everything, except that the global pseudo exchange, which repeats every
+// event of the real exchanges, has to be named in Exchanges.
+type Filter struct {
+	Topics    []string
+	Symbols   []string
+	Exchanges []string
+}
+
+func (f Filter) Match(e model.Event) bool {
+	if len(f.Exchanges) == 0 && e.Exchange == model.GlobalExchange {
+		return false
+	}
+	return matches(f.Topics, e.Topic) &&
+		(e.Symbol == "" || matches(f.Symbols, e.Symbol)) &&
+		(e.Exchange == "" || matches(f.Exchanges, e.Exchange))
+}
+
+func matches(values []string, v string) bool {
+	return len(values) == 0 || slices.Contains(values, v)
+}
+
+// Subscriber receives the events it matches on Events until it unsubscribes,
+// falls too far behind or the broadcaster is closed.
+type Subscriber struct {
+	match   func(model.Event) bool
+	events  chan model.Event
+	evicted bool
+}
+
+func (s *Subscriber) Events() <-chan model.Event {
+	return s.events
+}
+
+// Evicted reports whether the events channel was closed because the
+// subscriber did not keep up. It is only meaningful once Events is closed.
+func (s *Subscriber) Evicted() bool {
+	return s.evicted
+}
+
+// retainedTopics keep their latest event per exchange and symbol, so that new
+// subscribers can start from a snapshot.
+var retainedTopics = []string{model.TopicTicks, model.TopicCandles, model.TopicConsensus}
+
+type retainKey struct {
+	topic    string
+	exchange string
+	symbol   string
+}
+
+// Broadcaster fans events out to every subscriber without ever blocking the
+// publisher: a subscriber whose buffer is full is dropped.
+type Broadcaster struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscriber]struct{}
+	closed      bool
+
+	retainedMu sync.Mutex
+	retained   map[retainKey]model.Event
+}
+
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		subscribers: make(map[*Subscriber]struct{}),
+		retained:    make(map[retainKey]model.Event),
+	}
+}
+
+// Observe publishes every tick on model.TopicTicks at the time of the tick.
+func (b *Broadcaster) Observe(exchange string, trade model.Trade) {
+	at := trade.At(time.Now())
+	b.Publish(model.Event{
+		Topic:    model.TopicTicks,
+		Exchange: exchange,
+		Symbol:   trade.Symbol,
+		Time:     at,
+		Data: model.Quote{
+			Exchange:  exchange,
+			Symbol:    trade.Symbol,
+			Price:     trade.Price,
+			Timestamp: at,
+		},
+	})
+}
+
+// PublishCandle publishes a finalized candle on model.TopicCandles at the end
+// of its window.
+func (b *Broadcaster) PublishCandle(c model.Candle) {
+	b.Publish(model.Event{
+		Topic:    model.TopicCandles,
+		Exchange: c.Exchange,
+		Symbol:   c.Symbol,
+		Time:     c.Start.Add(model.TimeOfAverage),
+		Data:     c,
+		Key:      c.Key(),
+	})
+}
+
+// PublishConsensus publishes a consensus price on model.TopicConsensus.
+func (b *Broadcaster) PublishConsensus(p model.ConsensusPrice) {
+	b.Publish(model.Event{
+		Topic:  model.TopicConsensus,
+		Symbol: p.Symbol,
+		Time:   p.Timestamp,
+		Data:   p,
+	})
+}
+
+// PublishDivergence publishes a spread divergence on model.TopicAlerts.
+func (b *Broadcaster) PublishDivergence(e model.DivergenceEvent) {
+	b.Publish(model.Event{
+		Topic:  model.TopicAlerts,
+		Symbol: e.High.Symbol,
+		Time:   e.Time,
+		Data:   e,
+	})
+}
+
+// PublishAlert publishes an alert rule event on model.TopicAlerts. Spreads
+// and rules on the global exchange are published without an exchange, so
+// that subscribers who do not filter on exchanges still receive them.
+func (b *Broadcaster) PublishAlert(e model.AlertEvent) {
+	event := model.Event{
+		Topic:  model.TopicAlerts,
+		Symbol: e.Symbol,
+		Time:   e.Time,
+		Data:   e,
+		Key:    e.Key(),
+	}
+	if e.Kind != model.AlertSpread && e.Exchange != model.GlobalExchange {
+		event.Exchange = e.Exchange
+	}
+	b.Publish(event)
+}
+
+func (b *Broadcaster) Publish(e model.Event) {
+	if slices.Contains(retainedTopics, e.Topic) {
+		b.retainedMu.Lock()
+		b.retained[retainKey{topic: e.Topic, exchange: e.Exchange, symbol: e.Symbol}] = e
+		b.retainedMu.Unlock()
+	}
+
+	var slow []*Subscriber
+
+	b.mu.RLock()
+	for s := range b.subscribers {
+		if !s.match(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			slow = append(slow, s)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, s := range slow {
+		if b.remove(s, true) {
+			slog.Warn("evicted slow subscriber", "topic", e.Topic, "buffer", cap(s.events))
+		}
+	}
+}
+
+// Subscribe registers a subscriber with room for buffer events. It returns nil
+// once the broadcaster is closed.
+func (b *Broadcaster) Subscribe(filter Filter, buffer int) *Subscriber {
+	return b.SubscribeFunc(filter.Match, buffer)
+}
+
+// SubscribeFunc is Subscribe with an arbitrary match function, for subscribers
+// whose interests change over time. match is called from the publishers and
+// must not block.
+func (b *Broadcaster) SubscribeFunc(match func(model.Event) bool, buffer int) *Subscriber {
+	if buffer <= 0 {
+		buffer = DefaultSubscriberBuffer
+	}
+
+	s := &Subscriber{
+		match:  match,
+		events: make(chan model.Event, buffer),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.subscribers[s] = struct{}{}
+	return s
+}
+
+// Snapshot returns the latest retained event of every exchange and symbol
+// that match accepts, oldest first.
+func (b *Broadcaster) Snapshot(match func(model.Event) bool) []model.Event {
+	b.retainedMu.Lock()
+	var events []model.Event
+	for _, e := range b.retained {
+		if match(e) {
+			events = append(events, e)
+		}
+	}
+	b.retainedMu.Unlock()
+
+	slices.SortFunc(events, func(a, b model.Event) int {
+		return a.Time.Compare(b.Time)
+	})
+	return events
+}
+
+func (b *Broadcaster) Unsubscribe(s *Subscriber) {
+	b.remove(s, false)
+}
+
+// Close ends every subscription so that long-lived connections return.
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subscribers {
+		delete(b.subscribers, s)
+		close(s.events)
+	}
+}
+
+// remove closes the events channel of s. Publishers only send while holding
+// the read lock, so no send can race with the close.
+func (b *Broadcaster) remove(s *Subscriber, evicted bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[s]; !ok {
+		return false
+	}
+	delete(b.subscribers, s)
+	s.evicted = evicted
+	close(s.events)
+	return true
+}
+
+// Conflator keeps the latest tick of every pair on every exchange between
+// flushes, in the order the pairs first appeared.
+type Conflator struct {
+	latest map[string]model.Quote
+	order  []string
+}
+
+func NewConflator() *Conflator {
+	return &Conflator{latest: make(map[string]model.Quote)}
+}
+
+func (c *Conflator) Add(q model.Quote) {
+	key := q.Exchange + ":" + q.Symbol
+	if _, ok := c.latest[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.latest[key] = q
+}
+
+// Flush returns the held ticks and forgets them.
+func (c *Conflator) Flush() []model.Quote {
+	quotes := make([]model.Quote, len(c.order))
+	for i, key := range c.order {
+		quotes[i] = c.latest[key]
+	}
+	clear(c.latest)
+	c.order = c.order[:0]
+	return quotes
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+func TestBroadcaster_Filter(t *testing.T) {
+	b := NewBroadcaster()
+	sub := b.Subscribe(Filter{Topics: []string{model.TopicTicks}, Symbols: []string{model.BTCUSDT}}, 4)
+
+	b.Observe("exchange1", model.Trade{Symbol: model.ETHUSDT, Price: 1})
+	b.Observe("exchange2", model.Trade{Symbol: model.BTCUSDT, Price: 2})
+	b.Publish(model.Event{Topic: "other", Symbol: model.BTCUSDT})
+
+	if n := len(sub.Events()); n != 1 {
+		t.Fatalf("expected 1 event, got %d", n)
+	}
+	e := <-sub.Events()
+	if q := e.Data.(model.Quote); q.Exchange != "exchange2" || q.Price != 2 {
+		t.Errorf("unexpected tick %+v", q)
+	}
+}
+
+func TestBroadcaster_EventTimes(t *testing.T) {
+	b := NewBroadcaster()
+	sub := b.Subscribe(Filter{}, 4)
+	minute := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	b.Observe("exchange1", model.Trade{Symbol: model.BTCUSDT, Price: 1, Timestamp: minute.Add(time.Second).UnixMilli()})
+	b.PublishCandle(model.Candle{Exchange: "exchange1", Symbol: model.BTCUSDT, Start: minute})
+
+	if e := <-sub.Events(); !e.Time.Equal(minute.Add(time.Second)) || !e.Data.(model.Quote).Timestamp.Equal(e.Time) {
+		t.Errorf("expected the tick at the time of the trade, got %+v", e)
+	}
+	if e := <-sub.Events(); !e.Time.Equal(minute.Add(model.TimeOfAverage)) {
+		t.Errorf("expected the candle at the end of its window, got %v", e.Time)
+	}
+}
+
+func TestBroadcaster_EvictsSlowSubscriber(t *testing.T) {
+	b := NewBroadcaster()
+	slow := b.Subscribe(Filter{}, 2)
+	fast := b.Subscribe(Filter{}, 8)
+
+	for i := 0; i < 3; i++ {
+		b.Observe("exchange1", model.Trade{Symbol: model.BTCUSDT, Price: float64(i)})
+	}
+
+	n := 0
+	for range slow.Events() {
+		n++
+	}
+	if n != 2 || !slow.Evicted() {
+		t.Errorf("expected slow subscriber to be evicted after 2 events, got %d (evicted %v)", n, slow.Evicted())
+	}
+	if len(fast.Events()) != 3 {
+		t.Errorf("expected the other subscriber to keep all 3 events, got %d", len(fast.Events()))
+	}
+
+	b.Close()
+	if _, ok := <-fast.Events(); !ok {
+		t.Errorf("expected buffered events to survive close")
+	}
+	if b.Subscribe(Filter{}, 1) != nil {
+		t.Errorf("expected no subscriptions after close")
+	}
+	b.Unsubscribe(fast)
+}
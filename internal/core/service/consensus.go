@@ -0,0 +1,239 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+const (
+	ConsensusMedian       = "median"
+	ConsensusTrimmedMean  = "trimmed_mean"
+	ConsensusWeightedMean = "weighted_mean"
+)
+
+var (
+	ErrNoConsensus            = model.NewError(model.KindNotFound, "no_consensus", "no exchange has a fresh price for this symbol")
+	ErrUnknownConsensusMethod = errors.New("unknown consensus method")
+)
+
+// minVenuesForOutliers is the number of fresh venues needed before any of them
+// can be called an outlier; with two venues there is no majority to compare
+// against.
+const minVenuesForOutliers = 3
+
+type ConsensusConfig struct {
+	Method string
+	// Weights are used by the weighted mean. Exchanges without a weight
+	// count as 1, exchanges with a weight of 0 or less are left out.
+	Weights map[string]float64
+	// MaxAge is how old an exchange's latest price may be before the
+	// exchange is left out as stale.
+	MaxAge time.Duration
+	// MaxDeviationBps is how far, in basis points of the median, a price may
+	// be before the exchange is left out as an outlier.
+	MaxDeviationBps float64
+	// TrimFraction is the share of prices cut from each end by the trimmed
+	// mean.
+	TrimFraction float64
+}
+
+func (c ConsensusConfig) Validate() error {
+	switch c.Method {
+	case ConsensusMedian, ConsensusTrimmedMean, ConsensusWeightedMean:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownConsensusMethod, c.Method)
+	}
+
+	if c.TrimFraction < 0 || c.TrimFraction >= 0.5 {
+		return fmt.Errorf("trim fraction must be in [0, 0.5), got %v", c.TrimFraction)
+	}
+
+	return nil
+}
+
+// Consensus keeps the latest price of every pair on every exchange and derives
+// a cross-exchange price from them.
+type Consensus struct {
+	config ConsensusConfig
+
+	mu        sync.RWMutex
+	latest    map[string]map[string]model.Quote
+	listeners []func(model.ConsensusPrice)
+}
+
+func NewConsensus(config ConsensusConfig) *Consensus {
+	return &Consensus{
+		config: config,
+		latest: make(map[string]map[string]model.Quote),
+	}
+}
+
+// OnPrice registers f to be called with the new consensus price of a pair
+// every time one of its exchanges reports a tick.
+func (c *Consensus) OnPrice(f func(model.ConsensusPrice)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, f)
+}
+
+func (c *Consensus) Observe(exchange string, trade model.Trade) {
+	if exchange == model.GlobalExchange {
+		return
+	}
+
+	c.mu.Lock()
+	quotes, ok := c.latest[trade.Symbol]
+	if !ok {
+		quotes = make(map[string]model.Quote)
+		c.latest[trade.Symbol] = quotes
+	}
+	quotes[exchange] = model.Quote{
+		Exchange:  exchange,
+		Symbol:    trade.Symbol,
+		Price:     trade.Price,
+		Timestamp: trade.At(time.Now()),
+	}
+	listeners := c.listeners
+	c.mu.Unlock()
+
+	if len(listeners) == 0 {
+		return
+	}
+
+	price, err := c.Price(trade.Symbol)
+	if err != nil {
+		return
+	}
+	for _, f := range listeners {
+		f(price)
+	}
+}
+
+func (c *Consensus) Price(symbol string) (model.ConsensusPrice, error) {
+	c.mu.RLock()
+	quotes := make([]model.Quote, 0, len(c.latest[symbol]))
+	for _, q := range c.latest[symbol] {
+		quotes = append(quotes, q)
+	}
+	c.mu.RUnlock()
+
+	return c.compute(symbol, quotes, time.Now())
+}
+
+func (c *Consensus) compute(symbol string, quotes []model.Quote, now time.Time) (model.ConsensusPrice, error) {
+	result := model.ConsensusPrice{
+		Symbol: symbol,
+		Method: c.config.Method,
+	}
+
+	sort.Slice(quotes, func(i, j int) bool {
+		return quotes[i].Exchange < quotes[j].Exchange
+	})
+
+	fresh := make([]model.Quote, 0, len(quotes))
+	for _, q := range quotes {
+		if c.config.MaxAge > 0 && now.Sub(q.Timestamp) > c.config.MaxAge {
+			result.Excluded = append(result.Excluded, model.ConsensusExclusion{Quote: q, Reason: "stale"})
+			continue
+		}
+		if c.config.Method == ConsensusWeightedMean && c.weight(q.Exchange) <= 0 {
+			result.Excluded = append(result.Excluded, model.ConsensusExclusion{Quote: q, Reason: "zero weight"})
+			continue
+		}
+		fresh = append(fresh, q)
+	}
+
+	contributors := fresh
+	if len(fresh) >= minVenuesForOutliers && c.config.MaxDeviationBps > 0 {
+		mid := median(prices(fresh))
+		contributors = make([]model.Quote, 0, len(fresh))
+		for _, q := range fresh {
+			if math.Abs(q.Price-mid)/mid*10000 > c.config.MaxDeviationBps {
+				result.Excluded = append(result.Excluded, model.ConsensusExclusion{Quote: q, Reason: "outlier"})
+				continue
+			}
+			contributors = append(contributors, q)
+		}
+	}
+
+	if len(contributors) == 0 {
+		return result, ErrNoConsensus
+	}
+
+	switch c.config.Method {
+	case ConsensusMedian:
+		result.Price = median(prices(contributors))
+	case ConsensusTrimmedMean:
+		result.Price = trimmedMean(prices(contributors), c.config.TrimFraction)
+	case ConsensusWeightedMean:
+		var sum, weights float64
+		for _, q := range contributors {
+			w := c.weight(q.Exchange)
+			sum += q.Price * w
+			weights += w
+		}
+		result.Price = sum / weights
+	default:
+		return result, fmt.Errorf("%w: %q", ErrUnknownConsensusMethod, c.config.Method)
+	}
+
+	for _, q := range contributors {
+		weight := 1.0
+		if c.config.Method == ConsensusWeightedMean {
+			weight = c.weight(q.Exchange)
+		}
+		result.Contributors = append(result.Contributors, model.ConsensusContributor{Quote: q, Weight: weight})
+		if q.Timestamp.After(result.Timestamp) {
+			result.Timestamp = q.Timestamp
+		}
+	}
+
+	return result, nil
+}
+
+func (c *Consensus) weight(exchange string) float64 {
+	if w, ok := c.config.Weights[exchange]; ok {
+		return w
+	}
+	return 1
+}
+
+func prices(quotes []model.Quote) []float64 {
+	res := make([]float64, 0, len(quotes))
+	for _, q := range quotes {
+		res = append(res, q.Price)
+	}
+	return res
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func trimmedMean(values []float64, fraction float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	cut := int(float64(len(sorted)) * fraction)
+	kept := sorted[cut : len(sorted)-cut]
+
+	var sum float64
+	for _, v := range kept {
+		sum += v
+	}
+	return sum / float64(len(kept))
+}
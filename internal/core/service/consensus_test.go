@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+func TestConsensus_Compute(t *testing.T) {
+	now := time.Now()
+	quote := func(exchange string, price float64, age time.Duration) model.Quote {
+		return model.Quote{Exchange: exchange, Symbol: model.BTCUSDT, Price: price, Timestamp: now.Add(-age)}
+	}
+
+	tests := []struct {
+		name     string
+		config   ConsensusConfig
+		quotes   []model.Quote
+		want     float64
+		excluded map[string]string
+		err      error
+	}{
+		{
+			name:   "median",
+			config: ConsensusConfig{Method: ConsensusMedian, MaxAge: 10 * time.Second, MaxDeviationBps: 100},
+			quotes: []model.Quote{quote("exchange1", 100, 0), quote("exchange2", 100.2, 0), quote("exchange3", 100.4, 0)},
+			want:   100.2,
+		},
+		{
+			name:     "stale venue is left out",
+			config:   ConsensusConfig{Method: ConsensusMedian, MaxAge: 10 * time.Second},
+			quotes:   []model.Quote{quote("exchange1", 100, 0), quote("exchange2", 102, 0), quote("exchange3", 50, time.Minute)},
+			want:     101,
+			excluded: map[string]string{"exchange3": "stale"},
+		},
+		{
+			name:     "outlier is left out",
+			config:   ConsensusConfig{Method: ConsensusMedian, MaxAge: 10 * time.Second, MaxDeviationBps: 100},
+			quotes:   []model.Quote{quote("exchange1", 100, 0), quote("exchange2", 100.4, 0), quote("exchange3", 120, 0)},
+			want:     100.2,
+			excluded: map[string]string{"exchange3": "outlier"},
+		},
+		{
+			name:   "trimmed mean",
+			config: ConsensusConfig{Method: ConsensusTrimmedMean, TrimFraction: 0.25},
+			quotes: []model.Quote{quote("a", 1, 0), quote("b", 2, 0), quote("c", 3, 0), quote("d", 100, 0)},
+			want:   2.5,
+		},
+		{
+			name: "weighted mean",
+			config: ConsensusConfig{
+				Method:  ConsensusWeightedMean,
+				Weights: map[string]float64{"exchange1": 3, "exchange3": 0},
+			},
+			quotes:   []model.Quote{quote("exchange1", 100, 0), quote("exchange2", 104, 0), quote("exchange3", 1, 0)},
+			want:     101,
+			excluded: map[string]string{"exchange3": "zero weight"},
+		},
+		{
+			name:   "nothing fresh",
+			config: ConsensusConfig{Method: ConsensusMedian, MaxAge: time.Second},
+			quotes: []model.Quote{quote("exchange1", 100, time.Minute)},
+			err:    ErrNoConsensus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConsensus(tt.config)
+
+			got, err := c.compute(model.BTCUSDT, tt.quotes, now)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected error %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+
+			if math.Abs(got.Price-tt.want) > 1e-9 {
+				t.Errorf("expected price %v, got %v", tt.want, got.Price)
+			}
+
+			if len(got.Excluded) != len(tt.excluded) {
+				t.Fatalf("expected %d excluded venues, got %d", len(tt.excluded), len(got.Excluded))
+			}
+			for _, e := range got.Excluded {
+				if tt.excluded[e.Exchange] != e.Reason {
+					t.Errorf("expected %s to be excluded as %q, got %q", e.Exchange, tt.excluded[e.Exchange], e.Reason)
+				}
+			}
+		})
+	}
+}
+
+func TestConsensus_ObserveTickTime(t *testing.T) {
+	c := NewConsensus(ConsensusConfig{Method: ConsensusMedian})
+	var published []model.ConsensusPrice
+	c.OnPrice(func(p model.ConsensusPrice) { published = append(published, p) })
+
+	at := time.Now().Add(-time.Second).Truncate(time.Millisecond)
+	c.Observe("exchange1", model.Trade{Symbol: model.BTCUSDT, Price: 100, Timestamp: at.UnixMilli()})
+
+	if len(published) != 1 || !published[0].Timestamp.Equal(at) {
+		t.Errorf("expected the consensus to carry the time of the tick, got %+v", published)
+	}
+}
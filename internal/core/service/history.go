@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core/model"
+)
+
+const (
+	defaultHistoryLimit = 1000
+	maxHistoryLimit     = 10000
+)
+
+var (
+	ErrInvalidCursor = model.NewError(model.KindInvalid, "invalid_cursor", "invalid cursor")
+	// ErrNoRawHistory rejects a history of the raw ticks, which are only kept
+	// in Redis for a few minutes.
+	ErrNoRawHistory = model.NewError(model.KindInvalid, "raw_history_unavailable", "raw ticks are not archived; use 1m or a rollup")
+)
+
+// GetHistory returns a page of the history of a pair in rng at resolution,
+// the name of the market rows or a rollup. cursor is the Next of the previous
+// page, or empty for the first.
+func (s *Stats) GetHistory(ctx context.Context, exchange, symbol, resolution string, rng model.TimeRange, cursor string, limit int) (model.HistoryPage, error) {
+	if resolution == "" {
+		resolution = model.MarketResolution.Name
+	}
+	if resolution == model.RawResolution {
+		return model.HistoryPage{}, ErrNoRawHistory
+	}
+	if _, ok := model.ResolutionByName(resolution); !ok {
+		return model.HistoryPage{}, fmt.Errorf("%w: %q", ErrUnknownResolution, resolution)
+	}
+
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	limit = min(limit, maxHistoryLimit)
+
+	arg := storage.HistoryParams{
+		PairName:   symbol,
+		Exchange:   exchange,
+		Resolution: resolution,
+		From:       rng.From,
+		To:         rng.To,
+		// One more row than asked for tells whether there is another page.
+		Limit: limit + 1,
+	}
+	if cursor != "" {
+		var err error
+		if arg.After, err = decodeCursor(cursor, resolution); err != nil {
+			return model.HistoryPage{}, err
+		}
+	}
+
+	points, err := s.repo.GetHistory(ctx, arg)
+	if err != nil {
+		return model.HistoryPage{}, err
+	}
+
+	page := model.HistoryPage{Resolution: resolution, Points: points}
+	if len(points) > limit {
+		page.Points = points[:limit]
+		page.Next = encodeCursor(resolution, page.Points[limit-1])
+	}
+	return page, nil
+}
+
+// encodeCursor makes an opaque cursor that resumes a history after p. It
+// carries its resolution so that it cannot be replayed against another one.
+func encodeCursor(resolution string, p model.HistoryPoint) string {
+	raw := resolution + ":" + strconv.FormatInt(p.Time.UnixNano(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor, resolution string) (time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+
+	prefix, nanos, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, ErrInvalidCursor
+	}
+	if prefix != resolution {
+		return time.Time{}, fmt.Errorf("%w: cursor belongs to resolution %q", ErrInvalidCursor, prefix)
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return time.Unix(0, n).UTC(), nil
+}
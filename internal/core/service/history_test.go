@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+// historyRepo serves a fixed history the way the keyset queries do.
+type historyRepo struct {
+	core.Repository
+	points []model.HistoryPoint
+}
+
+func (r historyRepo) GetHistory(ctx context.Context, arg storage.HistoryParams) ([]model.HistoryPoint, error) {
+	var page []model.HistoryPoint
+	for _, p := range r.points {
+		if (arg.After.IsZero() || p.Time.After(arg.After)) && len(page) < arg.Limit {
+			page = append(page, p)
+		}
+	}
+	return page, nil
+}
+
+func TestStats_GetHistory(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := historyRepo{}
+	for i := range 5 {
+		repo.points = append(repo.points, model.HistoryPoint{Time: start.Add(time.Duration(i) * time.Minute), TickCount: int64(i + 1)})
+	}
+	stats := NewStats(repo)
+	rng := model.TimeRange{From: start, To: start.Add(time.Hour)}
+
+	var counts []int64
+	cursor := ""
+	for range 5 {
+		page, err := stats.GetHistory(context.Background(), "binance", "BTCUSDT", "1m", rng, cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range page.Points {
+			counts = append(counts, p.TickCount)
+		}
+		if cursor = page.Next; cursor == "" {
+			break
+		}
+	}
+	if len(counts) != 5 || counts[0] != 1 || counts[4] != 5 {
+		t.Errorf("expected every row once, got %v", counts)
+	}
+
+	_, err := stats.GetHistory(context.Background(), "binance", "BTCUSDT", "1h", rng, encodeCursor("1m", repo.points[0]), 2)
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected a 1m cursor to be rejected for 1h, got %v", err)
+	}
+	if _, err := stats.GetHistory(context.Background(), "binance", "BTCUSDT", model.RawResolution, rng, "", 2); !errors.Is(err, ErrNoRawHistory) {
+		t.Errorf("expected the raw ticks to be rejected, got %v", err)
+	}
+	if _, err := stats.GetHistory(context.Background(), "binance", "BTCUSDT", "1m", rng, "not a cursor", 2); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected a garbled cursor to be rejected, got %v", err)
+	}
+	if _, err := stats.GetHistory(context.Background(), "binance", "BTCUSDT", "2m", rng, "", 2); !errors.Is(err, ErrUnknownResolution) {
+		t.Errorf("expected an unknown resolution to be rejected, got %v", err)
+	}
+}
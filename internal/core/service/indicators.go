@@ -0,0 +1,393 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+const (
+	IndicatorSMA       = "sma"
+	IndicatorEMA       = "ema"
+	IndicatorRSI       = "rsi"
+	IndicatorBollinger = "bollinger"
+	IndicatorMACD      = "macd"
+)
+
+const (
+	// indicatorHistory is how many candles are kept per series.
+	indicatorHistory = 500
+	// maxIndicatorWindow leaves enough history for an EMA or RSI to settle.
+	maxIndicatorWindow = 200
+	// rollupSeriesTTL is how long a rollup series is served from memory; the
+	// rollups are rebuilt in the background, so they cannot be appended to.
+	rollupSeriesTTL = RollupTicker
+	// marketSeriesTTL is how long a one-minute series is kept without being
+	// read. Finalized windows keep it current until then.
+	marketSeriesTTL = time.Hour
+	// maxIndicatorSeries caps the cached series; the least recently read one
+	// is evicted first.
+	maxIndicatorSeries = 1000
+
+	macdFast   = 12
+	macdSlow   = 26
+	macdSignal = 9
+
+	bollingerWidth = 2
+)
+
+var (
+	ErrUnknownIndicator  = model.NewError(model.KindInvalid, "unknown_indicator", "unknown indicator type")
+	ErrUnknownResolution = model.NewError(model.KindInvalid, "unknown_interval", "unknown interval")
+	ErrInvalidWindow     = model.NewError(model.KindInvalid, "invalid_window", fmt.Sprintf("window must be between 2 and %d", maxIndicatorWindow))
+	ErrNotEnoughHistory  = model.NewError(model.KindNotFound, "not_enough_history", "not enough history for this window")
+)
+
+var defaultIndicatorWindows = map[string]int{
+	IndicatorSMA:       20,
+	IndicatorEMA:       20,
+	IndicatorRSI:       14,
+	IndicatorBollinger: 20,
+	IndicatorMACD:      macdSlow,
+}
+
+type seriesKey struct {
+	exchange   string
+	symbol     string
+	resolution string
+}
+
+type series struct {
+	candles []model.Candle
+	loaded  time.Time
+	used    time.Time
+}
+
+// fresh reports whether s can still be served at now.
+func (s *series) fresh(resolution string, now time.Time) bool {
+	if resolution == model.MarketResolution.Name {
+		return now.Sub(s.used) < marketSeriesTTL
+	}
+	return now.Sub(s.loaded) < rollupSeriesTTL
+}
+
+// Indicators computes technical indicators from the candle history. Histories
+// are cached per pair, exchange and resolution; one-minute histories are
+// extended with every window the aggregator finalizes. Only pairs in the
+// symbols registry with some history are cached.
+type Indicators struct {
+	repo    core.Repository
+	symbols *Symbols
+	now     func() time.Time
+
+	mu     sync.Mutex
+	series map[seriesKey]*series
+}
+
+func NewIndicators(repo core.Repository, symbols *Symbols) *Indicators {
+	return &Indicators{
+		repo:    repo,
+		symbols: symbols,
+		now:     time.Now,
+		series:  make(map[seriesKey]*series),
+	}
+}
+
+// Observe appends a finalized one-minute candle to its series if that series
+// is cached.
+func (in *Indicators) Observe(candle model.Candle) {
+	key := seriesKey{exchange: candle.Exchange, symbol: candle.Symbol, resolution: candle.Resolution}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	s, ok := in.series[key]
+	if !ok {
+		return
+	}
+
+	n := len(s.candles)
+	switch {
+	case n > 0 && s.candles[n-1].Start.Equal(candle.Start):
+		s.candles[n-1] = candle
+	case n == 0 || s.candles[n-1].Start.Before(candle.Start):
+		s.candles = append(s.candles, candle)
+		if len(s.candles) > indicatorHistory {
+			s.candles = s.candles[len(s.candles)-indicatorHistory:]
+		}
+	}
+}
+
+func (in *Indicators) Compute(ctx context.Context, exchange, symbol, kind string, window int, interval string) (model.Indicator, error) {
+	if interval == "" {
+		interval = model.MarketResolution.Name
+	}
+	if _, ok := model.ResolutionByName(interval); !ok {
+		return model.Indicator{}, fmt.Errorf("%w: %q", ErrUnknownResolution, interval)
+	}
+
+	if _, ok := defaultIndicatorWindows[kind]; !ok {
+		return model.Indicator{}, fmt.Errorf("%w: %q", ErrUnknownIndicator, kind)
+	}
+	if window == 0 || kind == IndicatorMACD {
+		window = defaultIndicatorWindows[kind]
+	}
+	if window < 2 || window > maxIndicatorWindow {
+		return model.Indicator{}, ErrInvalidWindow
+	}
+
+	candles, err := in.candles(ctx, seriesKey{exchange: exchange, symbol: symbol, resolution: interval})
+	if err != nil {
+		return model.Indicator{}, err
+	}
+
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+
+	var points []model.IndicatorPoint
+	switch kind {
+	case IndicatorSMA:
+		points = valuePoints(candles, SMA(closes, window))
+	case IndicatorEMA:
+		points = valuePoints(candles, EMA(closes, window))
+	case IndicatorRSI:
+		points = valuePoints(candles, RSI(closes, window))
+	case IndicatorBollinger:
+		mid, upper, lower := Bollinger(closes, window, bollingerWidth)
+		points = valuePoints(candles, mid)
+		offset := len(points) - len(upper)
+		for i := range points {
+			points[i].Upper = upper[i+offset]
+			points[i].Lower = lower[i+offset]
+		}
+	case IndicatorMACD:
+		macd, signal, hist := MACD(closes, macdFast, macdSlow, macdSignal)
+		points = valuePoints(candles, signal)
+		offset := len(macd) - len(signal)
+		for i := range points {
+			points[i].Value = macd[i+offset]
+			points[i].Signal = signal[i]
+			points[i].Histogram = hist[i]
+		}
+	}
+
+	if len(points) == 0 {
+		return model.Indicator{}, ErrNotEnoughHistory
+	}
+
+	return model.Indicator{
+		Exchange: exchange,
+		Symbol:   symbol,
+		Type:     kind,
+		Window:   window,
+		Interval: interval,
+		Points:   points,
+	}, nil
+}
+
+func (in *Indicators) candles(ctx context.Context, key seriesKey) ([]model.Candle, error) {
+	now := in.now()
+
+	in.mu.Lock()
+	s, ok := in.series[key]
+	if ok && s.fresh(key.resolution, now) {
+		s.used = now
+		candles := slices.Clone(s.candles)
+		in.mu.Unlock()
+		return candles, nil
+	}
+	in.mu.Unlock()
+
+	candles, err := in.repo.GetCandles(ctx, storage.CandleParams{
+		PairName:   key.symbol,
+		Exchange:   key.exchange,
+		Resolution: key.resolution,
+		Limit:      indicatorHistory,
+	})
+	if err != nil || len(candles) == 0 {
+		return candles, err
+	}
+
+	listed, err := in.symbols.Listed(ctx, key.exchange, key.symbol)
+	if err != nil {
+		slog.Warn("failed to look up indicator pair", "exchange", key.exchange, "symbol", key.symbol, "error", err)
+		return candles, nil
+	}
+	if listed {
+		in.store(key, &series{candles: slices.Clone(candles), loaded: now, used: now})
+	}
+
+	return candles, nil
+}
+
+// store caches s under key, dropping expired series and then the least
+// recently read ones to stay within maxIndicatorSeries.
+func (in *Indicators) store(key seriesKey, s *series) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	delete(in.series, key)
+	if len(in.series) >= maxIndicatorSeries {
+		for k, old := range in.series {
+			if !old.fresh(k.resolution, s.used) {
+				delete(in.series, k)
+			}
+		}
+	}
+	for len(in.series) >= maxIndicatorSeries {
+		var oldest seriesKey
+		var used time.Time
+		for k, old := range in.series {
+			if used.IsZero() || old.used.Before(used) {
+				oldest, used = k, old.used
+			}
+		}
+		delete(in.series, oldest)
+	}
+	in.series[key] = s
+}
+
+// valuePoints lines values up with the most recent candles.
+func valuePoints(candles []model.Candle, values []float64) []model.IndicatorPoint {
+	offset := len(candles) - len(values)
+	points := make([]model.IndicatorPoint, len(values))
+	for i, v := range values {
+		points[i] = model.IndicatorPoint{
+			Timestamp: candles[i+offset].Start,
+			Value:     v,
+		}
+	}
+	return points
+}
+
+// SMA returns the simple moving average for every full window of values.
+func SMA(values []float64, n int) []float64 {
+	if n <= 0 || len(values) < n {
+		return nil
+	}
+
+	res := make([]float64, 0, len(values)-n+1)
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= n {
+			sum -= values[i-n]
+		}
+		if i >= n-1 {
+			res = append(res, sum/float64(n))
+		}
+	}
+	return res
+}
+
+// EMA returns the exponential moving average seeded with the SMA of the first
+// n values.
+func EMA(values []float64, n int) []float64 {
+	if n <= 0 || len(values) < n {
+		return nil
+	}
+
+	alpha := 2 / float64(n+1)
+	res := make([]float64, 0, len(values)-n+1)
+	ema := SMA(values[:n], n)[0]
+	res = append(res, ema)
+	for _, v := range values[n:] {
+		ema = alpha*v + (1-alpha)*ema
+		res = append(res, ema)
+	}
+	return res
+}
+
+// RSI returns the relative strength index using Wilder's smoothing.
+func RSI(values []float64, n int) []float64 {
+	if n <= 0 || len(values) <= n {
+		return nil
+	}
+
+	var gain, loss float64
+	for i := 1; i <= n; i++ {
+		d := values[i] - values[i-1]
+		if d > 0 {
+			gain += d
+		} else {
+			loss -= d
+		}
+	}
+	gain /= float64(n)
+	loss /= float64(n)
+
+	rsi := func() float64 {
+		if loss == 0 {
+			return 100
+		}
+		return 100 - 100/(1+gain/loss)
+	}
+
+	res := make([]float64, 0, len(values)-n)
+	res = append(res, rsi())
+	for i := n + 1; i < len(values); i++ {
+		d := values[i] - values[i-1]
+		var g, l float64
+		if d > 0 {
+			g = d
+		} else {
+			l = -d
+		}
+		gain = (gain*float64(n-1) + g) / float64(n)
+		loss = (loss*float64(n-1) + l) / float64(n)
+		res = append(res, rsi())
+	}
+	return res
+}
+
+// Bollinger returns the middle, upper and lower bands, k population standard
+// deviations around the SMA.
+func Bollinger(values []float64, n int, k float64) ([]float64, []float64, []float64) {
+	mid := SMA(values, n)
+	upper := make([]float64, len(mid))
+	lower := make([]float64, len(mid))
+	for i, m := range mid {
+		var variance float64
+		for _, v := range values[i : i+n] {
+			variance += (v - m) * (v - m)
+		}
+		sd := math.Sqrt(variance / float64(n))
+		upper[i] = m + k*sd
+		lower[i] = m - k*sd
+	}
+	return mid, upper, lower
+}
+
+// MACD returns the MACD line, its signal line and their difference. The
+// signal and histogram are aligned with the end of the MACD line.
+func MACD(values []float64, fast, slow, signal int) ([]float64, []float64, []float64) {
+	slowEMA := EMA(values, slow)
+	fastEMA := EMA(values, fast)
+	if slowEMA == nil || fastEMA == nil {
+		return nil, nil, nil
+	}
+
+	offset := len(fastEMA) - len(slowEMA)
+	macd := make([]float64, len(slowEMA))
+	for i := range slowEMA {
+		macd[i] = fastEMA[i+offset] - slowEMA[i]
+	}
+
+	sig := EMA(macd, signal)
+	hist := make([]float64, len(sig))
+	offset = len(macd) - len(sig)
+	for i := range sig {
+		hist[i] = macd[i+offset] - sig[i]
+	}
+	return macd, sig, hist
+}
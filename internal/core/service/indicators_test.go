@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+// candleRepo serves one candle for every pair and counts the lookups.
+type candleRepo struct {
+	core.Repository
+	reads int
+}
+
+func (r *candleRepo) GetCandles(ctx context.Context, arg storage.CandleParams) ([]model.Candle, error) {
+	r.reads++
+	if arg.PairName == "EMPTY" {
+		return nil, nil
+	}
+	return []model.Candle{{Exchange: arg.Exchange, Symbol: arg.PairName, Resolution: arg.Resolution, Close: 1}}, nil
+}
+
+func TestIndicators_SeriesCache(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := &candleRepo{}
+	symbols := NewSymbols(&memorySymbols{listings: []model.SymbolListing{
+		{Exchange: "exchange1", Symbol: model.BTCUSDT},
+		{Exchange: "exchange1", Symbol: "EMPTY"},
+	}})
+	in := NewIndicators(repo, symbols)
+	in.now = func() time.Time { return now }
+
+	key := func(exchange, symbol string) seriesKey {
+		return seriesKey{exchange: exchange, symbol: symbol, resolution: model.MarketResolution.Name}
+	}
+	for _, k := range []seriesKey{key("exchange1", model.BTCUSDT), key(model.GlobalExchange, model.BTCUSDT), key("exchange1", "EMPTY"), key("made-up", model.BTCUSDT)} {
+		if _, err := in.candles(context.Background(), k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(in.series) != 2 {
+		t.Fatalf("expected only the listed pairs with history to be cached, got %v", in.series)
+	}
+
+	now = now.Add(marketSeriesTTL)
+	reads := repo.reads
+	if _, err := in.candles(context.Background(), key("exchange1", model.BTCUSDT)); err != nil {
+		t.Fatal(err)
+	}
+	if repo.reads != reads+1 {
+		t.Error("expected an idle one-minute series to be reloaded")
+	}
+
+	for i := range maxIndicatorSeries + 1 {
+		now = now.Add(time.Millisecond)
+		in.store(seriesKey{exchange: "exchange1", symbol: model.BTCUSDT, resolution: string(rune(i))}, &series{loaded: now, used: now})
+	}
+	if len(in.series) != maxIndicatorSeries {
+		t.Errorf("expected the cache to be capped at %d, got %d", maxIndicatorSeries, len(in.series))
+	}
+}
+
+func almostEqual(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%s: expected %d values, got %d", name, len(want), len(got))
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-6 {
+			t.Errorf("%s[%d]: expected %v, got %v", name, i, want[i], got[i])
+		}
+	}
+}
+
+func TestIndicators_SMA(t *testing.T) {
+	almostEqual(t, "sma", SMA([]float64{1, 2, 3, 4, 5}, 3), []float64{2, 3, 4})
+
+	if SMA([]float64{1, 2}, 3) != nil {
+		t.Errorf("expected no values for a window longer than the input")
+	}
+}
+
+func TestIndicators_EMA(t *testing.T) {
+	// alpha = 2 / (3 + 1) = 0.5, seeded with SMA(1, 2, 3) = 2
+	almostEqual(t, "ema", EMA([]float64{1, 2, 3, 4, 5}, 3), []float64{2, 3, 4})
+	almostEqual(t, "ema", EMA([]float64{2, 2, 2, 8}, 3), []float64{2, 5})
+}
+
+func TestIndicators_RSI(t *testing.T) {
+	almostEqual(t, "rising", RSI([]float64{1, 2, 3, 4, 5}, 3), []float64{100, 100})
+
+	// First window: gains 1, losses 1 -> 50. Next change is -2:
+	// gain = 2/3 * 1/3 = 0.2222, loss = (2/3 + 2)/3 = 0.8889.
+	got := RSI([]float64{10, 11, 10, 10, 8}, 3)
+	almostEqual(t, "mixed", got, []float64{50, 100 - 100/(1+0.2222222222/0.8888888889)})
+}
+
+func TestIndicators_Bollinger(t *testing.T) {
+	mid, upper, lower := Bollinger([]float64{2, 4, 4, 4, 5, 5, 7, 9}, 8, 2)
+
+	// The population standard deviation of the input is exactly 2.
+	almostEqual(t, "mid", mid, []float64{5})
+	almostEqual(t, "upper", upper, []float64{9})
+	almostEqual(t, "lower", lower, []float64{1})
+}
+
+func TestIndicators_MACD(t *testing.T) {
+	values := make([]float64, 40)
+	for i := range values {
+		values[i] = 100
+	}
+
+	macd, signal, hist := MACD(values, 12, 26, 9)
+	if len(macd) != 15 || len(signal) != 7 || len(hist) != 7 {
+		t.Fatalf("unexpected lengths: macd %d, signal %d, histogram %d", len(macd), len(signal), len(hist))
+	}
+
+	almostEqual(t, "flat", hist, make([]float64, 7))
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+// Route groups share one bucket per client.
+const (
+	// RateGroupLatest covers reads served from Redis.
+	RateGroupLatest = "latest"
+	// RateGroupHistory covers queries over a period, which hit Postgres.
+	RateGroupHistory = "history"
+	// RateGroupStream covers opening a stream.
+	RateGroupStream = "stream"
+	// RateGroupAdmin covers the mode switches and the /admin routes.
+	RateGroupAdmin = "admin"
+	// RateGroupAuth covers looking up the API key of a request. It is taken
+	// per address before the key is known, so made-up keys cannot reach the
+	// repository unlimited.
+	RateGroupAuth = "auth"
+)
+
+// RateGroups lists every group, for configuration.
+var RateGroups = []string{RateGroupLatest, RateGroupHistory, RateGroupStream, RateGroupAdmin, RateGroupAuth}
+
+// DefaultRateLimits keep one client from saturating a backend.
+var DefaultRateLimits = map[string]model.RateLimit{
+	RateGroupLatest:  {Requests: 20, Per: time.Second, Burst: 40},
+	RateGroupHistory: {Requests: 2, Per: time.Second, Burst: 10},
+	RateGroupStream:  {Requests: 10, Per: time.Minute, Burst: 5},
+	RateGroupAdmin:   {Requests: 1, Per: time.Second, Burst: 5},
+	RateGroupAuth:    {Requests: 50, Per: time.Second, Burst: 100},
+}
+
+var ErrRateLimited = model.NewError(model.KindRateLimited, "rate_limited", "too many requests")
+
+// usageDays is how many days of usage the in-memory fallback keeps.
+const usageDays = 2
+
+// storeBackoff is how long requests are limited in memory after the store
+// fails, so that they do not each wait for it to time out.
+const storeBackoff = 10 * time.Second
+
+// RateLimiter limits each client per route group and counts its requests per
+// day (UTC). Buckets live in the store so that they are shared by every
+// instance; while the store fails, each instance limits on its own.
+type RateLimiter struct {
+	store  core.RateLimitStore
+	limits map[string]model.RateLimit
+	memory *memoryRateLimits
+	now    func() time.Time
+
+	mu         sync.Mutex
+	lastFailed time.Time
+	retryAt    time.Time
+}
+
+func NewRateLimiter(store core.RateLimitStore, limits map[string]model.RateLimit) *RateLimiter {
+	return &RateLimiter{
+		store:  store,
+		limits: limits,
+		memory: newMemoryRateLimits(),
+		now:    time.Now,
+	}
+}
+
+// Take takes a token for a request of client to group.
+func (l *RateLimiter) Take(ctx context.Context, client, group string) (model.RateDecision, error) {
+	limit := l.limits[group]
+	now := l.now()
+	day := now.UTC().Format(time.DateOnly)
+
+	if l.store != nil && l.storeReady(now) {
+		d, err := l.store.Take(ctx, client, group, limit, day)
+		if err == nil {
+			return d, nil
+		}
+		l.storeFailed(err, now)
+	}
+	return l.memory.take(client, group, limit, day, now), nil
+}
+
+// Usage returns the usage of day sorted by client and group.
+func (l *RateLimiter) Usage(ctx context.Context, day string) ([]model.Usage, error) {
+	usage, err := l.listUsage(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Client != usage[j].Client {
+			return usage[i].Client < usage[j].Client
+		}
+		return usage[i].Group < usage[j].Group
+	})
+	return usage, nil
+}
+
+// listUsage adds the requests this instance counted in memory while the store
+// was failing to the usage in the store.
+func (l *RateLimiter) listUsage(ctx context.Context, day string) ([]model.Usage, error) {
+	memory := l.memory.usage(day)
+	if l.store == nil {
+		return memory, nil
+	}
+
+	usage, err := l.store.ListUsage(ctx, day)
+	if err != nil {
+		if model.KindOf(err) != model.KindUnavailable {
+			return nil, err
+		}
+		return memory, nil
+	}
+	return mergeUsage(usage, memory), nil
+}
+
+func mergeUsage(usage, more []model.Usage) []model.Usage {
+	index := make(map[bucketKey]int, len(usage))
+	for i, u := range usage {
+		index[bucketKey{client: u.Client, group: u.Group}] = i
+	}
+	for _, u := range more {
+		i, ok := index[bucketKey{client: u.Client, group: u.Group}]
+		if !ok {
+			usage = append(usage, u)
+			continue
+		}
+		usage[i].Requests += u.Requests
+		usage[i].Limited += u.Limited
+	}
+	return usage
+}
+
+// storeReady reports whether the store is worth asking again.
+func (l *RateLimiter) storeReady(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return !now.Before(l.retryAt)
+}
+
+// storeFailed leaves the store alone for storeBackoff and logs a failing
+// store at most once a minute.
+func (l *RateLimiter) storeFailed(err error, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.retryAt = now.Add(storeBackoff)
+	if now.Sub(l.lastFailed) < time.Minute {
+		return
+	}
+	l.lastFailed = now
+	slog.Warn("rate limit store failed, limiting in memory", "error", err, "retry_in", storeBackoff)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full is when the bucket is full again, after which it can be dropped.
+	full time.Time
+}
+
+// take refills b for the time since its last request and takes a token.
+func (b *bucket) take(limit model.RateLimit, now time.Time) bool {
+	rate := limit.PerMillisecond()
+	elapsed := float64(now.Sub(b.last)) / float64(time.Millisecond)
+	b.tokens = min(float64(limit.Burst), b.tokens+max(0, elapsed)*rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.full = now.Add(time.Duration((float64(limit.Burst) - b.tokens) / rate * float64(time.Millisecond)))
+	return allowed
+}
+
+type bucketKey struct {
+	client string
+	group  string
+}
+
+// memoryRateLimits is the fallback of a RateLimiter.
+type memoryRateLimits struct {
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	counts    map[string]map[bucketKey]*model.Usage
+	lastSweep time.Time
+}
+
+func newMemoryRateLimits() *memoryRateLimits {
+	return &memoryRateLimits{
+		buckets: make(map[bucketKey]*bucket),
+		counts:  make(map[string]map[bucketKey]*model.Usage),
+	}
+}
+
+func (m *memoryRateLimits) take(client, group string, limit model.RateLimit, day string, now time.Time) model.RateDecision {
+	key := bucketKey{client: client, group: group}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	allowed := true
+	var tokens float64
+	if limit.Enabled() {
+		b := m.buckets[key]
+		if b == nil {
+			b = &bucket{tokens: float64(limit.Burst), last: now}
+			m.buckets[key] = b
+		}
+		allowed = b.take(limit, now)
+		tokens = b.tokens
+	}
+
+	counts := m.counts[day]
+	if counts == nil {
+		counts = make(map[bucketKey]*model.Usage)
+		m.counts[day] = counts
+		m.pruneDays()
+	}
+	u := counts[key]
+	if u == nil {
+		u = &model.Usage{Client: client, Group: group}
+		counts[key] = u
+	}
+	if allowed {
+		u.Requests++
+	} else {
+		u.Limited++
+	}
+
+	return limit.Decide(allowed, tokens)
+}
+
+// sweep drops, about once a minute, the buckets that are full again; a new
+// bucket starts out full, so nothing is lost.
+func (m *memoryRateLimits) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if now.After(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+func (m *memoryRateLimits) pruneDays() {
+	if len(m.counts) <= usageDays {
+		return
+	}
+	days := make([]string, 0, len(m.counts))
+	for day := range m.counts {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	for _, day := range days[:len(days)-usageDays] {
+		delete(m.counts, day)
+	}
+}
+
+func (m *memoryRateLimits) usage(day string) []model.Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage := make([]model.Usage, 0, len(m.counts[day]))
+	for _, u := range m.counts[day] {
+		usage = append(usage, *u)
+	}
+	return usage
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+func TestMemoryRateLimits(t *testing.T) {
+	limit := model.RateLimit{Requests: 2, Per: time.Second, Burst: 3}
+	m := newMemoryRateLimits()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	day := now.Format(time.DateOnly)
+
+	for i := range 3 {
+		d := m.take("ip:1.2.3.4", RateGroupHistory, limit, day, now)
+		if !d.Allowed || d.Remaining != 2-i {
+			t.Fatalf("request %d: unexpected decision %+v", i, d)
+		}
+	}
+
+	d := m.take("ip:1.2.3.4", RateGroupHistory, limit, day, now)
+	if d.Allowed || d.RetryAfter != 500*time.Millisecond || d.Reset != 1500*time.Millisecond {
+		t.Errorf("expected the 4th request to wait 500ms, got %+v", d)
+	}
+
+	if d := m.take("ip:5.6.7.8", RateGroupHistory, limit, day, now); !d.Allowed {
+		t.Errorf("expected another client to have its own bucket, got %+v", d)
+	}
+
+	if d := m.take("ip:1.2.3.4", RateGroupHistory, limit, day, now.Add(500*time.Millisecond)); !d.Allowed || d.Remaining != 0 {
+		t.Errorf("expected a token after 500ms, got %+v", d)
+	}
+
+	if d := m.take("ip:1.2.3.4", RateGroupLatest, model.RateLimit{}, day, now); !d.Allowed || d.Limit != 0 {
+		t.Errorf("expected a disabled limit to let everything through, got %+v", d)
+	}
+
+	usage := m.usage(day)
+	var got model.Usage
+	for _, u := range usage {
+		if u.Client == "ip:1.2.3.4" && u.Group == RateGroupHistory {
+			got = u
+		}
+	}
+	if got.Requests != 4 || got.Limited != 1 {
+		t.Errorf("expected 4 requests and 1 limited, got %+v", got)
+	}
+}
+
+type failingRateLimits struct{}
+
+func (failingRateLimits) Take(ctx context.Context, client, group string, limit model.RateLimit, day string) (model.RateDecision, error) {
+	return model.RateDecision{}, errStoreDown
+}
+
+func (failingRateLimits) ListUsage(ctx context.Context, day string) ([]model.Usage, error) {
+	return nil, errStoreDown
+}
+
+var errStoreDown = model.NewError(model.KindUnavailable, "cache_unavailable", "no connection")
+
+func TestRateLimiter_Fallback(t *testing.T) {
+	l := NewRateLimiter(failingRateLimits{}, map[string]model.RateLimit{
+		RateGroupHistory: {Requests: 1, Per: time.Minute, Burst: 1},
+	})
+	ctx := context.Background()
+
+	if d, err := l.Take(ctx, "key:1", RateGroupHistory); err != nil || !d.Allowed {
+		t.Fatalf("expected the first request through, got %+v (%v)", d, err)
+	}
+	if d, _ := l.Take(ctx, "key:1", RateGroupHistory); d.Allowed {
+		t.Error("expected the fallback to limit the second request")
+	}
+
+	usage, err := l.Usage(ctx, time.Now().UTC().Format(time.DateOnly))
+	if err != nil || len(usage) != 1 || usage[0].Requests != 1 || usage[0].Limited != 1 {
+		t.Errorf("unexpected usage %+v (%v)", usage, err)
+	}
+}
+
+// flakyRateLimits allows and counts every request of one client while up.
+type flakyRateLimits struct {
+	down     bool
+	calls    int
+	requests int64
+}
+
+func (s *flakyRateLimits) Take(ctx context.Context, client, group string, limit model.RateLimit, day string) (model.RateDecision, error) {
+	s.calls++
+	if s.down {
+		return model.RateDecision{}, errStoreDown
+	}
+	s.requests++
+	return limit.Decide(true, 0), nil
+}
+
+func (s *flakyRateLimits) ListUsage(ctx context.Context, day string) ([]model.Usage, error) {
+	return []model.Usage{{Client: "key:1", Group: RateGroupLatest, Requests: s.requests}}, nil
+}
+
+func TestRateLimiter_Backoff(t *testing.T) {
+	store := &flakyRateLimits{}
+	l := NewRateLimiter(store, map[string]model.RateLimit{})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	l.Take(ctx, "key:1", RateGroupLatest)
+	store.down = true
+	for range 3 {
+		l.Take(ctx, "key:1", RateGroupLatest)
+	}
+	if store.calls != 2 {
+		t.Fatalf("expected the store to be skipped after it failed, got %d calls", store.calls)
+	}
+
+	now = now.Add(storeBackoff)
+	store.down = false
+	l.Take(ctx, "key:1", RateGroupLatest)
+	if store.calls != 3 {
+		t.Fatalf("expected the store to be asked again after %s, got %d calls", storeBackoff, store.calls)
+	}
+
+	// The request that failed and the two skipped ones were counted in
+	// memory; they are added to the usage of the store.
+	usage, err := l.Usage(ctx, now.Format(time.DateOnly))
+	if err != nil || len(usage) != 1 || usage[0].Requests != 5 {
+		t.Errorf("expected 2 requests in the store and 3 in memory, got %+v (%v)", usage, err)
+	}
+}
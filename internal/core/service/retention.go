@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+const retentionBatchSize = 5000
+
+// Retention deletes rows that have outlived their policy. Rows are removed in
+// batches so a large backlog never holds long locks on the hot tables.
+type Retention struct {
+	repo     core.RetentionRepository
+	policies []model.RetentionPolicy
+
+	mu    sync.Mutex
+	stats map[string]model.RetentionStats
+}
+
+func NewRetention(repo core.RetentionRepository, policies []model.RetentionPolicy) *Retention {
+	return &Retention{
+		repo:     repo,
+		policies: policies,
+		stats:    make(map[string]model.RetentionStats, len(policies)),
+	}
+}
+
+func (r *Retention) Start(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.enforce(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (r *Retention) enforce(ctx context.Context) {
+	for _, p := range r.policies {
+		if p.MaxAge <= 0 {
+			continue
+		}
+
+		start := time.Now()
+		var deleted int64
+		var runErr error
+		for ctx.Err() == nil {
+			dCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			n, err := r.repo.DeleteExpired(dCtx, p, retentionBatchSize)
+			cancel()
+			if err != nil {
+				runErr = err
+				break
+			}
+
+			deleted += n
+			if n < retentionBatchSize {
+				break
+			}
+		}
+
+		r.record(p, start, deleted, runErr)
+	}
+}
+
+func (r *Retention) record(p model.RetentionPolicy, start time.Time, deleted int64, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats[p.Name()]
+	stats.LastRun = start
+	stats.LastDuration = time.Since(start)
+	stats.LastDeleted = deleted
+	stats.TotalDeleted += deleted
+	stats.LastError = ""
+	if err != nil {
+		stats.LastError = err.Error()
+		slog.Error("retention error", "policy", p.Name(), "error", err)
+	}
+	r.stats[p.Name()] = stats
+
+	if deleted > 0 {
+		slog.Info("retention enforced",
+			"policy", p.Name(),
+			"deleted", deleted,
+			"duration_ms", stats.LastDuration.Milliseconds(),
+		)
+	}
+}
+
+// Report previews what the next run would delete for every policy, alongside
+// what has been deleted so far.
+func (r *Retention) Report(ctx context.Context) ([]model.RetentionReport, error) {
+	reports := make([]model.RetentionReport, 0, len(r.policies))
+	for _, p := range r.policies {
+		report := model.RetentionReport{Policy: p}
+
+		if p.MaxAge > 0 {
+			preview, err := r.repo.PreviewExpired(ctx, p)
+			if err != nil {
+				return nil, err
+			}
+			report.Preview = preview
+		}
+
+		r.mu.Lock()
+		report.Stats = r.stats[p.Name()]
+		r.mu.Unlock()
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
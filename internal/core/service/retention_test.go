@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+// memoryRetention deletes from a number of expired rows per table and fails
+// the tables in failing.
+type memoryRetention struct {
+	expired map[string]int64
+	failing map[string]bool
+	batches map[string]int
+}
+
+func (m *memoryRetention) DeleteExpired(ctx context.Context, p model.RetentionPolicy, batch int64) (int64, error) {
+	m.batches[p.Name()]++
+	if m.failing[p.Name()] {
+		return 0, errors.New("delete failed")
+	}
+	n := min(m.expired[p.Name()], batch)
+	m.expired[p.Name()] -= n
+	return n, nil
+}
+
+func (m *memoryRetention) PreviewExpired(ctx context.Context, p model.RetentionPolicy) (model.RetentionPreview, error) {
+	return model.RetentionPreview{Rows: m.expired[p.Name()]}, nil
+}
+
+func TestRetention_Enforce(t *testing.T) {
+	repo := &memoryRetention{
+		expired: map[string]int64{"market": 2*retentionBatchSize + 1, "raw_data": 10},
+		failing: map[string]bool{"alert_events": true},
+		batches: make(map[string]int),
+	}
+	policies := []model.RetentionPolicy{
+		{Table: "market", MaxAge: time.Hour},
+		{Table: "raw_data", MaxAge: time.Minute},
+		{Table: "market_rollup", Resolution: "1d"},
+		{Table: "alert_events", MaxAge: time.Hour},
+	}
+	r := NewRetention(repo, policies)
+
+	r.enforce(context.Background())
+	r.enforce(context.Background())
+
+	if repo.batches["market"] != 4 {
+		t.Errorf("expected market to be deleted in 3 batches and an empty one, got %d", repo.batches["market"])
+	}
+	if _, ok := repo.batches["market_rollup:1d"]; ok {
+		t.Error("expected a policy without a max age to keep its rows")
+	}
+
+	reports, err := r.Report(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != len(policies) {
+		t.Fatalf("expected a report per policy, got %d", len(reports))
+	}
+
+	market := reports[0].Stats
+	if market.TotalDeleted != 2*retentionBatchSize+1 || market.LastDeleted != 0 || market.LastRun.IsZero() {
+		t.Errorf("unexpected market stats %+v", market)
+	}
+	if raw := reports[1]; raw.Stats.TotalDeleted != 10 || raw.Preview.Rows != 0 {
+		t.Errorf("unexpected raw_data report %+v", raw)
+	}
+	if rollup := reports[2]; !rollup.Stats.LastRun.IsZero() {
+		t.Errorf("expected no run for a policy without a max age, got %+v", rollup.Stats)
+	}
+	if alerts := reports[3].Stats; alerts.LastError == "" || alerts.TotalDeleted != 0 {
+		t.Errorf("expected the failure to be reported, got %+v", alerts)
+	}
+}
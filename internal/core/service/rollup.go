@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+const (
+	RollupTicker = 30 * time.Second
+
+	rollupBatchSize = 10000
+)
+
+// Rollup downsamples the one-minute market rows into every resolution in
+// model.Resolutions. Progress is tracked by a watermark in the database, so a
+// restarted process picks up where the previous one stopped.
+type Rollup struct {
+	repo core.RollupRepository
+}
+
+func NewRollup(repo core.RollupRepository) *Rollup {
+	return &Rollup{
+		repo: repo,
+	}
+}
+
+func (r *Rollup) Start(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	r.run(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			r.run(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (r *Rollup) run(ctx context.Context) {
+	for _, res := range model.Resolutions {
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+
+			rCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			n, err := r.repo.RollupMarket(rCtx, res, rollupBatchSize)
+			cancel()
+			if err != nil {
+				slog.Error("rollup error", "resolution", res.Name, "error", err)
+				break
+			}
+
+			if n == 0 {
+				break
+			}
+			slog.Debug("rollup advanced", "resolution", res.Name, "rows", n)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"marketflow/internal/core/model"
+)
+
+// memoryRollups hands out pending rows per resolution in batches and fails
+// the resolutions in failing.
+type memoryRollups struct {
+	pending map[string]int64
+	failing map[string]bool
+	calls   map[string]int
+}
+
+func (m *memoryRollups) RollupMarket(ctx context.Context, res model.Resolution, batch int64) (int64, error) {
+	m.calls[res.Name]++
+	if m.failing[res.Name] {
+		return 0, errors.New("rollup failed")
+	}
+	n := min(m.pending[res.Name], batch)
+	m.pending[res.Name] -= n
+	return n, nil
+}
+
+func TestRollup_Run(t *testing.T) {
+	repo := &memoryRollups{
+		pending: map[string]int64{"5m": 2*rollupBatchSize + 1, "1h": rollupBatchSize},
+		failing: map[string]bool{"1h": true},
+		calls:   make(map[string]int),
+	}
+	NewRollup(repo).run(context.Background())
+
+	if repo.pending["5m"] != 0 || repo.calls["5m"] != 4 {
+		t.Errorf("expected 5m to be drained in 3 batches and a last empty one, got %d left after %d calls", repo.pending["5m"], repo.calls["5m"])
+	}
+	if repo.calls["1h"] != 1 {
+		t.Errorf("expected a failing resolution to be left for the next run, got %d calls", repo.calls["1h"])
+	}
+	if repo.calls["1d"] != 1 {
+		t.Errorf("expected a failure not to hold up the other resolutions, got %d calls for 1d", repo.calls["1d"])
+	}
+}
+
+func TestRollup_Canceled(t *testing.T) {
+	repo := &memoryRollups{
+		pending: map[string]int64{"5m": rollupBatchSize},
+		calls:   make(map[string]int),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewRollup(repo).run(ctx)
+
+	if len(repo.calls) != 0 {
+		t.Errorf("expected a canceled run to stop before the first batch, got %v", repo.calls)
+	}
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+const SpreadTicker = 1 * time.Second
+
+var ErrNoSpread = model.NewError(model.KindNotFound, "no_spread", "fewer than two exchanges have a price for this symbol")
+
+type SpreadConfig struct {
+	// ThresholdBps is the spread, in basis points of the lower price, above
+	// which two exchanges are considered diverged.
+	ThresholdBps float64
+	// MinDuration is how long a divergence has to last before an event is
+	// raised for it.
+	MinDuration time.Duration
+	// MaxQuoteAge is how old an exchange's latest price may be before the
+	// exchange is left out of the comparison.
+	MaxQuoteAge time.Duration
+}
+
+// divergenceKey names a pair of exchanges regardless of which one is higher,
+// so a divergence that changes sides is still the same divergence.
+type divergenceKey struct {
+	symbol string
+	a      string
+	b      string
+}
+
+func newDivergenceKey(symbol string, pair model.PairSpread) divergenceKey {
+	a, b := pair.High.Exchange, pair.Low.Exchange
+	if b < a {
+		a, b = b, a
+	}
+	return divergenceKey{symbol: symbol, a: a, b: b}
+}
+
+type divergence struct {
+	since    time.Time
+	reported bool
+	// last is the spread as it was last seen.
+	last model.PairSpread
+}
+
+// SpreadMonitor compares the latest prices of a pair across exchanges and
+// reports exchanges whose prices stay apart for too long.
+type SpreadMonitor struct {
+	repo   core.Repository
+	cache  core.Cache
+	config SpreadConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	diverged  map[divergenceKey]*divergence
+	listeners []func(model.DivergenceEvent)
+}
+
+func NewSpreadMonitor(repo core.Repository, cache core.Cache, config SpreadConfig) *SpreadMonitor {
+	return &SpreadMonitor{
+		repo:     repo,
+		cache:    cache,
+		config:   config,
+		now:      time.Now,
+		diverged: make(map[divergenceKey]*divergence),
+	}
+}
+
+// OnDivergence registers f to be called for every divergence event.
+func (m *SpreadMonitor) OnDivergence(f func(model.DivergenceEvent)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, f)
+}
+
+func (m *SpreadMonitor) Spreads(ctx context.Context, symbol string) (model.SpreadReport, error) {
+	exchangers, _, err := m.cache.GetCollection(ctx)
+	if err != nil {
+		return model.SpreadReport{}, err
+	}
+
+	return m.spreads(ctx, symbol, exchangers, m.now())
+}
+
+// spreads compares the exchanges that have a fresh price for symbol. An
+// exchange without a price is left out; any other failure to read one is
+// returned, so that it is not mistaken for a missing price.
+func (m *SpreadMonitor) spreads(ctx context.Context, symbol string, exchangers []string, now time.Time) (model.SpreadReport, error) {
+	report := model.SpreadReport{Symbol: symbol}
+	for _, exchange := range exchangers {
+		if exchange == model.GlobalExchange {
+			continue
+		}
+
+		quote, err := m.repo.GetLatest(ctx, exchange, symbol)
+		if model.KindOf(err) == model.KindNotFound {
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		if m.config.MaxQuoteAge > 0 && quote.Age(now) > m.config.MaxQuoteAge {
+			continue
+		}
+
+		report.Venues = append(report.Venues, quote)
+	}
+
+	if len(report.Venues) < 2 {
+		return report, ErrNoSpread
+	}
+
+	sort.Slice(report.Venues, func(i, j int) bool {
+		return report.Venues[i].Price > report.Venues[j].Price
+	})
+
+	for i := 0; i < len(report.Venues); i++ {
+		for j := i + 1; j < len(report.Venues); j++ {
+			high, low := report.Venues[i], report.Venues[j]
+			spread := high.Price - low.Price
+			var bps float64
+			if low.Price > 0 {
+				bps = spread / low.Price * 10000
+			}
+			report.Pairs = append(report.Pairs, model.PairSpread{
+				High:      high,
+				Low:       low,
+				Spread:    spread,
+				SpreadBps: bps,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+func (m *SpreadMonitor) Start(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			mCtx, cancel := context.WithTimeout(ctx, interval)
+			m.check(mCtx)
+			cancel()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (m *SpreadMonitor) check(ctx context.Context) {
+	exchangers, symbols, err := m.cache.GetCollection(ctx)
+	if err != nil {
+		slog.Error("spread monitor error", "error", err)
+		return
+	}
+
+	now := m.now()
+	seen := make(map[divergenceKey]bool)
+
+	for _, symbol := range symbols {
+		report, err := m.spreads(ctx, symbol, exchangers, now)
+		if err != nil {
+			if !errors.Is(err, ErrNoSpread) {
+				slog.Error("spread monitor error", "symbol", symbol, "error", err)
+				m.keep(seen, symbol)
+			}
+			continue
+		}
+
+		for _, pair := range report.Pairs {
+			if pair.SpreadBps < m.config.ThresholdBps {
+				continue
+			}
+
+			key := newDivergenceKey(symbol, pair)
+			seen[key] = true
+			m.diverge(key, pair, now)
+		}
+	}
+
+	m.converge(seen, now)
+}
+
+func (m *SpreadMonitor) diverge(key divergenceKey, pair model.PairSpread, now time.Time) {
+	m.mu.Lock()
+	d, ok := m.diverged[key]
+	if !ok {
+		d = &divergence{since: now}
+		m.diverged[key] = d
+	}
+	d.last = pair
+
+	if d.reported || now.Sub(d.since) < m.config.MinDuration {
+		m.mu.Unlock()
+		return
+	}
+	d.reported = true
+	m.mu.Unlock()
+
+	m.emit(model.DivergenceEvent{
+		Type:       model.DivergenceStarted,
+		Since:      d.since,
+		Time:       now,
+		PairSpread: pair,
+	})
+}
+
+// keep marks the divergences of symbol as seen, so that a check that failed
+// to read its prices neither ends them nor restarts them.
+func (m *SpreadMonitor) keep(seen map[divergenceKey]bool, symbol string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.diverged {
+		if key.symbol == symbol {
+			seen[key] = true
+		}
+	}
+}
+
+// converge forgets every divergence that was not seen in the latest check and
+// reports the end of the ones that had been reported.
+func (m *SpreadMonitor) converge(seen map[divergenceKey]bool, now time.Time) {
+	var ended []model.DivergenceEvent
+
+	m.mu.Lock()
+	for key, d := range m.diverged {
+		if seen[key] {
+			continue
+		}
+		delete(m.diverged, key)
+		if d.reported {
+			ended = append(ended, model.DivergenceEvent{
+				Type:  model.DivergenceEnded,
+				Since: d.since,
+				Time:  now,
+				PairSpread: model.PairSpread{
+					High: model.Quote{Exchange: d.last.High.Exchange, Symbol: key.symbol},
+					Low:  model.Quote{Exchange: d.last.Low.Exchange, Symbol: key.symbol},
+				},
+			})
+		}
+	}
+	m.mu.Unlock()
+
+	for _, e := range ended {
+		m.emit(e)
+	}
+}
+
+func (m *SpreadMonitor) emit(e model.DivergenceEvent) {
+	slog.Warn("exchange divergence",
+		"type", e.Type,
+		"symbol", e.High.Symbol,
+		"high", e.High.Exchange,
+		"low", e.Low.Exchange,
+		"spread_bps", e.SpreadBps,
+		"since", e.Since,
+	)
+
+	m.mu.Lock()
+	listeners := m.listeners
+	m.mu.Unlock()
+
+	for _, f := range listeners {
+		f(e)
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/secondary/storage"
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+type quoteRepo struct {
+	core.Repository
+	quotes map[string]model.Quote
+	err    error
+}
+
+func (r *quoteRepo) GetLatest(ctx context.Context, exchange string, symbol string) (model.Quote, error) {
+	if r.err != nil {
+		return model.Quote{}, r.err
+	}
+	q, ok := r.quotes[exchange]
+	if !ok {
+		return model.Quote{}, storage.ErrNoData
+	}
+	return q, nil
+}
+
+type exchangeCache struct {
+	core.Cache
+	exchanges []string
+}
+
+func (c exchangeCache) GetCollection(ctx context.Context) ([]string, []string, error) {
+	return c.exchanges, []string{model.BTCUSDT}, nil
+}
+
+func TestSpreadMonitor_Spreads(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := &quoteRepo{quotes: map[string]model.Quote{
+		"exchange1": {Exchange: "exchange1", Price: 100, Timestamp: now},
+		"exchange2": {Exchange: "exchange2", Price: 101, Timestamp: now.Add(-time.Second)},
+		"exchange3": {Exchange: "exchange3", Price: 150, Timestamp: now.Add(-time.Minute)},
+	}}
+	cache := exchangeCache{exchanges: []string{"exchange1", "exchange2", "exchange3", "exchange4", model.GlobalExchange}}
+	m := NewSpreadMonitor(repo, cache, SpreadConfig{MaxQuoteAge: 30 * time.Second})
+	m.now = func() time.Time { return now }
+
+	report, err := m.Spreads(context.Background(), model.BTCUSDT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Venues) != 2 || len(report.Pairs) != 1 {
+		t.Fatalf("expected the stale and the missing exchange to be left out, got %+v", report)
+	}
+	if p := report.Pairs[0]; p.High.Exchange != "exchange2" || p.Low.Exchange != "exchange1" || p.SpreadBps != 100 {
+		t.Errorf("unexpected spread %+v", p)
+	}
+
+	delete(repo.quotes, "exchange2")
+	if _, err := m.Spreads(context.Background(), model.BTCUSDT); !errors.Is(err, ErrNoSpread) {
+		t.Errorf("expected a single fresh exchange to have no spread, got %v", err)
+	}
+
+	repo.err = errors.New("connection refused")
+	if _, err := m.Spreads(context.Background(), model.BTCUSDT); err == nil || errors.Is(err, ErrNoSpread) {
+		t.Errorf("expected the failure to read a price to be returned, got %v", err)
+	}
+}
+
+func TestSpreadMonitor_Divergence(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := &quoteRepo{quotes: map[string]model.Quote{}}
+	set := func(price1, price2 float64) {
+		repo.quotes["exchange1"] = model.Quote{Exchange: "exchange1", Price: price1, Timestamp: now}
+		repo.quotes["exchange2"] = model.Quote{Exchange: "exchange2", Price: price2, Timestamp: now}
+	}
+	cache := exchangeCache{exchanges: []string{"exchange1", "exchange2"}}
+	m := NewSpreadMonitor(repo, cache, SpreadConfig{ThresholdBps: 50, MinDuration: 5 * time.Second, MaxQuoteAge: 30 * time.Second})
+	m.now = func() time.Time { return now }
+
+	var events []model.DivergenceEvent
+	m.OnDivergence(func(e model.DivergenceEvent) { events = append(events, e) })
+
+	start := now
+	set(100, 101)
+	m.check(context.Background())
+
+	// The divergence changes sides before it has lasted long enough; it is
+	// still the same divergence.
+	now = now.Add(3 * time.Second)
+	set(101, 100)
+	m.check(context.Background())
+	if len(events) != 0 {
+		t.Fatalf("expected no event before %s, got %+v", m.config.MinDuration, events)
+	}
+
+	now = now.Add(3 * time.Second)
+	m.check(context.Background())
+	if len(events) != 1 || events[0].Type != model.DivergenceStarted || !events[0].Since.Equal(start) {
+		t.Fatalf("expected one divergence since %v, got %+v", start, events)
+	}
+	if events[0].High.Exchange != "exchange1" {
+		t.Errorf("expected the event to carry the current sides, got %+v", events[0].PairSpread)
+	}
+
+	now = now.Add(time.Second)
+	set(100, 101)
+	m.check(context.Background())
+	if len(events) != 1 {
+		t.Fatalf("expected a divergence that changes sides not to be reported again, got %+v", events)
+	}
+
+	// A check that cannot read the prices neither ends the divergence nor
+	// restarts it.
+	now = now.Add(time.Second)
+	repo.err = errors.New("connection refused")
+	m.check(context.Background())
+	repo.err = nil
+	m.check(context.Background())
+	if len(events) != 1 {
+		t.Fatalf("expected a failed check to leave the divergence alone, got %+v", events)
+	}
+
+	// exchange2 stops reporting; its last price is not compared any more.
+	now = now.Add(time.Minute)
+	repo.quotes["exchange1"] = model.Quote{Exchange: "exchange1", Price: 100, Timestamp: now}
+	m.check(context.Background())
+	if len(events) != 2 || events[1].Type != model.DivergenceEnded || events[1].High.Exchange != "exchange2" {
+		t.Fatalf("expected the divergence to end once a price is stale, got %+v", events)
+	}
+}
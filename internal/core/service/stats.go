@@ -2,88 +2,112 @@ package service
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"marketflow/internal/adapters/secondary/storage"
 	"marketflow/internal/core"
+	"marketflow/internal/core/model"
 )
 
 type Stats struct {
 	repo core.Repository
 }
 
-func (s *Stats) GetAveragePrice(ctx context.Context, exchange string, symbol string, period string) (float64, error) {
-	if period == "" {
-		period = "24h"
-	}
+func (s *Stats) GetAveragePrice(ctx context.Context, exchange string, symbol string, rng model.TimeRange) (float64, error) {
+	return s.repo.GetAverage(ctx, params(exchange, symbol, rng))
+}
 
-	interval, err := time.ParseDuration(period)
-	if err != nil {
-		return 0, err
-	}
+// DefaultStaleAfter is how old the latest tick of a pair may be before it is
+// flagged as stale.
+const DefaultStaleAfter = 15 * time.Second
 
-	if interval <= 0 {
-		return 0, errors.New("incorrect period")
-	}
+// GetLatestPrice returns the latest tick of a pair. Its timestamp is the time
+// the tick was recorded.
+func (s *Stats) GetLatestPrice(ctx context.Context, exchange, symbol string) (model.Quote, error) {
+	return s.repo.GetLatest(ctx, exchange, symbol)
+}
 
-	params := storage.Params{
-		PairName: symbol,
-		Exchange: exchange,
-		Interval: interval,
+// MaxLatestPairs is how many pairs one batch lookup may ask for.
+const MaxLatestPairs = 200
+
+var ErrTooManyPairs = model.NewError(model.KindInvalid, "too_many_pairs", fmt.Sprintf("too many pairs, at most %d are allowed", MaxLatestPairs))
+
+// ErrStalePrice is returned when the latest tick is older than the caller
+// accepts.
+var ErrStalePrice = model.NewError(model.KindUnavailable, "stale_price", "latest price is older than max_age")
+
+// GetLatestPrices returns the latest tick of every pair that has one, each
+// with its own timestamp. Repeated pairs are looked up once.
+func (s *Stats) GetLatestPrices(ctx context.Context, pairs []model.Pair) ([]model.Quote, error) {
+	seen := make(map[model.Pair]struct{}, len(pairs))
+	unique := make([]model.Pair, 0, len(pairs))
+	for _, p := range pairs {
+		if _, ok := seen[p]; !ok {
+			seen[p] = struct{}{}
+			unique = append(unique, p)
+		}
+	}
+	if len(unique) > MaxLatestPairs {
+		return nil, ErrTooManyPairs
+	}
+	if len(unique) == 0 {
+		return nil, nil
 	}
 
-	return s.repo.GetAverage(ctx, params)
+	return s.repo.GetLatestBatch(ctx, unique)
 }
 
-func (s *Stats) GetLatestPrice(ctx context.Context, exchange, symbol string) (float64, error) {
-	return s.repo.GetLatest(ctx, exchange, symbol)
+func (s *Stats) GetHighestPrice(ctx context.Context, exchange, symbol string, rng model.TimeRange) (float64, error) {
+	return s.repo.GetMax(ctx, params(exchange, symbol, rng))
 }
 
-func (s *Stats) GetHighestPrice(ctx context.Context, exchange, symbol, period string) (float64, error) {
-	if period == "" {
-		period = "24h"
-	}
-
-	interval, err := time.ParseDuration(period)
-	if err != nil {
-		return 0, err
-	}
-
-	if interval <= 0 {
-		return 0, errors.New("incorrect period")
-	}
+func (s *Stats) GetLowestPrice(ctx context.Context, exchange, symbol string, rng model.TimeRange) (float64, error) {
+	return s.repo.GetMin(ctx, params(exchange, symbol, rng))
+}
 
-	params := storage.Params{
-		PairName: symbol,
-		Exchange: exchange,
-		Interval: interval,
-	}
+func (s *Stats) GetDistribution(ctx context.Context, exchange, symbol string, rng model.TimeRange) (model.Distribution, error) {
+	return s.repo.GetDistribution(ctx, params(exchange, symbol, rng))
+}
 
-	return s.repo.GetMax(ctx, params)
+func (s *Stats) GetSummary(ctx context.Context, exchange, symbol string, rng model.TimeRange) (model.Summary, error) {
+	return s.repo.GetSummary(ctx, params(exchange, symbol, rng))
 }
 
-func (s *Stats) GetLowestPrice(ctx context.Context, exchange, symbol, period string) (float64, error) {
-	if period == "" {
-		period = "24h"
-	}
+const (
+	defaultCandleLimit = 100
+	maxCandleLimit     = 1000
+)
 
-	interval, err := time.ParseDuration(period)
-	if err != nil {
-		return 0, err
+// GetCandles returns the latest limit candles of interval, oldest first.
+func (s *Stats) GetCandles(ctx context.Context, exchange, symbol, interval string, limit int) ([]model.Candle, error) {
+	if interval == "" {
+		interval = model.MarketResolution.Name
+	}
+	if _, ok := model.ResolutionByName(interval); !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownResolution, interval)
 	}
 
-	if interval <= 0 {
-		return 0, errors.New("incorrect period")
+	if limit <= 0 {
+		limit = defaultCandleLimit
 	}
+	limit = min(limit, maxCandleLimit)
+
+	return s.repo.GetCandles(ctx, storage.CandleParams{
+		PairName:   symbol,
+		Exchange:   exchange,
+		Resolution: interval,
+		Limit:      limit,
+	})
+}
 
-	params := storage.Params{
+func params(exchange, symbol string, rng model.TimeRange) storage.Params {
+	return storage.Params{
 		PairName: symbol,
 		Exchange: exchange,
-		Interval: interval,
+		From:     rng.From,
+		To:       rng.To,
 	}
-
-	return s.repo.GetMin(ctx, params)
 }
 
 func NewStats(repo core.Repository) *Stats {
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+const SymbolsTicker = 10 * time.Second
+
+var ErrUnknownExchange = model.NewError(model.KindNotFound, "unknown_exchange", "no symbols have been seen on this exchange")
+
+type listingKey struct {
+	exchange string
+	symbol   string
+}
+
+// Symbols is the registry of every pair seen on every exchange. Ticks are
+// collected in memory and written to the repository in batches, so the
+// registry outlives the raw ticks in Redis.
+type Symbols struct {
+	repo core.SymbolRepository
+
+	mu      sync.Mutex
+	pending map[listingKey]*model.SymbolListing
+}
+
+func NewSymbols(repo core.SymbolRepository) *Symbols {
+	return &Symbols{
+		repo:    repo,
+		pending: make(map[listingKey]*model.SymbolListing),
+	}
+}
+
+func (s *Symbols) Observe(exchange string, trade model.Trade) {
+	if exchange == model.GlobalExchange {
+		return
+	}
+
+	now := time.Now()
+	key := listingKey{exchange: exchange, symbol: trade.Symbol}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.pending[key]
+	if !ok {
+		s.pending[key] = &model.SymbolListing{
+			Exchange:  exchange,
+			Symbol:    trade.Symbol,
+			FirstSeen: now,
+			LastTick:  now,
+		}
+		return
+	}
+	l.LastTick = now
+}
+
+func (s *Symbols) Start(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sCtx, cancel := context.WithTimeout(ctx, interval)
+			s.flush(sCtx)
+			cancel()
+		case <-ctx.Done():
+			sCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			s.flush(sCtx)
+			cancel()
+			return nil
+		}
+	}
+}
+
+// flush writes the pending listings. They are put back if the write fails,
+// unless newer ticks have replaced them in the meantime.
+func (s *Symbols) flush(ctx context.Context) {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[listingKey]*model.SymbolListing)
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	listings := make([]model.SymbolListing, 0, len(pending))
+	for _, l := range pending {
+		listings = append(listings, *l)
+	}
+
+	if err := s.repo.UpsertSymbols(ctx, listings); err != nil {
+		slog.Error("failed to save symbols", "error", err)
+
+		s.mu.Lock()
+		for key, l := range pending {
+			if newer, ok := s.pending[key]; ok {
+				newer.FirstSeen = l.FirstSeen
+				continue
+			}
+			s.pending[key] = l
+		}
+		s.mu.Unlock()
+	}
+}
+
+// List returns every known symbol ordered by name.
+func (s *Symbols) List(ctx context.Context) ([]model.Symbol, error) {
+	listings, err := s.listings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bySymbol := make(map[string]*model.Symbol)
+	for _, l := range listings {
+		sym, ok := bySymbol[l.Symbol]
+		if !ok {
+			base, quote := model.SplitSymbol(l.Symbol)
+			sym = &model.Symbol{
+				Name:      l.Symbol,
+				Base:      base,
+				Quote:     quote,
+				FirstSeen: l.FirstSeen,
+				LastTick:  l.LastTick,
+			}
+			bySymbol[l.Symbol] = sym
+		}
+		if l.FirstSeen.Before(sym.FirstSeen) {
+			sym.FirstSeen = l.FirstSeen
+		}
+		if l.LastTick.After(sym.LastTick) {
+			sym.LastTick = l.LastTick
+		}
+		sym.Listings = append(sym.Listings, l)
+	}
+
+	symbols := make([]model.Symbol, 0, len(bySymbol))
+	for _, name := range slices.Sorted(maps.Keys(bySymbol)) {
+		symbols = append(symbols, *bySymbol[name])
+	}
+	return symbols, nil
+}
+
+// ListByExchange returns the symbols quoted by exchange. Every other exchange
+// quoting them is still listed.
+func (s *Symbols) ListByExchange(ctx context.Context, exchange string) ([]model.Symbol, error) {
+	symbols, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []model.Symbol
+	for _, sym := range symbols {
+		if slices.ContainsFunc(sym.Listings, func(l model.SymbolListing) bool { return l.Exchange == exchange }) {
+			res = append(res, sym)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, ErrUnknownExchange
+	}
+	return res, nil
+}
+
+// listings merges the stored listings with the ones not written yet.
+func (s *Symbols) listings(ctx context.Context) ([]model.SymbolListing, error) {
+	stored, err := s.repo.ListSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[listingKey]model.SymbolListing, len(stored))
+	for _, l := range stored {
+		merged[listingKey{exchange: l.Exchange, symbol: l.Symbol}] = l
+	}
+
+	s.mu.Lock()
+	for key, l := range s.pending {
+		m, ok := merged[key]
+		if !ok {
+			merged[key] = *l
+			continue
+		}
+		if l.LastTick.After(m.LastTick) {
+			m.LastTick = l.LastTick
+		}
+		merged[key] = m
+	}
+	s.mu.Unlock()
+
+	listings := slices.Collect(maps.Values(merged))
+	sort.Slice(listings, func(i, j int) bool {
+		if listings[i].Symbol != listings[j].Symbol {
+			return listings[i].Symbol < listings[j].Symbol
+		}
+		return listings[i].Exchange < listings[j].Exchange
+	})
+	return listings, nil
+}
+
+// Listed reports whether symbol has been seen on exchange. Every seen symbol is
+// listed on the global exchange.
+func (s *Symbols) Listed(ctx context.Context, exchange, symbol string) (bool, error) {
+	listings, err := s.listings(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.ContainsFunc(listings, func(l model.SymbolListing) bool {
+		return l.Symbol == symbol && (exchange == model.GlobalExchange || l.Exchange == exchange)
+	}), nil
+}
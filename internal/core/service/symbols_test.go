@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+type memorySymbols struct {
+	listings []model.SymbolListing
+	err      error
+}
+
+func (m *memorySymbols) UpsertSymbols(ctx context.Context, listings []model.SymbolListing) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.listings = append(m.listings, listings...)
+	return nil
+}
+
+func (m *memorySymbols) ListSymbols(ctx context.Context) ([]model.SymbolListing, error) {
+	return m.listings, nil
+}
+
+func TestSymbols_List(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	repo := &memorySymbols{listings: []model.SymbolListing{
+		{Exchange: "exchange1", Symbol: model.BTCUSDT, FirstSeen: old, LastTick: old},
+	}}
+	s := NewSymbols(repo)
+
+	s.Observe("exchange1", model.Trade{Symbol: model.BTCUSDT, Price: 1})
+	s.Observe("exchange2", model.Trade{Symbol: model.BTCUSDT, Price: 1})
+	s.Observe("exchange2", model.Trade{Symbol: model.ETHUSDT, Price: 1})
+	s.Observe(model.GlobalExchange, model.Trade{Symbol: model.SOLUSDT, Price: 1})
+
+	symbols, err := s.List(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(symbols) != 2 || symbols[0].Name != model.BTCUSDT || symbols[1].Name != model.ETHUSDT {
+		t.Fatalf("expected BTCUSDT and ETHUSDT, got %+v", symbols)
+	}
+
+	btc := symbols[0]
+	if btc.Base != "BTC" || btc.Quote != "USDT" {
+		t.Errorf("expected BTC/USDT, got %s/%s", btc.Base, btc.Quote)
+	}
+	if len(btc.Listings) != 2 {
+		t.Errorf("expected BTCUSDT on 2 exchanges, got %d", len(btc.Listings))
+	}
+	if !btc.FirstSeen.Equal(old) || !btc.LastTick.After(old) {
+		t.Errorf("expected stored first seen and pending last tick, got %v and %v", btc.FirstSeen, btc.LastTick)
+	}
+
+	eth, err := s.ListByExchange(context.Background(), "exchange2")
+	if err != nil || len(eth) != 2 {
+		t.Errorf("expected 2 symbols on exchange2, got %d (%v)", len(eth), err)
+	}
+	if _, err := s.ListByExchange(context.Background(), "exchange9"); !errors.Is(err, ErrUnknownExchange) {
+		t.Errorf("expected %v, got %v", ErrUnknownExchange, err)
+	}
+}
+
+func TestSymbols_FlushRetries(t *testing.T) {
+	repo := &memorySymbols{err: errors.New("unavailable")}
+	s := NewSymbols(repo)
+
+	s.Observe("exchange1", model.Trade{Symbol: model.TONUSDT, Price: 1})
+	s.flush(context.Background())
+
+	repo.err = nil
+	s.flush(context.Background())
+
+	if len(repo.listings) != 1 || repo.listings[0].Symbol != model.TONUSDT {
+		t.Errorf("expected the failed listing to be written on the next flush, got %+v", repo.listings)
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"marketflow/internal/core/model"
+)
+
+const DefaultPeriod = "24h"
+
+var (
+	ErrInvalidPeriod = model.NewError(model.KindInvalid, "invalid_period", "invalid period")
+	ErrInvalidTime   = model.NewError(model.KindInvalid, "invalid_time", "invalid time, expected RFC3339 or Unix milliseconds")
+	ErrInvalidRange  = model.NewError(model.KindInvalid, "invalid_range", "invalid time range")
+)
+
+// Calendar periods start at the beginning of the current day, week, month or
+// year and end now.
+const (
+	PeriodToday     = "today"
+	PeriodThisWeek  = "this_week"
+	PeriodThisMonth = "this_month"
+	PeriodYTD       = "ytd"
+)
+
+// ParseRange resolves the period, from and to query parameters into an
+// absolute range:
+//
+//	period           [now-period, now)
+//	from             [from, now)
+//	from, to         [from, to)
+//	period, from     [from, from+period)
+//	period, to       [to-period, to)
+//
+// Without any of them the range is the DefaultPeriod up to now. Calendar
+// periods and the d, w and mo units are aligned to the location of now.
+func ParseRange(period, from, to string, now time.Time) (model.TimeRange, error) {
+	if period != "" && from != "" && to != "" {
+		return model.TimeRange{}, fmt.Errorf("%w: period cannot be combined with both from and to", ErrInvalidRange)
+	}
+
+	if start, ok := calendarStart(period, now); ok {
+		if from != "" || to != "" {
+			return model.TimeRange{}, fmt.Errorf("%w: %q cannot be combined with from or to", ErrInvalidRange, period)
+		}
+		return model.TimeRange{From: start, To: now}, nil
+	}
+
+	var rng model.TimeRange
+	var err error
+
+	if from != "" {
+		if rng.From, err = parseTime(from); err != nil {
+			return model.TimeRange{}, fmt.Errorf("from: %w", err)
+		}
+		rng.From = rng.From.In(now.Location())
+	}
+	if to != "" {
+		if rng.To, err = parseTime(to); err != nil {
+			return model.TimeRange{}, fmt.Errorf("to: %w", err)
+		}
+		rng.To = rng.To.In(now.Location())
+	}
+
+	if period == "" && from == "" {
+		period = DefaultPeriod
+	}
+
+	if period != "" {
+		p, err := parsePeriod(period)
+		if err != nil {
+			return model.TimeRange{}, err
+		}
+
+		switch {
+		case from != "":
+			rng.To = p.after(rng.From)
+		case to != "":
+			rng.From = p.before(rng.To)
+		default:
+			rng.To = now
+			rng.From = p.before(now)
+		}
+	}
+
+	if rng.To.IsZero() {
+		rng.To = now
+	}
+
+	if !rng.From.Before(rng.To) {
+		return model.TimeRange{}, fmt.Errorf("%w: from must be before to", ErrInvalidRange)
+	}
+
+	return rng, nil
+}
+
+// calendarStart returns the start of a calendar period containing now.
+func calendarStart(period string, now time.Time) (time.Time, bool) {
+	year, month, day := now.Date()
+	loc := now.Location()
+
+	switch period {
+	case PeriodToday:
+		return time.Date(year, month, day, 0, 0, 0, 0, loc), true
+	case PeriodThisWeek:
+		// Weeks start on Monday.
+		offset := (int(now.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, loc), true
+	case PeriodThisMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, loc), true
+	case PeriodYTD:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, loc), true
+	}
+	return time.Time{}, false
+}
+
+// period is a relative length of time. Months and days are calendar units, so
+// a day is not always 24 hours and a month not always 30 days.
+type period struct {
+	months   int
+	days     int
+	duration time.Duration
+}
+
+func (p period) after(t time.Time) time.Time {
+	return t.AddDate(0, p.months, p.days).Add(p.duration)
+}
+
+func (p period) before(t time.Time) time.Time {
+	return t.AddDate(0, -p.months, -p.days).Add(-p.duration)
+}
+
+// parsePeriod accepts everything time.ParseDuration does, plus whole days (d),
+// weeks (w) and months (mo), e.g. "7d", "2w", "1mo" or "1d12h".
+func parsePeriod(s string) (period, error) {
+	var p period
+
+	rest := s
+	for rest != "" {
+		i := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+		if i <= 0 {
+			return period{}, fmt.Errorf("%w: %q", ErrInvalidPeriod, s)
+		}
+		j := strings.IndexFunc(rest[i:], func(r rune) bool { return unicode.IsDigit(r) || r == '.' })
+		if j < 0 {
+			j = len(rest) - i
+		}
+		value, unit := rest[:i], rest[i:i+j]
+		rest = rest[i+j:]
+
+		switch unit {
+		case "d", "w", "mo":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return period{}, fmt.Errorf("%w: %q, days, weeks and months must be whole numbers", ErrInvalidPeriod, s)
+			}
+			switch unit {
+			case "d":
+				p.days += n
+			case "w":
+				p.days += 7 * n
+			case "mo":
+				p.months += n
+			}
+		default:
+			d, err := time.ParseDuration(value + unit)
+			if err != nil {
+				return period{}, fmt.Errorf("%w: %q", ErrInvalidPeriod, s)
+			}
+			p.duration += d
+		}
+	}
+
+	if p == (period{}) {
+		return period{}, fmt.Errorf("%w: %q must be positive", ErrInvalidPeriod, s)
+	}
+
+	return p, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidTime, s)
+	}
+	return t, nil
+}
+
+// Bounds of CacheTTL.
+const (
+	MinCacheTTL = time.Second
+	MaxCacheTTL = 5 * time.Minute
+)
+
+// settleAfter is how long after a minute ends its aggregate may still change.
+const settleAfter = 2 * time.Minute
+
+// CacheTTL is how long a result over rng may be served again. A range that
+// ends in the settled past does not change any more; otherwise one new minute
+// matters less the longer the range is, so the TTL is 1/720 of it: 5s for an
+// hour, 2m for a day.
+func CacheTTL(rng model.TimeRange, now time.Time) time.Duration {
+	if rng.To.Before(now.Add(-settleAfter)) {
+		return MaxCacheTTL
+	}
+	return min(MaxCacheTTL, max(MinCacheTTL, rng.Duration()/720))
+}
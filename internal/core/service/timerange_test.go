@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/core/model"
+)
+
+func TestParseRange(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	hour := time.Date(2024, 5, 9, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		period, from, to string
+		wantFrom, wantTo time.Time
+	}{
+		{name: "default", wantFrom: now.Add(-24 * time.Hour), wantTo: now},
+		{name: "period", period: "5m", wantFrom: now.Add(-5 * time.Minute), wantTo: now},
+		{name: "from", from: "2024-05-09T14:00:00Z", wantFrom: hour, wantTo: now},
+		{name: "from and to", from: "2024-05-09T14:00:00Z", to: "2024-05-09T15:00:00Z", wantFrom: hour, wantTo: hour.Add(time.Hour)},
+		{name: "unix milliseconds", from: "1715263200000", to: "1715266800000", wantFrom: hour, wantTo: hour.Add(time.Hour)},
+		{name: "period from", period: "1h", from: "2024-05-09T14:00:00Z", wantFrom: hour, wantTo: hour.Add(time.Hour)},
+		{name: "period to", period: "1h", to: "2024-05-09T15:00:00Z", wantFrom: hour, wantTo: hour.Add(time.Hour)},
+	}
+
+	for _, tt := range tests {
+		rng, err := ParseRange(tt.period, tt.from, tt.to, now)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if !rng.From.Equal(tt.wantFrom) || !rng.To.Equal(tt.wantTo) {
+			t.Errorf("%s: expected [%v, %v), got [%v, %v)", tt.name, tt.wantFrom, tt.wantTo, rng.From, rng.To)
+		}
+	}
+}
+
+func TestParseRange_Invalid(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		period, from, to string
+		want             error
+	}{
+		{name: "all three", period: "1h", from: "1715263200000", to: "1715266800000", want: ErrInvalidRange},
+		{name: "bad period", period: "soon", want: ErrInvalidPeriod},
+		{name: "negative period", period: "-1h", want: ErrInvalidPeriod},
+		{name: "bad from", from: "yesterday", want: ErrInvalidTime},
+		{name: "to before from", from: "1715266800000", to: "1715263200000", want: ErrInvalidRange},
+		{name: "from in the future", from: "2024-05-11T00:00:00Z", want: ErrInvalidRange},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseRange(tt.period, tt.from, tt.to, now)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestParseRange_Periods(t *testing.T) {
+	// A Thursday.
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	now := time.Date(2024, 5, 9, 1, 30, 0, 0, loc)
+
+	tests := []struct {
+		period   string
+		wantFrom time.Time
+	}{
+		{"7d", time.Date(2024, 5, 2, 1, 30, 0, 0, loc)},
+		{"2w", time.Date(2024, 4, 25, 1, 30, 0, 0, loc)},
+		{"1mo", time.Date(2024, 4, 9, 1, 30, 0, 0, loc)},
+		{"1d12h", time.Date(2024, 5, 7, 13, 30, 0, 0, loc)},
+		{"1.5h", time.Date(2024, 5, 9, 0, 0, 0, 0, loc)},
+		{PeriodToday, time.Date(2024, 5, 9, 0, 0, 0, 0, loc)},
+		{PeriodThisWeek, time.Date(2024, 5, 6, 0, 0, 0, 0, loc)},
+		{PeriodThisMonth, time.Date(2024, 5, 1, 0, 0, 0, 0, loc)},
+		{PeriodYTD, time.Date(2024, 1, 1, 0, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		rng, err := ParseRange(tt.period, "", "", now)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.period, err)
+			continue
+		}
+		if !rng.From.Equal(tt.wantFrom) || !rng.To.Equal(now) {
+			t.Errorf("%s: expected [%v, %v), got [%v, %v)", tt.period, tt.wantFrom, now, rng.From, rng.To)
+		}
+	}
+}
+
+func TestParseRange_CalendarDays(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	// The day before 2024-03-31 02:00 in Berlin is 23 hours long.
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, loc)
+	rng, err := ParseRange("1d", "", "", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rng.Duration(); got != 23*time.Hour {
+		t.Errorf("expected a 23h day, got %v", got)
+	}
+
+	// Sunday belongs to the week that started on Monday.
+	rng, err = ParseRange(PeriodThisWeek, "", "", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2024, 3, 25, 0, 0, 0, 0, loc); !rng.From.Equal(want) {
+		t.Errorf("expected week to start at %v, got %v", want, rng.From)
+	}
+}
+
+func TestParseRange_InvalidPeriods(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	for _, period := range []string{"1.5d", "d", "3x", "0d", "1y"} {
+		if _, err := ParseRange(period, "", "", now); !errors.Is(err, ErrInvalidPeriod) {
+			t.Errorf("%s: expected %v, got %v", period, ErrInvalidPeriod, err)
+		}
+	}
+
+	if _, err := ParseRange(PeriodToday, "1715263200000", "", now); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected calendar period with from to fail, got %v", err)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rng  model.TimeRange
+		want time.Duration
+	}{
+		{model.TimeRange{From: now.Add(-time.Minute), To: now}, MinCacheTTL},
+		{model.TimeRange{From: now.Add(-time.Hour), To: now}, 5 * time.Second},
+		{model.TimeRange{From: now.Add(-24 * time.Hour), To: now}, 2 * time.Minute},
+		{model.TimeRange{From: now.Add(-720 * time.Hour), To: now}, MaxCacheTTL},
+		{model.TimeRange{From: now.Add(-2 * time.Hour), To: now.Add(-time.Hour)}, MaxCacheTTL},
+	}
+	for _, tt := range tests {
+		if got := CacheTTL(tt.rng, now); got != tt.want {
+			t.Errorf("%v: expected %v, got %v", tt.rng.Duration(), tt.want, got)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"sync"
 	"time"
 
 	"marketflow/internal/core"
@@ -10,13 +11,23 @@ import (
 	"marketflow/pkg/conc"
 )
 
+// TradeObserver is notified once for every distinct tick that passes through
+// the worker pools.
+type TradeObserver interface {
+	Observe(exchange string, trade model.Trade)
+}
+
 type TradeHandler struct {
-	cache core.Cache
+	cache     core.Cache
+	observers []TradeObserver
+	recent    *recentTicks
 }
 
-func NewTradeHandler(cache core.Cache) *TradeHandler {
+func NewTradeHandler(cache core.Cache, observers ...TradeObserver) *TradeHandler {
 	return &TradeHandler{
-		cache: cache,
+		cache:     cache,
+		observers: observers,
+		recent:    newRecentTicks(5 * time.Second),
 	}
 }
 
@@ -35,6 +46,12 @@ func (th *TradeHandler) Handle(q int, task conc.Task, result chan<- conc.Result)
 		return
 	}
 
+	if !th.recent.seen(task.From, data) {
+		for _, o := range th.observers {
+			o.Observe(task.From, data)
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -58,3 +75,60 @@ func (th *TradeHandler) Handle(q int, task conc.Task, result chan<- conc.Result)
 		Err:       nil,
 	}
 }
+
+type tickKey struct {
+	exchange  string
+	symbol    string
+	price     float64
+	timestamp int64
+}
+
+// recentTicks remembers the ticks seen during the last one to two rotation
+// periods. FanOut hands every tick to each worker pool, so the same trade is
+// handled several times; like the Redis sorted sets, ticks with the same
+// price and timestamp are treated as one.
+type recentTicks struct {
+	period time.Duration
+
+	mu       sync.Mutex
+	current  map[tickKey]struct{}
+	previous map[tickKey]struct{}
+	rotated  time.Time
+}
+
+func newRecentTicks(period time.Duration) *recentTicks {
+	return &recentTicks{
+		period:   period,
+		current:  make(map[tickKey]struct{}),
+		previous: make(map[tickKey]struct{}),
+		rotated:  time.Now(),
+	}
+}
+
+func (r *recentTicks) seen(exchange string, trade model.Trade) bool {
+	key := tickKey{
+		exchange:  exchange,
+		symbol:    trade.Symbol,
+		price:     trade.Price,
+		timestamp: trade.Timestamp,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := time.Now(); now.Sub(r.rotated) >= r.period {
+		r.previous = r.current
+		r.current = make(map[tickKey]struct{}, len(r.previous))
+		r.rotated = now
+	}
+
+	if _, ok := r.current[key]; ok {
+		return true
+	}
+	if _, ok := r.previous[key]; ok {
+		return true
+	}
+
+	r.current[key] = struct{}{}
+	return false
+}
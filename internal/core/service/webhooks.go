@@ -0,0 +1,773 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"marketflow/internal/core"
+	"marketflow/internal/core/model"
+)
+
+const WebhooksTicker = 1 * time.Second
+
+const (
+	// Headers sent with every delivery.
+	WebhookEventHeader     = "X-Marketflow-Event"
+	WebhookDeliveryHeader  = "X-Marketflow-Delivery"
+	WebhookSignatureHeader = "X-Marketflow-Signature"
+
+	// WebhookTestTopic is the type of the payload sent by Test.
+	WebhookTestTopic = "test"
+
+	maxWebhooks   = 100
+	maxWebhookURL = 2048
+
+	webhookTimeout = 10 * time.Second
+	// maxConcurrentDeliveries bounds the requests in flight at once.
+	maxConcurrentDeliveries = 8
+	// deliveryLease is how long a claimed delivery is held back from the
+	// other instances, longer than an attempt may take.
+	deliveryLease = 2 * webhookTimeout
+
+	maxDeliveryAttempts = 8
+	baseDeliveryBackoff = 5 * time.Second
+	maxDeliveryBackoff  = 10 * time.Minute
+
+	// A webhook whose deliveries failed breakerThreshold times in a row is
+	// not sent anything for breakerOpenFor, doubled every time the probe
+	// sent afterwards fails too, up to maxBreakerOpenFor.
+	breakerThreshold  = 5
+	breakerOpenFor    = time.Minute
+	maxBreakerOpenFor = 30 * time.Minute
+
+	webhookReloadInterval = time.Minute
+	// webhookSubscriberBuffer is large, as the subscription only falls
+	// behind while the deliveries are being queued.
+	webhookSubscriberBuffer = 4096
+	// maxPendingDeliveries bounds the deliveries held while the repository
+	// cannot be written to.
+	maxPendingDeliveries = 10000
+	// maxErrorLength bounds the error recorded for an attempt.
+	maxErrorLength = 500
+
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+// webhookTopics are the topics a webhook may subscribe to. Ticks come too
+// often to be posted one by one.
+var webhookTopics = []string{model.TopicAlerts, model.TopicCandles, model.TopicConsensus}
+
+// conflatedTopics are posted at most once per symbol and interval, with the
+// first event of the interval. A consensus price is published on every tick.
+var conflatedTopics = map[string]time.Duration{
+	model.TopicConsensus: time.Minute,
+}
+
+type conflationKey struct {
+	topic  string
+	symbol string
+}
+
+var (
+	ErrInvalidWebhook  = model.NewError(model.KindInvalid, "invalid_webhook", "invalid webhook")
+	ErrUnknownWebhook  = model.NewError(model.KindNotFound, "unknown_webhook", "no webhook with this id")
+	ErrTooManyWebhooks = model.NewError(model.KindConflict, "too_many_webhooks", fmt.Sprintf("at most %d webhooks can be defined", maxWebhooks))
+)
+
+// WebhookPayload is the body posted for every event.
+type WebhookPayload struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data"`
+}
+
+// breaker is the circuit breaker of a webhook.
+type breaker struct {
+	failures  int
+	openFor   time.Duration
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *breaker) state(now time.Time) string {
+	switch {
+	case b.failures < breakerThreshold:
+		return model.CircuitClosed
+	case now.Before(b.openUntil):
+		return model.CircuitOpen
+	default:
+		return model.CircuitHalfOpen
+	}
+}
+
+// allow reports whether a delivery may be sent now. Once the breaker is half
+// open, a single delivery is let through to probe the endpoint.
+func (b *breaker) allow(now time.Time) bool {
+	switch b.state(now) {
+	case model.CircuitClosed:
+		return true
+	case model.CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return false
+}
+
+func (b *breaker) success() {
+	*b = breaker{}
+}
+
+func (b *breaker) failure(now time.Time) {
+	b.failures++
+	b.probing = false
+	if b.failures < breakerThreshold {
+		return
+	}
+	if b.openFor == 0 {
+		b.openFor = breakerOpenFor
+	} else {
+		b.openFor = min(2*b.openFor, maxBreakerOpenFor)
+	}
+	b.openUntil = now.Add(b.openFor)
+}
+
+type webhook struct {
+	model.Webhook
+	filter  Filter
+	breaker breaker
+}
+
+// Webhooks posts the events of the broadcaster to the webhooks subscribed to
+// them. Every delivery is written to the repository first, then sent and
+// retried with an exponential backoff until the endpoint accepts it, refuses
+// it for good or the attempts run out. Endpoints that keep failing are left
+// alone for a while by their circuit breaker.
+type Webhooks struct {
+	repo        core.WebhookRepository
+	broadcaster *Broadcaster
+	encode      func(model.Event) (any, bool)
+	client      *http.Client
+	now         func() time.Time
+
+	mu        sync.Mutex
+	loaded    bool
+	webhooks  map[int64]*webhook
+	pending   []model.WebhookDelivery
+	conflated map[conflationKey]time.Time
+
+	inflight chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewWebhooks delivers the events of broadcaster, with encode turning their
+// payloads into the data of the body.
+func NewWebhooks(repo core.WebhookRepository, broadcaster *Broadcaster, encode func(model.Event) (any, bool)) *Webhooks {
+	return &Webhooks{
+		repo:        repo,
+		broadcaster: broadcaster,
+		encode:      encode,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now:       time.Now,
+		webhooks:  make(map[int64]*webhook),
+		conflated: make(map[conflationKey]time.Time),
+		inflight:  make(chan struct{}, maxConcurrentDeliveries),
+	}
+}
+
+func (w *Webhooks) Start(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	reload := time.NewTicker(webhookReloadInterval)
+	defer reload.Stop()
+	defer w.wg.Wait()
+
+	if err := w.load(ctx); err != nil {
+		slog.Error("failed to load webhooks", "error", err)
+	}
+
+	sub := w.subscribe()
+	defer func() {
+		if sub != nil {
+			w.broadcaster.Unsubscribe(sub)
+		}
+	}()
+
+	for {
+		var events <-chan model.Event
+		if sub != nil {
+			events = sub.Events()
+		}
+
+		select {
+		case e, ok := <-events:
+			if ok {
+				w.enqueue(e)
+				continue
+			}
+			if !sub.Evicted() {
+				sub = nil
+				continue
+			}
+			slog.Warn("webhook subscription fell behind, events were skipped")
+			sub = w.subscribe()
+		case <-ticker.C:
+			wCtx, cancel := context.WithTimeout(ctx, interval)
+			w.flush(wCtx)
+			cancel()
+			w.dispatch(ctx)
+		case <-reload.C:
+			wCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			if err := w.load(wCtx); err != nil {
+				slog.Error("failed to reload webhooks", "error", err)
+			}
+			cancel()
+		case <-ctx.Done():
+			wCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			w.flush(wCtx)
+			cancel()
+			return nil
+		}
+	}
+}
+
+func (w *Webhooks) subscribe() *Subscriber {
+	return w.broadcaster.SubscribeFunc(func(e model.Event) bool {
+		return slices.Contains(webhookTopics, e.Topic)
+	}, webhookSubscriberBuffer)
+}
+
+// enqueue queues a delivery of e to every webhook subscribed to it.
+func (w *Webhooks) enqueue(e model.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.conflate(&e) {
+		return
+	}
+
+	var payload []byte
+	now := w.now()
+	for _, hook := range w.webhooks {
+		if !hook.filter.Match(e) {
+			continue
+		}
+		if payload == nil {
+			data, ok := w.encode(e)
+			if !ok {
+				return
+			}
+			var err error
+			payload, err = json.Marshal(WebhookPayload{Type: e.Topic, Timestamp: e.Time, Data: data})
+			if err != nil {
+				slog.Error("failed to encode webhook payload", "topic", e.Topic, "error", err)
+				return
+			}
+		}
+		w.pending = append(w.pending, model.WebhookDelivery{
+			WebhookID:   hook.ID,
+			Topic:       e.Topic,
+			EventKey:    e.Key,
+			Payload:     payload,
+			Status:      model.DeliveryPending,
+			NextAttempt: now,
+			CreatedAt:   now,
+		})
+	}
+
+	if over := len(w.pending) - maxPendingDeliveries; over > 0 {
+		slog.Warn("dropping webhook deliveries that could not be saved", "count", over)
+		w.pending = slices.Delete(w.pending, 0, over)
+	}
+}
+
+// conflate reports whether e is the first event of its interval on a
+// conflated topic, or on another topic. The first event gets a key, so that
+// other instances do not post their own first event as well. It must be
+// called with mu held.
+func (w *Webhooks) conflate(e *model.Event) bool {
+	interval, ok := conflatedTopics[e.Topic]
+	if !ok {
+		return true
+	}
+
+	key := conflationKey{topic: e.Topic, symbol: e.Symbol}
+	bucket := e.Time.Truncate(interval)
+	if last, ok := w.conflated[key]; ok && !bucket.After(last) {
+		return false
+	}
+	w.conflated[key] = bucket
+	if e.Key == "" {
+		e.Key = e.Topic + ":" + e.Symbol + ":" + strconv.FormatInt(bucket.Unix(), 10)
+	}
+	return true
+}
+
+// flush writes the queued deliveries. They are put back if the write fails.
+func (w *Webhooks) flush(ctx context.Context) {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	if err := w.repo.InsertDeliveries(ctx, pending); err != nil {
+		slog.Error("failed to save webhook deliveries", "error", err)
+
+		w.mu.Lock()
+		w.pending = append(pending, w.pending...)
+		if over := len(w.pending) - maxPendingDeliveries; over > 0 {
+			w.pending = slices.Delete(w.pending, 0, over)
+		}
+		w.mu.Unlock()
+	}
+}
+
+// dispatch claims as many due deliveries as there are free slots and sends
+// each of them in the background.
+func (w *Webhooks) dispatch(ctx context.Context) {
+	free := cap(w.inflight) - len(w.inflight)
+	if free == 0 {
+		return
+	}
+
+	claimCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	deliveries, err := w.repo.ClaimDeliveries(claimCtx, w.now(), free, deliveryLease)
+	cancel()
+	if err != nil {
+		slog.Error("failed to claim webhook deliveries", "error", err)
+		return
+	}
+
+	reloaded := false
+	for _, d := range deliveries {
+		w.mu.Lock()
+		_, known := w.webhooks[d.WebhookID]
+		w.mu.Unlock()
+		if !known && !reloaded {
+			// The webhook may have been created by another instance.
+			reloaded = true
+			if err := w.load(ctx); err != nil {
+				slog.Error("failed to reload webhooks", "error", err)
+			}
+		}
+
+		w.inflight <- struct{}{}
+		w.wg.Add(1)
+		go func() {
+			defer func() {
+				<-w.inflight
+				w.wg.Done()
+			}()
+			w.attempt(ctx, d)
+		}()
+	}
+}
+
+// attempt sends d once and records the outcome. The outcome is recorded even
+// when ctx is done by then.
+func (w *Webhooks) attempt(ctx context.Context, d model.WebhookDelivery) {
+	w.mu.Lock()
+	hook, ok := w.webhooks[d.WebhookID]
+	var target model.Webhook
+	var allowed bool
+	if ok {
+		target = hook.Webhook
+		allowed = hook.breaker.allow(w.now())
+	}
+	w.mu.Unlock()
+
+	if !ok {
+		d.Status = model.DeliveryFailed
+		d.Error = "webhook deleted"
+		d.NextAttempt = time.Time{}
+		w.update(ctx, d)
+		return
+	}
+	if !allowed {
+		// The breaker is open: try again once it half opens, without
+		// spending an attempt.
+		w.mu.Lock()
+		next := hook.breaker.openUntil
+		w.mu.Unlock()
+		d.NextAttempt = later(next, w.now().Add(baseDeliveryBackoff))
+		w.update(ctx, d)
+		return
+	}
+
+	status, err := w.send(ctx, target, d)
+	if ctx.Err() != nil {
+		// Shutting down: the delivery is sent again once its lease is over.
+		return
+	}
+	now := w.now()
+	success := err == nil && status >= 200 && status < 300
+
+	w.mu.Lock()
+	if success {
+		hook.breaker.success()
+	} else {
+		hook.breaker.failure(now)
+	}
+	w.mu.Unlock()
+
+	d.Attempts++
+	d.StatusCode = status
+	d.Error = deliveryError(status, err)
+	switch {
+	case success:
+		d.Status = model.DeliverySucceeded
+		d.NextAttempt = time.Time{}
+	case permanent(status) || d.Attempts >= maxDeliveryAttempts:
+		d.Status = model.DeliveryFailed
+		d.NextAttempt = time.Time{}
+		slog.Warn("webhook delivery failed", "webhook", d.WebhookID, "delivery", d.ID, "attempts", d.Attempts, "error", d.Error)
+	default:
+		d.NextAttempt = now.Add(backoff(d.Attempts))
+	}
+	w.update(ctx, d)
+}
+
+func (w *Webhooks) update(ctx context.Context, d model.WebhookDelivery) {
+	uCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := w.repo.UpdateDelivery(uCtx, d); err != nil {
+		slog.Error("failed to update webhook delivery", "delivery", d.ID, "error", err)
+	}
+}
+
+// send posts the payload of d to hook. It returns the status code of the
+// response, or an error when there was none.
+func (w *Webhooks) send(ctx context.Context, hook model.Webhook, d model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "marketflow-webhooks")
+	req.Header.Set(WebhookEventHeader, d.Topic)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(WebhookSignatureHeader, Sign(hook.Secret, w.now(), d.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header of a payload sent at t: the time in Unix
+// seconds and the hex HMAC-SHA256 of "<time>.<payload>" keyed with secret.
+func Sign(secret string, t time.Time, payload []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff is the wait after the given number of failed attempts.
+func backoff(attempts int) time.Duration {
+	d := baseDeliveryBackoff
+	for i := 1; i < attempts && d < maxDeliveryBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxDeliveryBackoff)
+}
+
+// permanent reports whether the endpoint refused a delivery in a way that
+// retrying will not change. Timeouts and rate limits are worth retrying.
+func permanent(status int) bool {
+	return status >= 300 && status < 500 &&
+		status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+func deliveryError(status int, err error) string {
+	var msg string
+	switch {
+	case err != nil:
+		msg = err.Error()
+	case status < 200 || status >= 300:
+		msg = fmt.Sprintf("unexpected status %d", status)
+	}
+	if len(msg) > maxErrorLength {
+		msg = msg[:maxErrorLength]
+	}
+	return msg
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// load replaces the webhooks with the ones in the repository. Webhooks this
+// instance already knows keep their breakers.
+func (w *Webhooks) load(ctx context.Context) error {
+	webhooks, err := w.repo.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	loaded := make(map[int64]*webhook, len(webhooks))
+	for _, hook := range webhooks {
+		loaded[hook.ID] = w.webhook(hook)
+	}
+	w.webhooks = loaded
+	w.loaded = true
+	return nil
+}
+
+// webhook wraps hook, keeping the breaker of the webhook it replaces. It must
+// be called with mu held.
+func (w *Webhooks) webhook(hook model.Webhook) *webhook {
+	wh := &webhook{
+		Webhook: hook,
+		filter:  Filter{Topics: hook.Topics, Symbols: hook.Symbols, Exchanges: hook.Exchanges},
+	}
+	if current, ok := w.webhooks[hook.ID]; ok {
+		wh.breaker = current.breaker
+	}
+	return wh
+}
+
+// Webhooks returns every webhook with the state of its breaker, ordered by
+// id. Secrets are left out.
+func (w *Webhooks) Webhooks(ctx context.Context) ([]model.Webhook, error) {
+	if err := w.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	webhooks := make([]model.Webhook, 0, len(w.webhooks))
+	for _, hook := range w.webhooks {
+		h := hook.Webhook
+		h.Secret = ""
+		h.Circuit = hook.breaker.state(now)
+		webhooks = append(webhooks, h)
+	}
+	slices.SortFunc(webhooks, func(a, b model.Webhook) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return webhooks, nil
+}
+
+func (w *Webhooks) ensureLoaded(ctx context.Context) error {
+	w.mu.Lock()
+	loaded := w.loaded
+	w.mu.Unlock()
+	if loaded {
+		return nil
+	}
+	return w.load(ctx)
+}
+
+// Create validates hook, generates its secret and stores it. The secret is
+// only ever returned here.
+func (w *Webhooks) Create(ctx context.Context, hook model.Webhook) (model.Webhook, error) {
+	hook, err := normalizeWebhook(hook)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	webhooks, err := w.Webhooks(ctx)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+	if len(webhooks) >= maxWebhooks {
+		return model.Webhook{}, ErrTooManyWebhooks
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return model.Webhook{}, fmt.Errorf("generate webhook secret: %w", err)
+	}
+	hook.Secret = "whsec_" + hex.EncodeToString(secret)
+
+	hook, err = w.repo.CreateWebhook(ctx, hook)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	w.mu.Lock()
+	w.webhooks[hook.ID] = w.webhook(hook)
+	w.mu.Unlock()
+
+	hook.Circuit = model.CircuitClosed
+	return hook, nil
+}
+
+// Delete removes a webhook. Its pending deliveries fail when they come due.
+func (w *Webhooks) Delete(ctx context.Context, id int64) error {
+	err := w.repo.DeleteWebhook(ctx, id)
+	if model.KindOf(err) == model.KindNotFound {
+		return fmt.Errorf("%w: %d", ErrUnknownWebhook, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	delete(w.webhooks, id)
+	w.mu.Unlock()
+	return nil
+}
+
+// Test sends a test event to a webhook right away and returns the logged
+// delivery. It is sent even while the breaker is open and is not retried; a
+// success closes the breaker.
+func (w *Webhooks) Test(ctx context.Context, id int64) (model.WebhookDelivery, error) {
+	hook, err := w.find(ctx, id)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	now := w.now()
+	payload, err := json.Marshal(WebhookPayload{
+		Type:      WebhookTestTopic,
+		Timestamp: now,
+		Data:      map[string]int64{"webhook_id": id},
+	})
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	d, err := w.repo.CreateDelivery(ctx, model.WebhookDelivery{
+		WebhookID: id,
+		Topic:     WebhookTestTopic,
+		Payload:   payload,
+		Status:    model.DeliveryPending,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	status, err := w.send(ctx, hook, d)
+	d.Attempts = 1
+	d.StatusCode = status
+	d.Error = deliveryError(status, err)
+	d.Status = model.DeliveryFailed
+	if err == nil && status >= 200 && status < 300 {
+		d.Status = model.DeliverySucceeded
+		w.mu.Lock()
+		if current, ok := w.webhooks[id]; ok {
+			current.breaker.success()
+		}
+		w.mu.Unlock()
+	}
+
+	if err := w.repo.UpdateDelivery(ctx, d); err != nil {
+		return model.WebhookDelivery{}, err
+	}
+	d.UpdatedAt = w.now()
+	return d, nil
+}
+
+// Deliveries returns the latest deliveries of a webhook, newest first.
+func (w *Webhooks) Deliveries(ctx context.Context, id int64, limit int) ([]model.WebhookDelivery, error) {
+	if _, err := w.find(ctx, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultDeliveriesLimit
+	}
+	limit = min(limit, maxDeliveriesLimit)
+	return w.repo.ListDeliveries(ctx, id, limit)
+}
+
+// find returns the webhook with id, reading the webhooks again if this
+// instance does not know it yet.
+func (w *Webhooks) find(ctx context.Context, id int64) (model.Webhook, error) {
+	if err := w.ensureLoaded(ctx); err != nil {
+		return model.Webhook{}, err
+	}
+
+	for reloaded := false; ; reloaded = true {
+		w.mu.Lock()
+		hook, ok := w.webhooks[id]
+		w.mu.Unlock()
+		if ok {
+			return hook.Webhook, nil
+		}
+		if reloaded {
+			return model.Webhook{}, fmt.Errorf("%w: %d", ErrUnknownWebhook, id)
+		}
+		if err := w.load(ctx); err != nil {
+			return model.Webhook{}, err
+		}
+	}
+}
+
+// normalizeWebhook checks a new webhook. It subscribes to alerts unless told
+// otherwise.
+func normalizeWebhook(hook model.Webhook) (model.Webhook, error) {
+	invalid := func(format string, args ...any) (model.Webhook, error) {
+		return model.Webhook{}, fmt.Errorf("%w: %s", ErrInvalidWebhook, fmt.Sprintf(format, args...))
+	}
+
+	if len(hook.URL) > maxWebhookURL {
+		return invalid("url must be at most %d characters", maxWebhookURL)
+	}
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return invalid("url must be an absolute http or https url")
+	}
+	if u.User != nil {
+		return invalid("url must not contain credentials")
+	}
+
+	if len(hook.Topics) == 0 {
+		hook.Topics = []string{model.TopicAlerts}
+	}
+	for _, topic := range hook.Topics {
+		if !slices.Contains(webhookTopics, topic) {
+			return invalid("unknown topic %q, expected one of %v", topic, webhookTopics)
+		}
+	}
+	hook.Topics = slices.Compact(slices.Sorted(slices.Values(hook.Topics)))
+
+	for _, v := range slices.Concat(hook.Symbols, hook.Exchanges) {
+		if v == "" {
+			return invalid("symbols and exchanges must not be empty")
+		}
+	}
+	return hook, nil
+}
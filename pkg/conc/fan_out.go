@@ -2,14 +2,30 @@ package conc
 
 import (
 	"context"
+	"strconv"
 	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+var fanOutDrops = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "marketflow_fanout_drops_total",
+	Help: "Tasks FanOut dropped because the destination was not ready, by destination index.",
+}, []string{"destination"})
+
+// FanOut copies every task of src to each destination that is ready to take
+// it, and drops it for the others.
 func FanOut(ctx context.Context, src <-chan Task, dests ...chan Task) {
 	var wg sync.WaitGroup
 
+	drops := make([]prometheus.Counter, len(dests))
+	for i := range dests {
+		drops[i] = fanOutDrops.WithLabelValues(strconv.Itoa(i))
+	}
+
 	for s := range src {
-		for _, d := range dests {
+		for i, d := range dests {
 			wg.Add(1)
 			go func(val Task, dest chan Task) {
 				defer wg.Done()
@@ -18,6 +34,7 @@ func FanOut(ctx context.Context, src <-chan Task, dests ...chan Task) {
 					return
 				case dest <- val:
 				default:
+					drops[i].Inc()
 				}
 			}(s, d)
 		}
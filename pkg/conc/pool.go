@@ -3,11 +3,59 @@ package conc
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"marketflow/internal/core/model"
+	"marketflow/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	queueWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "marketflow_pool_queue_wait_seconds",
+		Help:    "Time a task waited for a free worker of the pool.",
+		Buckets: metrics.DurationBuckets,
+	}, []string{"pool"})
+	handlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "marketflow_pool_handler_duration_seconds",
+		Help:    "Time the pool's handler took per task.",
+		Buckets: metrics.DurationBuckets,
+	}, []string{"pool"})
+
+	// pools lists the pools whose workers report their task counts.
+	poolsMu sync.Mutex
+	pools   []*Pool
 )
 
+func init() {
+	prometheus.MustRegister(taskCounts{
+		desc: prometheus.NewDesc("marketflow_pool_tasks_total", "Tasks processed per worker of the pool.", []string{"pool", "worker"}, nil),
+	})
+}
+
+// taskCounts reports the task counts the workers of every pool keep.
+type taskCounts struct {
+	desc *prometheus.Desc
+}
+
+func (c taskCounts) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c taskCounts) Collect(ch chan<- prometheus.Metric) {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	for _, p := range pools {
+		for _, w := range p.workers {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(w.CountTasks.Load()), p.Name, strconv.Itoa(w.ID))
+		}
+	}
+}
+
 type PoolHandler interface {
 	Handle(
 		workerID int,
@@ -21,6 +69,9 @@ type Pool struct {
 	Name    string
 	pool    chan *Worker
 	handler PoolHandler
+
+	wait     prometheus.Observer
+	duration prometheus.Observer
 }
 
 func NewWorkerPool(workers []*Worker, handler PoolHandler) *Pool {
@@ -31,17 +82,29 @@ func NewWorkerPool(workers []*Worker, handler PoolHandler) *Pool {
 	}
 }
 
+// Create makes the workers available. The pool reports its metrics under
+// the Name it has by then.
 func (p *Pool) Create() {
+	p.wait = queueWait.WithLabelValues(p.Name)
+	p.duration = handlerDuration.WithLabelValues(p.Name)
+	poolsMu.Lock()
+	pools = append(pools, p)
+	poolsMu.Unlock()
+
 	for _, worker := range p.workers {
 		p.pool <- worker
 	}
 }
 
 func (p *Pool) Work(task Task, result chan<- Result) {
+	queued := time.Now()
 	worker := <-p.pool
+	p.wait.Observe(time.Since(queued).Seconds())
 	go func(w *Worker, t Task, result chan<- Result) {
+		start := time.Now()
 		p.handler.Handle(w.ID, t, result)
-		w.CountTasks++
+		p.duration.Observe(time.Since(start).Seconds())
+		w.CountTasks.Add(1)
 		p.pool <- w
 	}(worker, task, result)
 }
@@ -1,11 +1,14 @@
 package conc
 
-import "fmt"
+import (
+	"fmt"
+	"sync/atomic"
+)
 
 type Worker struct {
 	ID         int
 	Name       string
-	CountTasks int
+	CountTasks atomic.Int64
 }
 
 func NewWorker(opts ...func(*Worker)) *Worker {
@@ -38,5 +41,5 @@ func WithName(name string) func(*Worker) {
 }
 
 func (w *Worker) Stat() string {
-	return fmt.Sprintf("Worker: %s | ID: %d | Tasks processed: %d", w.Name, w.ID, w.CountTasks)
+	return fmt.Sprintf("Worker: %s | ID: %d | Tasks processed: %d", w.Name, w.ID, w.CountTasks.Load())
 }
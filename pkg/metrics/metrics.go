@@ -0,0 +1,8 @@
+// Package metrics holds what the Prometheus metrics of the other packages
+// share. The metrics themselves are registered with the default registry of
+// client_golang, which /metrics serves.
+package metrics
+
+// DurationBuckets suit latencies from a tenth of a millisecond to ten
+// seconds.
+var DurationBuckets = []float64{0.0001, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}